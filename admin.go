@@ -0,0 +1,94 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"sync/atomic"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+var errReadOnly = errors.New("service is in read-only mode and no cached scorecard is available")
+
+// readOnly stops all outbound API calls and scans, seeded from READ_ONLY and toggled via /admin/readonly
+var readOnly atomic.Bool
+
+// readOnlyState is the body accepted and returned by the read-only admin endpoint
+type readOnlyState struct {
+	Enabled bool `json:"enabled"`
+}
+
+// readAdminToken is the backend of adminAuth, a variable so tests can make it fail
+var readAdminToken = func() (string, error) { return config.AdminToken, nil }
+
+// adminAuth guards the admin routes with the shared ADMIN_TOKEN, sent as X-Admin-Token.
+// The admin routes are disabled entirely when ADMIN_TOKEN is not set. When the token cannot
+// be read the "admin" fail mode decides, closed by default.
+func adminAuth(c *fiber.Ctx) error {
+	var token string
+	err := callBackend(func() (err error) {
+		token, err = readAdminToken()
+		return err
+	})
+	if err != nil {
+		if err := middlewareFailed("admin", err); err != nil {
+			return sendLookupError(c, err)
+		}
+		return c.Next()
+	}
+	if token == "" {
+		return fiber.NewError(fiber.StatusForbidden, "admin endpoints are disabled")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(c.Get("X-Admin-Token")), []byte(token)) != 1 {
+		return fiber.ErrUnauthorized
+	}
+	return c.Next()
+}
+
+// getReadOnly godoc
+// @Summary Get the read-only mode
+// @Description Report whether outbound API calls and scans are disabled
+// @Tags admin
+// @Produce json
+// @Success 200 {object} readOnlyState
+// @Router /admin/readonly [get]
+func getReadOnly(c *fiber.Ctx) error {
+	return c.JSON(readOnlyState{Enabled: readOnly.Load()})
+}
+
+// setReadOnly godoc
+// @Summary Set the read-only mode
+// @Description Enable or disable outbound API calls and scans without a redeploy
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param state body readOnlyState true "desired state"
+// @Success 200 {object} readOnlyState
+// @Failure 400
+// @Router /admin/readonly [put]
+func setReadOnly(c *fiber.Ctx) error {
+	var state readOnlyState
+	if err := c.BodyParser(&state); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	readOnly.Store(state.Enabled)
+	logger.Info("read-only mode changed", zap.Bool("enabled", state.Enabled))
+	return c.JSON(state)
+}
+
+// getCacheStats godoc
+// @Summary Get the cache statistics
+// @Description Report the entries, hits, misses, hit ratio, evictions and approximate memory of the
+// @Description CACHE_BACKEND cache, to tune the cache TTLs and CACHE_MAX_ENTRIES and CACHE_MAX_BYTES with.
+// @Description Hits and misses are counted by this instance since startup; with redis, memory and
+// @Description evictions are those of the whole Redis, and memcached reports neither nor its entries.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} CacheStats
+// @Router /admin/cache/stats [get]
+func getCacheStats(c *fiber.Ctx) error {
+	return c.JSON(cache.Stats())
+}
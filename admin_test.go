@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// setReadOnlyMode toggles read-only mode through the admin endpoint with token
+func setReadOnlyMode(t *testing.T, app *fiber.App, token string, enabled bool) int {
+	t.Helper()
+	body := `{"enabled":false}`
+	if enabled {
+		body = `{"enabled":true}`
+	}
+	req := httptest.NewRequest(fiber.MethodPut, "/admin/readonly", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set("X-Admin-Token", token)
+	status, _ := doRequest(t, app, req)
+	return status
+}
+
+func TestReadOnlyServesHitsAndRefusesMisses(t *testing.T) {
+	api := newFakeAPI(t)
+	api.serve("github.com/a/cached", resultJSON("github.com/a/cached", sha(1), 5, nil))
+	api.serve("github.com/a/missed", resultJSON("github.com/a/missed", sha(2), 5, nil))
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL, "ADMIN_TOKEN": "admin"})
+
+	if status, _ := get(t, app, "/msapi/scorecard/github.com/a/cached"); status != fiber.StatusOK {
+		t.Fatalf("status %d before read-only", status)
+	}
+	if status := setReadOnlyMode(t, app, "admin", true); status != fiber.StatusOK {
+		t.Fatalf("enabling read-only: status %d", status)
+	}
+
+	if status, body := get(t, app, "/msapi/scorecard/github.com/a/cached"); status != fiber.StatusOK {
+		t.Errorf("cached hit: status %d, body %s", status, body)
+	}
+	status, body := get(t, app, "/msapi/scorecard/github.com/a/missed")
+	if status != fiber.StatusServiceUnavailable || !strings.Contains(body, "READ_ONLY") {
+		t.Errorf("miss: status %d, body %s", status, body)
+	}
+	if n := api.called("github.com/a/missed"); n != 0 {
+		t.Errorf("the API was called %d times in read-only mode", n)
+	}
+
+	if status := setReadOnlyMode(t, app, "admin", false); status != fiber.StatusOK {
+		t.Fatalf("disabling read-only: status %d", status)
+	}
+	// a refresh, as lookups within COALESCE_WINDOW_MS would share the refused one
+	if status, _ := get(t, app, "/msapi/scorecard/github.com/a/missed?refresh=true"); status != fiber.StatusOK {
+		t.Errorf("after read-only: status %d", status)
+	}
+}
+
+func TestReadOnlyFromTheEnvironment(t *testing.T) {
+	app := newTestApp(t, map[string]string{"READ_ONLY": "true", "ADMIN_TOKEN": "admin"})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/admin/readonly", nil)
+	req.Header.Set("X-Admin-Token", "admin")
+	status, body := doRequest(t, app, req)
+	if status != fiber.StatusOK || !strings.Contains(body, `"enabled":true`) {
+		t.Errorf("status %d, body %s", status, body)
+	}
+	if status, _ := get(t, app, "/msapi/scorecard/github.com/a/b"); status != fiber.StatusServiceUnavailable {
+		t.Errorf("lookup: status %d", status)
+	}
+}
+
+func TestReadOnlyToggleNeedsTheAdminToken(t *testing.T) {
+	app := newTestApp(t, map[string]string{"ADMIN_TOKEN": "admin"})
+	if status := setReadOnlyMode(t, app, "wrong", true); status != fiber.StatusUnauthorized {
+		t.Errorf("wrong token: status %d", status)
+	}
+	if readOnly.Load() {
+		t.Error("a wrong token enabled read-only mode")
+	}
+
+	disabled := newTestApp(t, nil)
+	if status := setReadOnlyMode(t, disabled, "", true); status != fiber.StatusForbidden {
+		t.Errorf("without ADMIN_TOKEN: status %d", status)
+	}
+}
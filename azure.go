@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+const (
+	azureDevOpsHost   = "dev.azure.com"
+	legacyAzureSuffix = ".visualstudio.com"
+)
+
+// normalizeAzureDevOps rewrites the Azure DevOps url forms to dev.azure.com/org/project/repo:
+// the org@ user prefix is dropped, org.visualstudio.com (with or without DefaultCollection)
+// becomes dev.azure.com/org and the _git segment before the repo name is removed. When the
+// repo shares the project's name Azure allows dev.azure.com/org/_git/repo, which becomes
+// dev.azure.com/org/repo.
+func normalizeAzureDevOps(s string) string {
+	host, path, _ := strings.Cut(s, "/")
+	if _, after, found := strings.Cut(host, "@"); found {
+		host = after
+	}
+
+	lower := strings.ToLower(host)
+	switch {
+	case strings.HasSuffix(lower, legacyAzureSuffix):
+		org := host[:len(host)-len(legacyAzureSuffix)]
+		path = org + "/" + strings.TrimPrefix(path, "DefaultCollection/")
+	case lower != azureDevOpsHost:
+		return s
+	}
+	return azureDevOpsHost + "/" + strings.Replace(path, "/_git/", "/", 1)
+}
+
+// azureDevOpsCloneURL puts the _git segment back for cloning
+func azureDevOpsCloneURL(repoURL string) string {
+	parts := strings.Split(strings.TrimPrefix(repoURL, azureDevOpsHost+"/"), "/")
+	if len(parts) == 2 {
+		return "https://" + azureDevOpsHost + "/" + parts[0] + "/_git/" + parts[1]
+	}
+	return "https://" + azureDevOpsHost + "/" + strings.Join(parts[:len(parts)-1], "/") + "/_git/" + parts[len(parts)-1]
+}
+
+// azureDevOpsAuth sends a personal access token as basic auth, the caller's token when
+// given and AZURE_DEVOPS_AUTH_TOKEN otherwise
+func azureDevOpsAuth(token string) transport.AuthMethod {
+	if token == "" {
+		token = config.AzureDevOpsToken
+	}
+	if token == "" {
+		return nil
+	}
+	return &http.BasicAuth{Username: "pat", Password: token}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// badgeLabel is the left hand text of every badge
+const badgeLabel = "scorecard"
+
+// badgeColor is the lowest aggregate that earns a color, as a shields.io color name and as
+// the hex value shields.io draws it with
+type badgeColor struct {
+	Name  string
+	Color string
+	Min   float64
+}
+
+// badgeColors follows the shields.io scale from the best score down
+var badgeColors = []badgeColor{
+	{"brightgreen", "#4c1", 8},
+	{"green", "#97ca00", 6},
+	{"yellow", "#dfb317", 4},
+	{"orange", "#fe7d37", 2},
+	{"red", "#e05d44", 0},
+}
+
+// badgeGrey is the color of a badge without a score
+var badgeGrey = badgeColor{Name: "lightgrey", Color: "#9f9f9f"}
+
+// scoreColor is the badge color of an aggregate; a negative aggregate has no score and is grey
+func scoreColor(aggregate float64) badgeColor {
+	if aggregate < 0 {
+		return badgeGrey
+	}
+	for _, c := range badgeColors {
+		if aggregate >= c.Min {
+			return c
+		}
+	}
+	return badgeColors[len(badgeColors)-1]
+}
+
+// scoreMessage is the badge text of an aggregate, e.g. 7.3/10, or N/A without one
+func scoreMessage(aggregate float64) string {
+	if aggregate < 0 {
+		return gradeNA
+	}
+	return strconv.FormatFloat(aggregate, 'f', 1, 64) + "/10"
+}
+
+// textWidth approximates the width in pixels of s in 11px Verdana, which is wide enough
+// for the digits, letters and punctuation a badge shows
+func textWidth(s string) int {
+	return len(s)*7 + 10
+}
+
+// renderBadge draws a flat shields.io style badge
+func renderBadge(label, message, color string) string {
+	labelWidth, messageWidth := textWidth(label), textWidth(message)
+	width := labelWidth + messageWidth
+	label, message = html.EscapeString(label), html.EscapeString(message)
+
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[2]s: %[3]s">`+
+		`<title>%[2]s: %[3]s</title>`+
+		`<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`+
+		`<clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>`+
+		`<g clip-path="url(#r)"><rect width="%[4]d" height="20" fill="#555"/><rect x="%[4]d" width="%[5]d" height="20" fill="%[6]s"/><rect width="%[1]d" height="20" fill="url(#s)"/></g>`+
+		`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`+
+		`<text x="%[7]d" y="15" fill="#010101" fill-opacity=".3">%[2]s</text><text x="%[7]d" y="14">%[2]s</text>`+
+		`<text x="%[8]d" y="15" fill="#010101" fill-opacity=".3">%[3]s</text><text x="%[8]d" y="14">%[3]s</text>`+
+		`</g></svg>`,
+		width, label, message, labelWidth, messageWidth, color, labelWidth/2, labelWidth+messageWidth/2)
+}
+
+// sendBadge writes a badge, cached by browsers and image proxies for cacheFor seconds
+func sendBadge(c *fiber.Ctx, message, color string, cacheFor int) error {
+	c.Set(fiber.HeaderContentType, "image/svg+xml; charset=utf-8")
+	if cacheFor > 0 {
+		c.Set(fiber.HeaderCacheControl, "public, max-age="+strconv.Itoa(cacheFor))
+	} else {
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+	}
+	return c.SendString(renderBadge(badgeLabel, message, color))
+}
+
+// getBadge godoc
+// @Summary Get an SVG badge of the OSSF scorecard score
+// @Description Render the aggregate score as a badge for READMEs and the Ortelius UI, green from 8,
+// @Description yellow from 4 and red below 2. The badge is served with a public Cache-Control of
+// @Description BADGE_MAX_AGE so image proxies and browsers do not look the repo up on every page
+// @Description view. A repo that cannot be scored gets a grey badge that is not cached.
+// @Tags scorecard
+// @Produce image/svg+xml
+// @Param commit query string false "commit sha"
+// @Param ref query string false "branch or tag to score, resolved to its commit through the forge"
+// @Param latest query bool false "ignore commit and ref and use the latest, unpinned result"
+// @Param prefer query string false "which source to try first; defaults to PREFER_SOURCE or api" Enums(api, cli)
+// @Param refresh query bool false "skip the cache and the lookups shared within COALESCE_WINDOW_MS and fetch the scorecard again"
+// @Success 200 {string} string "SVG badge"
+// @Router /msapi/scorecard/:key/badge [get]
+func getBadge(c *fiber.Ctx) error {
+	prefer, err := preference(c)
+	if err != nil {
+		return err
+	}
+
+	githubURL, commitSha, err := lookupKey(c)
+	if err != nil {
+		return sendBadgeError(c, err)
+	}
+
+	result, _, err := requestLookup(c, githubURL, commitSha, prefer)
+	if err != nil {
+		return sendBadgeError(c, err)
+	}
+
+	recordScored(githubURL)
+	aggregate := float64(result.AggregateScore)
+	return sendBadge(c, scoreMessage(aggregate), scoreColor(aggregate).Color, int(config.BadgeMaxAge.Seconds()))
+}
+
+// sendBadgeError writes the grey badge of a failed lookup. It is still a 200 so READMEs
+// and image proxies show it, rather than a broken image.
+func sendBadgeError(c *fiber.Ctx, err error) error {
+	return sendBadge(c, badgeErrorMessage(err), badgeGrey.Color, 0)
+}
+
+// badgeErrorMessage is the badge text of a failed lookup
+func badgeErrorMessage(err error) string {
+	if errors.Is(err, errScorecardProcessing) {
+		return "pending"
+	}
+	return "unknown"
+}
+
+// shieldsEndpoint is the shields.io endpoint badge schema,
+// https://shields.io/badges/endpoint-badge
+type shieldsEndpoint struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+	IsError       bool   `json:"isError,omitempty"`
+	CacheSeconds  int    `json:"cacheSeconds,omitempty"`
+}
+
+// getShield godoc
+// @Summary Get the OSSF scorecard score as a shields.io endpoint badge
+// @Description Describe the score badge in the shields.io endpoint schema, for
+// @Description https://img.shields.io/endpoint?url=..., with the colors of the SVG badge. cacheSeconds
+// @Description is BADGE_MAX_AGE, and a repo that cannot be scored is returned as an isError badge.
+// @Tags scorecard
+// @Produce json
+// @Param commit query string false "commit sha"
+// @Param ref query string false "branch or tag to score, resolved to its commit through the forge"
+// @Param latest query bool false "ignore commit and ref and use the latest, unpinned result"
+// @Param prefer query string false "which source to try first; defaults to PREFER_SOURCE or api" Enums(api, cli)
+// @Param refresh query bool false "skip the cache and the lookups shared within COALESCE_WINDOW_MS and fetch the scorecard again"
+// @Success 200 {object} shieldsEndpoint
+// @Router /msapi/scorecard/:key/shield [get]
+func getShield(c *fiber.Ctx) error {
+	prefer, err := preference(c)
+	if err != nil {
+		return err
+	}
+
+	githubURL, commitSha, err := lookupKey(c)
+	if err != nil {
+		return sendShieldError(c, err)
+	}
+
+	result, _, err := requestLookup(c, githubURL, commitSha, prefer)
+	if err != nil {
+		return sendShieldError(c, err)
+	}
+
+	recordScored(githubURL)
+	aggregate := float64(result.AggregateScore)
+	return c.JSON(shieldsEndpoint{
+		SchemaVersion: 1,
+		Label:         badgeLabel,
+		Message:       scoreMessage(aggregate),
+		Color:         scoreColor(aggregate).Name,
+		CacheSeconds:  int(config.BadgeMaxAge.Seconds()),
+	})
+}
+
+// sendShieldError describes the grey badge of a failed lookup, with a 200 as shields.io
+// requires to render it
+func sendShieldError(c *fiber.Ctx, err error) error {
+	return c.JSON(shieldsEndpoint{
+		SchemaVersion: 1,
+		Label:         badgeLabel,
+		Message:       badgeErrorMessage(err),
+		Color:         badgeGrey.Name,
+		IsError:       true,
+	})
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// batchItem is one repo, and optionally a commit or a branch or tag, to score in a batch
+type batchItem struct {
+	Repo   string `json:"repo"`
+	Commit string `json:"commit,omitempty"`
+	Ref    string `json:"ref,omitempty"`
+}
+
+// batchResult is the outcome for one batch item, in the position the item was given.
+// Exactly one of Scorecard and Error is set.
+type batchResult struct {
+	Repo      string             `json:"repo"`
+	Commit    string             `json:"commit,omitempty"`
+	Scorecard *scorecardResponse `json:"scorecard,omitempty"`
+	Error     *errorResponse     `json:"error,omitempty"`
+}
+
+// getBatch godoc
+// @Summary Get OSSF scorecards for many repos
+// @Description Score a list of repo and commit pairs in one call. Lookups run concurrently, at most
+// @Description BATCH_CONCURRENCY at a time and SCORECARD_MAX_CONCURRENCY across all batch, SBOM,
+// @Description compare and diff requests, and results are returned in the order given.
+// @Description A lookup that fails reports its error code in place of the scorecard.
+// @Tags scorecard
+// @Accept json
+// @Produce json
+// @Param items body []batchItem true "repos to score"
+// @Param verbose query bool false "include per-check scores, reasons and documentation links"
+// @Param details query bool false "list the checks as verbose does, each with its details"
+// @Param aggregate_present query bool false "add aggregate_present, the equal-weighted mean of the checks that ran"
+// @Param provenance query bool false "add meta with the scored repo, commit, analysis date and scorecard version"
+// @Param include_grade query bool false "add grade, the aggregate as a letter on the GRADE_THRESHOLDS scale or N/A without an aggregate"
+// @Param risk query string false "list only the checks in these comma separated risk tiers, e.g. Critical,High"
+// @Param include_unmapped query bool false "add unmapped_checks, the scores of checks with no scorecard field; defaults to INCLUDE_UNMAPPED_CHECKS"
+// @Param X-Repo-Token header string false "forge token used instead of the configured one to scan a private repo; Authorization: Bearer also works"
+// @Success 200 {array} batchResult
+// @Failure 400
+// @Failure 413
+// @Router /msapi/scorecard/batch [post]
+func getBatch(c *fiber.Ctx) error {
+	var items []batchItem
+	if err := c.BodyParser(&items); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if len(items) > config.BatchMaxItems {
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, "batch exceeds BATCH_MAX_ITEMS")
+	}
+
+	opts, err := parseResponseOptions(c)
+	if err != nil {
+		return err
+	}
+
+	token := requestToken(c)
+	results := make([]batchResult, len(items))
+	runConcurrently(len(items), config.BatchConcurrency, func(i int) {
+		results[i] = scoreBatchItem(items[i], token, opts)
+	})
+	return c.JSON(results)
+}
+
+// batchWorkers is shared by every runConcurrently, so however many batch-like requests
+// arrive at once no more than SCORECARD_MAX_CONCURRENCY of their lookups run. It is rebuilt
+// by setupRoutes.
+var batchWorkers = newOutboundLimiter(config.ScorecardMaxConcurrency)
+
+// runConcurrently calls fn for 0 to n-1 from at most workers goroutines and waits for all of
+// them. Each call also holds one of the SCORECARD_MAX_CONCURRENCY batchWorkers slots.
+func runConcurrently(n, workers int, fn func(i int)) {
+	work := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(n, workers); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				release := batchWorkers.acquire()
+				fn(i)
+				release()
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+}
+
+// scoreBatchItem looks up one batch item the same way getScorecard does, Go module paths
+// included, with the token passed with the batch request
+func scoreBatchItem(item batchItem, token string, opts responseOptions) batchResult {
+	out := batchResult{Repo: cleanRepoURL(item.Repo), Commit: item.Commit}
+	githubURL, _, err := repoForKey(item.Repo)
+	if err != nil {
+		return out.failed(err)
+	}
+	if item.Ref == "" {
+		return scoreRepo(githubURL, item.Commit, token, opts)
+	}
+
+	out.Repo = githubURL
+	commitSha, err := revision(githubURL, item.Commit, item.Ref, token)
+	if err != nil {
+		return out.failed(err)
+	}
+	return scoreRepo(githubURL, commitSha, token, opts)
+}
+
+// scoreRepo looks up a normalized repo for a batch or SBOM result
+func scoreRepo(githubURL, commitSha, token string, opts responseOptions) batchResult {
+	out := batchResult{Repo: githubURL, Commit: commitSha}
+
+	if err := validateRepoURL(githubURL); err != nil {
+		return out.failed(err)
+	}
+
+	result, source, err := coalescedLookup(lookupRequest{repo: githubURL, commit: commitSha, prefer: config.PreferSource, token: token})
+	if err != nil {
+		return out.failed(err)
+	}
+
+	recordScored(githubURL)
+	out.Scorecard = newResponse(result, commitSha, source, opts)
+	return out
+}
+
+func (r batchResult) failed(err error) batchResult {
+	_, code := lookupErrorStatus(err)
+	r.Error = &errorResponse{Code: code, Message: err.Error()}
+	return r
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// states of the circuit breaker, as the readiness endpoint reports them
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+var errCircuitOpen = fmt.Errorf("%w: the OpenSSF scorecard API keeps failing, calls to it are paused", errUpstream)
+
+// circuitBreaker stops calling the OpenSSF API after threshold failures in a row, so lookups
+// fail at once, and are answered from the cache, instead of each waiting out a timeout.
+// After cooldown one call is let through as a probe: a success closes the breaker and a
+// failure opens it for another cooldown.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	probing   bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration, now func() time.Time) *circuitBreaker {
+	return &circuitBreaker{now: now, threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a call may go out, claiming the probe when the cooldown is over
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.probing || b.now().Sub(b.openedAt) < b.cooldown {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record counts the outcome of a call; transport errors and 5xx responses are failures
+func (b *circuitBreaker) record(statusCode int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil && statusCode < fiber.StatusInternalServerError {
+		if b.failures >= b.threshold {
+			logger.Info("circuit breaker closed, calls to the OpenSSF API resume")
+		}
+		b.failures, b.probing = 0, false
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		if b.failures == b.threshold {
+			logger.Warn("circuit breaker opened, calls to the OpenSSF API are paused", zap.Duration("cooldown", b.cooldown))
+		}
+		b.openedAt, b.probing = b.now(), false
+	}
+}
+
+// state is closed, open, or half-open once the cooldown is over
+func (b *circuitBreaker) state() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case b.failures < b.threshold:
+		return breakerClosed
+	case b.probing || b.now().Sub(b.openedAt) >= b.cooldown:
+		return breakerHalfOpen
+	}
+	return breakerOpen
+}
+
+// breaker is rebuilt by setupRoutes with BREAKER_FAILURES and BREAKER_COOLDOWN
+var breaker = newCircuitBreaker(config.BreakerFailures, config.BreakerCooldown, time.Now)
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"container/list"
+	"encoding/json"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	ossf "github.com/ossf/scorecard/v5/pkg/scorecard"
+)
+
+// cache backends accepted by CACHE_BACKEND
+const (
+	cacheMemory    = "memory"
+	cacheRedis     = "redis"
+	cacheMemcached = "memcached"
+	cacheFile      = "file"
+)
+
+// cacheKeyPrefix namespaces the cache keys in a Redis or memcached shared with other services
+const cacheKeyPrefix = "scec-scorecard:"
+
+// CacheEntry is a cached lookup result, the source that produced it and when it goes stale,
+// or for a lookup that found no scorecard, the error code of the miss and no result
+type CacheEntry struct {
+	Result  *ossf.JSONScorecardResultV2 `json:"result"`
+	Source  string                      `json:"source"`
+	Expires time.Time                   `json:"expires"`
+	Miss    string                      `json:"miss,omitempty"`
+}
+
+// missErrors are the lookup errors cached as misses, by error code. They say the repo has no
+// scorecard to find, unlike upstream failures, which are never cached.
+var missErrors = map[string]error{
+	"NO_SCORECARD":           errNoScorecard,
+	"REPO_NOT_FOUND":         errRepoNotFound,
+	"SCORECARD_NOT_COMPUTED": errScorecardNotComputed,
+}
+
+// jitterTTL moves ttl by a random amount of up to CACHE_TTL_JITTER percent either way, so
+// the entries cached in a burst, by a batch say, do not all expire, and are fetched again,
+// at the same moment
+func jitterTTL(ttl time.Duration) time.Duration {
+	if config.CacheTTLJitter == 0 || ttl <= 0 {
+		return ttl
+	}
+	spread := float64(ttl) * float64(config.CacheTTLJitter) / 100
+	return ttl + time.Duration((rand.Float64()*2-1)*spread)
+}
+
+// missCode is the error code to cache err under, or "" when err is not a miss
+func missCode(err error) string {
+	_, code := lookupErrorStatus(err)
+	if _, ok := missErrors[code]; !ok {
+		return ""
+	}
+	return code
+}
+
+// Cache is where lookup results are kept between lookups. CACHE_BACKEND picks the
+// implementation; a backend that fails reports a miss so the lookup goes to the upstream.
+type Cache interface {
+	// Get returns the entry cached for key, if it has not expired
+	Get(key string) (CacheEntry, bool)
+	// Set caches entry for key for ttl, replacing any entry it had; a ttl of zero caches nothing
+	Set(key string, entry CacheEntry, ttl time.Duration)
+	// Invalidate drops the entry cached for key
+	Invalidate(key string)
+	// Purge drops every entry whose key starts with prefix, all of them for "", and returns how many
+	Purge(prefix string) int
+	// Stats reports the size of the cache and how well it has been answering
+	Stats() CacheStats
+}
+
+// CacheStats is the body returned by the cache stats admin endpoint. Hits and misses count
+// Get calls since startup; evictions count entries dropped before anyone purged them.
+type CacheStats struct {
+	Backend     string  `json:"backend"`
+	Entries     int     `json:"entries"`
+	Hits        int64   `json:"hits"`
+	Misses      int64   `json:"misses"`
+	HitRatio    float64 `json:"hit_ratio"`
+	Evictions   int64   `json:"evictions"`
+	MemoryBytes int64   `json:"memory_bytes"`
+}
+
+// cacheCounters counts the reads and evictions of a Cache
+type cacheCounters struct {
+	hits, misses, evictions atomic.Int64
+}
+
+// read counts a Get that found an entry, or did not, and passes the result on
+func (n *cacheCounters) read(entry CacheEntry, ok bool) (CacheEntry, bool) {
+	if ok {
+		n.hits.Add(1)
+	} else {
+		n.misses.Add(1)
+	}
+	return entry, ok
+}
+
+// stats fills in the counted fields of stats
+func (n *cacheCounters) stats(stats CacheStats) CacheStats {
+	stats.Hits, stats.Misses, stats.Evictions = n.hits.Load(), n.misses.Load(), n.evictions.Load()
+	if reads := stats.Hits + stats.Misses; reads > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(reads)
+	}
+	return stats
+}
+
+// minCacheSweep is the fewest entries the memory cache holds before it sweeps out expired ones
+const minCacheSweep = 64
+
+// memoryCache is the in-process Cache. Expired entries are dropped when read, and swept out
+// whenever the cache has doubled since the last sweep. Past maxEntries entries or maxBytes
+// bytes the least recently used entries are evicted, so a flood of unique repos cannot
+// grow it without bound.
+type memoryCache struct {
+	cacheCounters
+	mu         sync.Mutex
+	now        func() time.Time
+	entries    map[string]*list.Element
+	recent     *list.List // of *memoryEntry, most recently used first
+	nextSweep  int
+	size       int64
+	maxEntries int
+	maxBytes   int64
+}
+
+// memoryEntry is a cached entry with its key, its expiry and its approximate size: the key
+// and the entry as JSON
+type memoryEntry struct {
+	CacheEntry
+	key     string
+	expires time.Time
+	size    int64
+}
+
+func newMemoryCache(now func() time.Time, maxEntries int, maxBytes int64) *memoryCache {
+	return &memoryCache{
+		now:        now,
+		entries:    make(map[string]*list.Element),
+		recent:     list.New(),
+		nextSweep:  minCacheSweep,
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+	}
+}
+
+func (c *memoryCache) Get(key string) (CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return c.read(CacheEntry{}, false)
+	}
+	entry := elem.Value.(*memoryEntry)
+	if !c.now().Before(entry.expires) {
+		c.drop(elem)
+		c.evictions.Add(1)
+		return c.read(CacheEntry{}, false)
+	}
+	c.recent.MoveToFront(elem)
+	return c.read(entry.CacheEntry, true)
+}
+
+func (c *memoryCache) Set(key string, entry CacheEntry, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	data, _ := json.Marshal(entry)
+	size := int64(len(key) + len(data))
+	if size > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if elem, ok := c.entries[key]; ok {
+		c.drop(elem)
+	}
+	c.entries[key] = c.recent.PushFront(&memoryEntry{CacheEntry: entry, key: key, expires: now.Add(ttl), size: size})
+	c.size += size
+	for len(c.entries) > c.maxEntries || c.size > c.maxBytes {
+		c.drop(c.recent.Back())
+		c.evictions.Add(1)
+	}
+	if len(c.entries) < c.nextSweep {
+		return
+	}
+
+	for _, elem := range c.entries {
+		if !now.Before(elem.Value.(*memoryEntry).expires) {
+			c.drop(elem)
+			c.evictions.Add(1)
+		}
+	}
+	c.nextSweep = max(2*len(c.entries), minCacheSweep)
+}
+
+func (c *memoryCache) Invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.drop(elem)
+	}
+}
+
+// drop removes the entry of elem, with c.mu held
+func (c *memoryCache) drop(elem *list.Element) {
+	entry := c.recent.Remove(elem).(*memoryEntry)
+	c.size -= entry.size
+	delete(c.entries, entry.key)
+}
+
+func (c *memoryCache) Purge(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	purged := 0
+	for key, elem := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.drop(elem)
+			purged++
+		}
+	}
+	return purged
+}
+
+func (c *memoryCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats(CacheStats{Backend: cacheMemory, Entries: len(c.entries), MemoryBytes: c.size})
+}
+
+// repoCachePrefix is the prefix of the cache keys of every commit of repo
+func repoCachePrefix(repo string) string {
+	return repo + "@"
+}
+
+// cache is rebuilt by setupRoutes with CACHE_BACKEND
+var cache Cache = newMemoryCache(time.Now, config.CacheMaxEntries, int64(config.CacheMaxBytes))
+
+// newCache is the Cache CACHE_BACKEND names
+func newCache(cfg *Config) Cache {
+	switch cfg.CacheBackend {
+	case cacheRedis:
+		return newRedisCache(cfg.Redis)
+	case cacheMemcached:
+		return newMemcachedCache(cfg.MemcachedServers)
+	case cacheFile:
+		return newFileCache(cfg.CacheDir, time.Now)
+	}
+	return newMemoryCache(time.Now, cfg.CacheMaxEntries, int64(cfg.CacheMaxBytes))
+}
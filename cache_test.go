@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// cacheBurst scores n repos in one batch and returns when each one's cached scorecard expires
+func cacheBurst(t *testing.T, jitter string, n int) []time.Time {
+	t.Helper()
+	api := newFakeAPI(t)
+	items := make([]batchItem, 0, n)
+	for i := 0; i < n; i++ {
+		repo := fmt.Sprintf("github.com/o/r%d", i)
+		api.serve(repo, resultJSON(repo, sha(i), 5, nil))
+		items = append(items, batchItem{Repo: repo})
+	}
+	app := newTestApp(t, map[string]string{
+		"SCORECARD_API_URLS":  api.URL,
+		"SCORECARD_CACHE_TTL": "1h",
+		"CACHE_TTL_JITTER":    jitter,
+		"BATCH_CONCURRENCY":   "16",
+	})
+
+	body, _ := json.Marshal(items)
+	req := httptest.NewRequest(fiber.MethodPost, "/msapi/scorecard/batch", strings.NewReader(string(body)))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if status, body := doRequest(t, app, req); status != fiber.StatusOK {
+		t.Fatalf("status %d, body %s", status, body)
+	}
+
+	expiries := make([]time.Time, 0, n)
+	for _, item := range items {
+		entry, ok := cache.Get(repoCachePrefix(item.Repo))
+		if !ok {
+			t.Fatalf("%s was not cached", item.Repo)
+		}
+		expiries = append(expiries, entry.Expires)
+	}
+	return expiries
+}
+
+// spread is the earliest and latest of times
+func spread(times []time.Time) (time.Time, time.Time) {
+	first, last := times[0], times[0]
+	for _, t := range times {
+		if t.Before(first) {
+			first = t
+		}
+		if t.After(last) {
+			last = t
+		}
+	}
+	return first, last
+}
+
+func TestJitterSpreadsTheExpiryOfABurst(t *testing.T) {
+	start := time.Now()
+	expiries := cacheBurst(t, "20", 50)
+	end := time.Now()
+
+	distinct := make(map[int64]bool)
+	for _, expires := range expiries {
+		if expires.Before(start.Add(48*time.Minute)) || expires.After(end.Add(72*time.Minute)) {
+			t.Errorf("expires %v after the burst, outside 1h ± 20%%", expires.Sub(start))
+		}
+		distinct[expires.Unix()] = true
+	}
+	if len(distinct) < 40 {
+		t.Errorf("only %d distinct expiry seconds among 50 entries", len(distinct))
+	}
+	if first, last := spread(expiries); last.Sub(first) < 10*time.Minute {
+		t.Errorf("expiries spread over %v, want most of the 24m jitter window", last.Sub(first))
+	}
+}
+
+func TestNoJitterExpiresABurstTogether(t *testing.T) {
+	expiries := cacheBurst(t, "0", 20)
+	if first, last := spread(expiries); last.Sub(first) > time.Second {
+		t.Errorf("expiries spread over %v without jitter", last.Sub(first))
+	}
+}
+
+func TestCacheTTLJitterIsBounded(t *testing.T) {
+	if cfg := testConfig(t, nil); cfg.CacheTTLJitter != 10 {
+		t.Errorf("default jitter %d", cfg.CacheTTLJitter)
+	}
+	for _, v := range []string{"-1", "51", "ten"} {
+		if _, err := loadConfig(func(name string) string {
+			if name == "CACHE_TTL_JITTER" {
+				return v
+			}
+			return ""
+		}); err == nil {
+			t.Errorf("CACHE_TTL_JITTER=%s was accepted", v)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// cachePurge is the body returned by the cache purge endpoint
+type cachePurge struct {
+	Repo   string `json:"repo,omitempty"`
+	Purged int    `json:"purged"`
+}
+
+// purgeCache godoc
+// @Summary Purge cached scorecards
+// @Description Drop the cached scorecards and misses of every commit of a repo, or with no repo the
+// @Description whole cache, so the next lookups go to the upstream. Use it when the upstream returned
+// @Description wrong data or after rotating a leaked token. memcached cannot list its keys, so there only
+// @Description the repo's latest scorecard is dropped and purging everything flushes the whole memcached.
+// @Description Requires ADMIN_TOKEN, sent as X-Admin-Token.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} cachePurge
+// @Failure 400 {object} errorResponse "INVALID_REPO"
+// @Failure 401
+// @Failure 403
+// @Router /msapi/scorecard/cache/:key [delete]
+func purgeCache(c *fiber.Ctx) error {
+	key := strings.Trim(c.Params("*"), "/")
+	if key == "" {
+		purged := cache.Purge("")
+		logger.Info("cache purged", zap.Int("purged", purged))
+		return c.JSON(cachePurge{Purged: purged})
+	}
+
+	repo := cleanRepoURL(key)
+	if err := validateRepoURL(repo); err != nil {
+		return sendLookupError(c, err)
+	}
+	purged := cache.Purge(repoCachePrefix(repo))
+	logger.Info("cache purged", zap.String("repo", repo), zap.Int("purged", purged))
+	return c.JSON(cachePurge{Repo: repo, Purged: purged})
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	docs "github.com/ossf/scorecard/v5/docs/checks"
+	ossf "github.com/ossf/scorecard/v5/pkg/scorecard"
+	"go.uber.org/zap"
+)
+
+// riskWeights are the weights OpenSSF gives each risk level when computing the aggregate
+var riskWeights = map[string]float64{"Critical": 10, "High": 7.5, "Medium": 5, "Low": 2.5}
+
+// checkFields maps each OpenSSF check name to the model.Scorecard field that holds its score.
+// It is the one list of mapped checks; checks not listed here are unmapped.
+var checkFields = map[string]string{
+	"Maintained":             "Maintained",
+	"Code-Review":            "CodeReview",
+	"CII-Best-Practices":     "CIIBestPractices",
+	"License":                "License",
+	"Signed-Releases":        "SignedReleases",
+	"Dangerous-Workflow":     "DangerousWorkflow",
+	"Packaging":              "Packaging",
+	"Token-Permissions":      "TokenPermissions",
+	"Branch-Protection":      "BranchProtection",
+	"Binary-Artifacts":       "BinaryArtifacts",
+	"Pinned-Dependencies":    "PinnedDependencies",
+	"Security-Policy":        "SecurityPolicy",
+	"Fuzzing":                "Fuzzing",
+	"SAST":                   "SAST",
+	"Vulnerabilities":        "Vulnerabilities",
+	"CI-Tests":               "CITests",
+	"Contributors":           "Contributors",
+	"Dependency-Update-Tool": "DependencyUpdateTool",
+	"SBOM":                   "SBOM",
+	"Webhooks":               "Webhooks",
+}
+
+// unmappedChecks returns the scores of the checks that have no model.Scorecard field, by check name
+func unmappedChecks(result *ossf.JSONScorecardResultV2) map[string]int {
+	unmapped := make(map[string]int)
+	for _, check := range result.Checks {
+		if _, ok := checkFields[check.Name]; !ok {
+			unmapped[check.Name] = check.Score
+		}
+	}
+	return unmapped
+}
+
+// checkDocs is the check documentation bundled with the scorecard library
+var checkDocs = readCheckDocs()
+
+func readCheckDocs() docs.Doc {
+	checkDocs, err := docs.Read()
+	if err != nil {
+		logger.Sugar().Fatalf("Failed to read the scorecard check docs: %v", err)
+	}
+	return checkDocs
+}
+
+// checkRisk is the risk tier OpenSSF documents for a check, empty for checks the library
+// has no documentation for
+func checkRisk(name string) string {
+	doc, err := checkDocs.GetCheck(name)
+	if err != nil {
+		return ""
+	}
+	return doc.GetRisk()
+}
+
+// parseRiskTiers reads comma separated risk tiers, e.g. "Critical,High", in any case.
+// An empty list selects no tier filter and returns nil.
+func parseRiskTiers(s string) (map[string]bool, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	tiers := make(map[string]bool)
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		tier := ""
+		for known := range riskWeights {
+			if strings.EqualFold(name, known) {
+				tier = known
+			}
+		}
+		if tier == "" {
+			return nil, fmt.Errorf("unknown risk tier %q, use Critical, High, Medium or Low", name)
+		}
+		tiers[tier] = true
+	}
+	return tiers, nil
+}
+
+// recomputeAggregate repeats the OpenSSF aggregate calculation from the individual checks:
+// the risk-weighted mean of every check that ran (score >= 0). Checks the library has no
+// documentation for are skipped. Returns -1 when no check counts.
+func recomputeAggregate(result *ossf.JSONScorecardResultV2) float64 {
+	var total, score float64
+	for _, check := range result.Checks {
+		if check.Score < 0 {
+			continue
+		}
+
+		weight, ok := riskWeights[checkRisk(check.Name)]
+		if !ok {
+			continue
+		}
+
+		total += weight
+		score += weight * float64(check.Score)
+	}
+
+	if total == 0 {
+		return -1
+	}
+	return score / total
+}
+
+// aggregateConsistent says whether the reported aggregate agrees with the recomputation.
+// AGGREGATE_TOLERANCE is how far apart the two may be; the upstream rounds to one decimal so the
+// default is 0.1.
+func aggregateConsistent(result *ossf.JSONScorecardResultV2) bool {
+	// allow for float noise on top of the tolerance
+	return math.Abs(float64(result.AggregateScore)-recomputeAggregate(result)) <= config.AggregateTolerance+1e-9
+}
+
+// checkAggregate logs a fetched result whose aggregate disagrees with the recomputation. It runs
+// once per fetch, not per response. AGGREGATE_CHECK=log (default) only logs, flag also reports
+// aggregate_consistent in the response meta and off skips the check.
+func checkAggregate(result *ossf.JSONScorecardResultV2) {
+	if config.AggregateCheck == "off" || aggregateConsistent(result) {
+		return
+	}
+	logger.Warn("upstream aggregate disagrees with the recomputed aggregate",
+		zap.String("repo", result.Repo.Name), zap.Float64("reported", float64(result.AggregateScore)),
+		zap.Float64("recomputed", recomputeAggregate(result)))
+}
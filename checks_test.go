@@ -0,0 +1,169 @@
+package main
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestAggregateThatDisagreesWithTheChecks(t *testing.T) {
+	// both checks score 10, so the recomputed aggregate is 10 whatever their weights
+	checks := map[string]int{"Code-Review": 10, "License": 10}
+	disagrees := resultJSON("github.com/a/b", sha(1), 4, checks)
+	agrees := resultJSON("github.com/a/b", sha(1), 10, checks)
+	consistent, inconsistent := true, false
+
+	for _, tt := range []struct {
+		name       string
+		env        map[string]string
+		body       string
+		consistent *bool // aggregate_consistent, nil when left out of the meta
+		logged     int
+	}{
+		{"logged by default", nil, disagrees, nil, 1},
+		{"flagged", map[string]string{"AGGREGATE_CHECK": "flag"}, disagrees, &inconsistent, 1},
+		{"flagged consistent", map[string]string{"AGGREGATE_CHECK": "flag"}, agrees, &consistent, 0},
+		{"within the tolerance", map[string]string{"AGGREGATE_CHECK": "flag", "AGGREGATE_TOLERANCE": "6"}, disagrees, &consistent, 0},
+		{"off", map[string]string{"AGGREGATE_CHECK": "off"}, disagrees, nil, 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := observeLogs(t, zapcore.WarnLevel)
+			api := newFakeAPI(t)
+			api.serve("github.com/a/b", tt.body)
+			env := map[string]string{"SCORECARD_API_URLS": api.URL}
+			maps.Copy(env, tt.env)
+			app := newTestApp(t, env)
+
+			// the second lookup is a cache hit, so the mismatch is logged only once
+			for range 2 {
+				resp := getResponse(t, app, "/msapi/scorecard/github.com/a/b")
+				var got *bool
+				if resp.Meta != nil {
+					got = resp.Meta.AggregateConsistent
+				}
+				if (got == nil) != (tt.consistent == nil) || (got != nil && *got != *tt.consistent) {
+					t.Errorf("aggregate_consistent %v, want %v", got, tt.consistent)
+				}
+				if float64(resp.Score) != float64(parseResult(t, tt.body).AggregateScore) {
+					t.Errorf("aggregate %v, want the upstream's", resp.Score)
+				}
+			}
+			if n := api.called("github.com/a/b"); n != 1 {
+				t.Errorf("upstream called %d times, want 1", n)
+			}
+			if n := logs.FilterMessage("upstream aggregate disagrees with the recomputed aggregate").Len(); n != tt.logged {
+				t.Errorf("logged %d mismatches, want %d", n, tt.logged)
+			}
+		})
+	}
+}
+
+func TestMappedAggregateIsFlaggedButNotLogged(t *testing.T) {
+	logs := observeLogs(t, zapcore.WarnLevel)
+	app := newTestApp(t, map[string]string{"AGGREGATE_CHECK": "flag"})
+
+	_, body := postMap(t, app, "", resultJSON("github.com/a/b", sha(1), 4, map[string]int{"Code-Review": 10, "License": 10}))
+	var resp scorecardResponse
+	mustJSON(t, body, &resp)
+	if resp.Meta == nil || resp.Meta.AggregateConsistent == nil || *resp.Meta.AggregateConsistent {
+		t.Errorf("want aggregate_consistent false in %s", body)
+	}
+	if n := logs.FilterMessage("upstream aggregate disagrees with the recomputed aggregate").Len(); n != 0 {
+		t.Errorf("a mapped body logged %d mismatches", n)
+	}
+}
+
+func TestUnmappedChecksDefaultAndOverride(t *testing.T) {
+	// a check named like a response field must not shadow it
+	raw := resultJSON("github.com/a/b", sha(1), 6, map[string]int{"Code-Review": 8, "Score": 1, "Some-New-Check": 4})
+
+	for _, tt := range []struct {
+		name   string
+		env    map[string]string
+		query  string
+		listed bool
+	}{
+		{"off by default", nil, "", false},
+		{"asked for", nil, "?include_unmapped=true", true},
+		{"on by default", map[string]string{"INCLUDE_UNMAPPED_CHECKS": "true"}, "", true},
+		{"default overridden", map[string]string{"INCLUDE_UNMAPPED_CHECKS": "true"}, "?include_unmapped=false", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(t, tt.env)
+			_, body := postMap(t, app, tt.query, raw)
+			var resp scorecardResponse
+			mustJSON(t, body, &resp)
+
+			if resp.Score != 6 || resp.CodeReview != 8 {
+				t.Errorf("mapped fields changed: score %v, code review %v", resp.Score, resp.CodeReview)
+			}
+			want := map[string]int(nil)
+			if tt.listed {
+				want = map[string]int{"Score": 1, "Some-New-Check": 4}
+			}
+			if !maps.Equal(resp.UnmappedChecks, want) {
+				t.Errorf("unmapped_checks %v, want %v", resp.UnmappedChecks, want)
+			}
+		})
+	}
+}
+
+func TestRiskListsOnlyTheRequestedTiers(t *testing.T) {
+	api := newFakeAPI(t)
+	api.serve("github.com/a/b", resultJSON("github.com/a/b", sha(1), 6.5, map[string]int{
+		"Dangerous-Workflow": 10, // Critical
+		"Code-Review":        7,  // High
+		"Maintained":         3,  // High
+		"SAST":               5,  // Medium
+		"License":            10, // Low
+	}))
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL})
+
+	resp := getResponse(t, app, "/msapi/scorecard/github.com/a/b?risk=critical,High")
+	listed := make(map[string]string, len(resp.Checks))
+	for _, check := range resp.Checks {
+		listed[check.Name] = check.Risk
+	}
+	want := map[string]string{"Dangerous-Workflow": "Critical", "Code-Review": "High", "Maintained": "High"}
+	if !maps.Equal(listed, want) {
+		t.Errorf("checks %v, want %v", listed, want)
+	}
+	if resp.Score != 6.5 || resp.License != 10 {
+		t.Errorf("score %v, license %v, want the aggregate and fields untouched", resp.Score, resp.License)
+	}
+
+	if status, _ := get(t, app, "/msapi/scorecard/github.com/a/b?risk=Severe"); status != fiber.StatusBadRequest {
+		t.Errorf("an unknown tier gave status %d", status)
+	}
+}
+
+func TestMetadataListsEveryMappedCheckOnce(t *testing.T) {
+	app := newTestApp(t, nil)
+	status, body := get(t, app, "/msapi/scorecard/metadata")
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d", status)
+	}
+	var metadata []checkMetadata
+	mustJSON(t, body, &metadata)
+
+	seen := make(map[string]int, len(metadata))
+	for _, entry := range metadata {
+		seen[entry.Name]++
+		if entry.Field != checkFields[entry.Name] || entry.JSONKey == "" {
+			t.Errorf("%s: field %q, json key %q", entry.Name, entry.Field, entry.JSONKey)
+		}
+		if entry.Risk == "" || entry.Weight != riskWeights[entry.Risk] || entry.DocumentationURL == "" {
+			t.Errorf("%s: risk %q, weight %v, documentation %q", entry.Name, entry.Risk, entry.Weight, entry.DocumentationURL)
+		}
+	}
+	for name := range checkFields {
+		if seen[name] != 1 {
+			t.Errorf("%s listed %d times, want once", name, seen[name])
+		}
+	}
+	if len(metadata) != len(checkFields) {
+		t.Errorf("%d entries for %d mapped checks", len(metadata), len(checkFields))
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+// cloneForge is a forge the scorecard library has no client for. Its repos are cloned
+// and scanned as a local directory, which runs only the file based checks. auth is given
+// the token passed with the request, empty when there is none.
+type cloneForge struct {
+	host     string
+	cloneURL func(repoURL string) string
+	auth     func(token string) transport.AuthMethod
+}
+
+// cloneForges are matched on the host of the normalized repo url
+var cloneForges = []cloneForge{
+	{"bitbucket.org", httpsCloneURL, bitbucketAuth},
+	{azureDevOpsHost, azureDevOpsCloneURL, azureDevOpsAuth},
+}
+
+// cloneForgeFor finds the clone forge hosting a normalized repo url. Besides cloneForges,
+// the CLONE_HOSTS (codeberg.org by default) are cloned over https, anonymously unless the
+// request passed a token, which covers Gitea, Forgejo and other plain git hosts. Only
+// listed hosts are ever cloned.
+func cloneForgeFor(repoURL string) (cloneForge, bool) {
+	host, _, _ := strings.Cut(repoURL, "/")
+	for _, forge := range cloneForges {
+		if host == forge.host {
+			return forge, true
+		}
+	}
+	for _, cloneHost := range config.CloneHosts {
+		if host == cloneHost {
+			return cloneForge{cloneHost, httpsCloneURL, requestTokenAuth}, true
+		}
+	}
+	return cloneForge{}, false
+}
+
+func httpsCloneURL(repoURL string) string {
+	return "https://" + repoURL + ".git"
+}
+
+// requestTokenAuth sends the caller's token as the basic auth password, which Gitea and
+// Forgejo accept for any user name
+func requestTokenAuth(token string) transport.AuthMethod {
+	if token == "" {
+		return nil
+	}
+	return &http.BasicAuth{Username: "oauth2", Password: token}
+}
+
+// cloneRepo clones a repo into a temporary directory and checks out commitSha, or the
+// default branch when it is empty, for a local scan. token is the one passed with the
+// request, if any. It returns the directory, which the caller removes, and the full sha
+// that was checked out.
+func cloneRepo(ctx context.Context, forge cloneForge, repoURL, commitSha, token string) (string, string, error) {
+	dir, err := os.MkdirTemp("", "scorecard-clone-")
+	if err != nil {
+		return "", "", err
+	}
+
+	opts := &git.CloneOptions{URL: forge.cloneURL(repoURL), Auth: forge.auth(token)}
+	if commitSha == "" {
+		opts.Depth = 1 // only HEAD is needed
+	}
+
+	repo, err := git.PlainCloneContext(ctx, dir, false, opts)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", "", fmt.Errorf("cloning %s: %w", repoURL, err)
+	}
+
+	revision := plumbing.Revision("HEAD")
+	if commitSha != "" {
+		revision = plumbing.Revision(commitSha)
+	}
+	hash, err := repo.ResolveRevision(revision)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", "", fmt.Errorf("resolving %s in %s: %w", revision, repoURL, err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err == nil {
+		err = worktree.Checkout(&git.CheckoutOptions{Hash: *hash})
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", "", fmt.Errorf("checking out %s in %s: %w", hash, repoURL, err)
+	}
+	return dir, hash.String(), nil
+}
+
+// bitbucketAuth uses the caller's repository access token, or BITBUCKET_USERNAME and
+// BITBUCKET_APP_PASSWORD, for private Bitbucket repos
+func bitbucketAuth(token string) transport.AuthMethod {
+	if token != "" {
+		return &http.BasicAuth{Username: "x-token-auth", Password: token}
+	}
+	if config.BitbucketUsername == "" || config.BitbucketAppPassword == "" {
+		return nil
+	}
+	return &http.BasicAuth{Username: config.BitbucketUsername, Password: config.BitbucketAppPassword}
+}
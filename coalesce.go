@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	ossf "github.com/ossf/scorecard/v5/pkg/scorecard"
+)
+
+// coalescer lets lookups for the same key join a fetch that is in progress, or that
+// finished less than window ago, instead of calling the upstream again
+type coalescer struct {
+	mu     sync.Mutex
+	window time.Duration
+	calls  map[string]*coalescedCall
+}
+
+type coalescedCall struct {
+	done   chan struct{}
+	result *ossf.JSONScorecardResultV2
+	source string
+	err    error
+}
+
+func newCoalescer(window time.Duration) *coalescer {
+	return &coalescer{window: window, calls: make(map[string]*coalescedCall)}
+}
+
+// do runs fn for key unless a call for key is running or finished within the window,
+// in which case that call's outcome is returned. A fresh call always runs fn, and lookups
+// for key that come after it join it instead of the call it replaced.
+func (c *coalescer) do(key string, fresh bool, fn func() (*ossf.JSONScorecardResultV2, string, error)) (*ossf.JSONScorecardResultV2, string, error) {
+	c.mu.Lock()
+	if call, ok := c.calls[key]; ok && !fresh {
+		c.mu.Unlock()
+		<-call.done
+		return call.result, call.source, call.err
+	}
+
+	call := &coalescedCall{done: make(chan struct{})}
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	call.result, call.source, call.err = fn()
+	close(call.done)
+
+	time.AfterFunc(c.window, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.calls[key] == call {
+			delete(c.calls, key)
+		}
+	})
+	return call.result, call.source, call.err
+}
+
+// lookups is rebuilt by setupRoutes with COALESCE_WINDOW_MS
+var lookups = newCoalescer(config.CoalesceWindow)
+
+// coalescedLookup is lookupScorecard answered from the cache when it can be, and otherwise
+// with near-simultaneous identical lookups sharing one fetch. Progress callbacks are not
+// shared, so a streaming lookup that misses the cache runs its own fetch.
+//
+// Only what changes the fetch is part of the key: repo, commit and stage order. The shared
+// value is the raw upstream result, and each request shapes its own response from it
+// (format, fields, verbose, grade, ...), so requests with different options for the same
+// repo can share a fetch without ever seeing each other's response shape. The cache is
+// keyed by repo and commit alone, a result is the scorecard of that commit whichever stage
+// produced it. A lookup that found no scorecard is cached as a miss for the shorter
+// SCORECARD_NEGATIVE_CACHE_TTL, so unscored repos are not scanned on every lookup.
+// A result past SCORECARD_CACHE_TTL but within SCORECARD_STALE_TTL is returned as is,
+// with req.onStale told, while a background lookup replaces it. Past that it is kept for
+// SCORECARD_FALLBACK_TTL more and returned, as stale, only when the upstream fails or the
+// circuit breaker is open.
+// A lookup with a caller's token is never cached or shared, its result may be of a private
+// repo. A refresh lookup skips the cache, never joins another lookup and becomes the one
+// later lookups share and find cached. The other lookups are counted for the HOT_REPOS refresher.
+func coalescedLookup(req lookupRequest) (*ossf.JSONScorecardResultV2, string, error) {
+	if req.token != "" {
+		return lookupScorecard(req)
+	}
+
+	cacheKey := repoCachePrefix(req.repo) + req.commit
+	if !req.refresh {
+		hot.add(req.repo, req.commit)
+		if entry, ok := cache.Get(cacheKey); ok {
+			now := time.Now()
+			if entry.Miss == "" && now.Before(entry.Expires) {
+				return entry.Result, entry.Source, nil
+			}
+			if entry.Miss == "" && now.Before(entry.Expires.Add(config.StaleTTL)) {
+				go revalidate(req, cacheKey)
+				req.stale()
+				return entry.Result, entry.Source, nil
+			}
+			if err, known := missErrors[entry.Miss]; known {
+				return nil, sourceNone, err
+			}
+		}
+	}
+
+	var (
+		result *ossf.JSONScorecardResultV2
+		source string
+		err    error
+	)
+	if req.progress != nil {
+		result, source, err = cachedFetch(req, cacheKey)
+	} else {
+		result, source, err = lookups.do(cacheKey+"|"+req.prefer, req.refresh, func() (*ossf.JSONScorecardResultV2, string, error) {
+			return cachedFetch(req, cacheKey)
+		})
+	}
+	if errors.Is(err, errUpstream) || errors.Is(err, errUpstreamTimeout) {
+		if entry, ok := cache.Get(cacheKey); ok && entry.Miss == "" {
+			req.stale()
+			return entry.Result, entry.Source, nil
+		}
+	}
+	return result, source, err
+}
+
+// fetchFresher reads the store while lookupScorecard runs and returns the scorecard analysed
+// later, the stored one on a tie. An upstream scorecard that is fresher is written back to
+// the store. The stored scorecard is also returned when the upstream has none or fails.
+func fetchFresher(req lookupRequest) (*ossf.JSONScorecardResultV2, string, error) {
+	loaded := make(chan storedScorecard, 1)
+	go func() {
+		stored, _ := store.load(req.repo, req.commit)
+		loaded <- stored
+	}()
+
+	result, source, err := lookupScorecard(req)
+	stored := <-loaded
+	if stored.Result != nil && (result == nil || stored.Result.Date >= result.Date) {
+		return stored.Result, stored.Source, nil
+	}
+	store.save(req.repo, source, result)
+	return result, source, err
+}
+
+// revalidate replaces a stale cached result, joining any lookup of it already under way.
+// It runs after the request that found the result has returned, so it tells that
+// request nothing.
+func revalidate(req lookupRequest, cacheKey string) {
+	req.progress, req.onStale = nil, nil
+	_, _, _ = lookups.do(cacheKey+"|"+req.prefer, false, func() (*ossf.JSONScorecardResultV2, string, error) {
+		return cachedFetch(req, cacheKey)
+	})
+}
+
+// cachedFetch runs lookupScorecard and caches its result, or the miss, under cacheKey, for
+// a TTL spread by CACHE_TTL_JITTER. A result is kept for SCORECARD_STALE_TTL and
+// SCORECARD_FALLBACK_TTL past its expiry so it can be served stale. With PERSIST_SCORECARDS
+// set the result is stored too, and a lookup of a commit found in the store, unless a
+// refresh, is answered from it; with STORE_PARALLEL_FETCH as well the upstream is asked
+// anyway, see fetchFresher. The aggregate of the result is checked here, once per fetch.
+func cachedFetch(req lookupRequest, cacheKey string) (*ossf.JSONScorecardResultV2, string, error) {
+	var (
+		result *ossf.JSONScorecardResultV2
+		source string
+		err    error
+	)
+	switch {
+	case store == nil:
+		result, source, err = lookupScorecard(req)
+	case config.StoreParallelFetch && !req.refresh:
+		result, source, err = fetchFresher(req)
+	default:
+		var stored storedScorecard
+		var ok bool
+		if !req.refresh {
+			stored, ok = store.load(req.repo, req.commit)
+		}
+		if ok {
+			result, source = stored.Result, stored.Source
+		} else {
+			result, source, err = lookupScorecard(req)
+			store.save(req.repo, source, result)
+		}
+	}
+	if result != nil {
+		checkAggregate(result)
+	}
+	recordHistory(req, result)
+	switch code := missCode(err); {
+	case err == nil && config.CacheTTL > 0:
+		ttl := jitterTTL(config.CacheTTL)
+		entry := CacheEntry{Result: result, Source: source, Expires: time.Now().Add(ttl)}
+		cache.Set(cacheKey, entry, ttl+config.StaleTTL+config.FallbackTTL)
+	case code != "":
+		cache.Set(cacheKey, CacheEntry{Source: sourceNone, Miss: code}, jitterTTL(config.NegativeCacheTTL))
+	}
+	return result, source, err
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	ossf "github.com/ossf/scorecard/v5/pkg/scorecard"
+)
+
+func TestOverlappingRequestsShareOneFetch(t *testing.T) {
+	api := newFakeAPI(t)
+	api.serve("github.com/a/b", resultJSON("github.com/a/b", sha(1), 6, nil))
+	api.serve("github.com/a/c", resultJSON("github.com/a/c", sha(2), 7, nil))
+	api.slow(100 * time.Millisecond)
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL})
+
+	batch, _ := json.Marshal([]batchItem{{Repo: "github.com/a/b"}, {Repo: "github.com/a/c"}})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var status int
+			if i%2 == 0 {
+				status, _ = get(t, app, "/msapi/scorecard/github.com/a/b")
+			} else {
+				req := httptest.NewRequest(fiber.MethodPost, "/msapi/scorecard/batch", strings.NewReader(string(batch)))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				status, _ = doRequest(t, app, req)
+			}
+			if status != fiber.StatusOK {
+				t.Errorf("request %d: status %d", i, status)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, repo := range []string{"github.com/a/b", "github.com/a/c"} {
+		if n := api.called(repo); n != 1 {
+			t.Errorf("%s fetched %d times, want the overlapping requests to share one fetch", repo, n)
+		}
+	}
+}
+
+func TestCoalescerWindow(t *testing.T) {
+	var fetches atomic.Int32
+	fetch := func() (*ossf.JSONScorecardResultV2, string, error) {
+		fetches.Add(1)
+		return &ossf.JSONScorecardResultV2{}, sourceAPI, nil
+	}
+
+	c := newCoalescer(50 * time.Millisecond)
+	c.do("k", false, fetch)
+	c.do("k", false, fetch)
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("%d fetches within the window, want 1", n)
+	}
+	c.do("k", true, fetch)
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("a fresh call was shared, %d fetches", n)
+	}
+	c.do("other", false, fetch)
+	if n := fetches.Load(); n != 3 {
+		t.Errorf("another key was shared, %d fetches", n)
+	}
+	time.Sleep(100 * time.Millisecond)
+	c.do("k", false, fetch)
+	if n := fetches.Load(); n != 4 {
+		t.Errorf("a call after the window was shared, %d fetches", n)
+	}
+
+	if cfg := testConfig(t, nil); cfg.CoalesceWindow != 50*time.Millisecond {
+		t.Errorf("default window %v", cfg.CoalesceWindow)
+	}
+	if cfg := testConfig(t, map[string]string{"COALESCE_WINDOW_MS": "0"}); cfg.CoalesceWindow != 0 {
+		t.Errorf("COALESCE_WINDOW_MS=0 gave %v", cfg.CoalesceWindow)
+	}
+}
+
+func TestParallelFetchKeepsTheFresherScorecard(t *testing.T) {
+	api := newFakeAPI(t)
+	for _, repo := range []string{"github.com/a/b", "github.com/a/c", "github.com/a/d"} {
+		api.serve(repo+"?commit="+sha(1), resultJSON(repo, sha(1), 8, nil))
+	}
+	api.fail("github.com/a/e?commit="+sha(1), fiber.StatusInternalServerError)
+	env := map[string]string{"SCORECARD_API_URLS": api.URL, "OUTBOUND_RETRIES": "0", "STORE_PARALLEL_FETCH": "true"}
+
+	stored := func(s *memoryStore, repo, date string) {
+		result := parseResult(t, resultJSON(repo, sha(1), 5, nil))
+		result.Date = date
+		s.save(repo, sourceAPI, result)
+	}
+	score := func(app *fiber.App, repo string) float32 {
+		return getResponse(t, app, "/msapi/scorecard/"+repo+"?commit="+sha(1)).Score
+	}
+
+	app := newTestApp(t, env)
+	s := useMemoryStore(t)
+	stored(s, "github.com/a/b", "2024-04-01T00:00:00Z")
+	if got := score(app, "github.com/a/b"); got != 8 {
+		t.Errorf("score %v, want the fresher API scorecard", got)
+	}
+	if doc, _ := s.load("github.com/a/b", sha(1)); doc.Result == nil || doc.Result.AggregateScore != 8 {
+		t.Errorf("stored %+v, want the API scorecard written back", doc.Result)
+	}
+
+	stored(s, "github.com/a/c", "2024-06-01T00:00:00Z")
+	if got := score(app, "github.com/a/c"); got != 5 || api.called("github.com/a/c?commit="+sha(1)) != 1 {
+		t.Errorf("score %v, want the fresher stored scorecard after asking the API", got)
+	}
+
+	stored(s, "github.com/a/e", "2024-04-01T00:00:00Z")
+	if got := score(app, "github.com/a/e"); got != 5 {
+		t.Errorf("score %v, want the stored scorecard when the API fails", got)
+	}
+
+	delete(env, "STORE_PARALLEL_FETCH")
+	app = newTestApp(t, env)
+	s = useMemoryStore(t)
+	stored(s, "github.com/a/d", "2024-04-01T00:00:00Z")
+	if got := score(app, "github.com/a/d"); got != 5 || api.called("github.com/a/d?commit="+sha(1)) != 0 {
+		t.Errorf("score %v, want the stored scorecard without asking the API", got)
+	}
+}
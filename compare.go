@@ -0,0 +1,142 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	ossf "github.com/ossf/scorecard/v5/pkg/scorecard"
+)
+
+// compareRepo is one column of a comparison: the repo and the scorecard it was compared by.
+// Exactly one of Score and Error is set.
+type compareRepo struct {
+	Repo   string         `json:"repo"`
+	Commit string         `json:"commit,omitempty"`
+	Date   string         `json:"date,omitempty"`
+	Score  *float64       `json:"score,omitempty"`
+	Source string         `json:"source,omitempty"`
+	Error  *errorResponse `json:"error,omitempty"`
+}
+
+// compareRow is one check of a comparison, with its score in each repo in the order of
+// repos. A score of -1 means the check was inconclusive, did not run or the lookup failed.
+type compareRow struct {
+	Name   string `json:"name"`
+	Scores []int  `json:"scores"`
+}
+
+// compareResponse is the body returned by the compare endpoint
+type compareResponse struct {
+	Repos  []compareRepo `json:"repos"`
+	Checks []compareRow  `json:"checks"`
+}
+
+// compareScorecards lays the check scores of results out as a matrix with a row per check,
+// listed by name, and a column per result. A nil result is a column of -1.
+func compareScorecards(results []*ossf.JSONScorecardResultV2) []compareRow {
+	rows := map[string][]int{}
+	for i, result := range results {
+		if result == nil {
+			continue
+		}
+		for _, check := range result.Checks {
+			scores, ok := rows[check.Name]
+			if !ok {
+				scores = make([]int, len(results))
+				for j := range scores {
+					scores[j] = -1
+				}
+				rows[check.Name] = scores
+			}
+			scores[i] = check.Score
+		}
+	}
+
+	names := make([]string, 0, len(rows))
+	for name := range rows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	matrix := make([]compareRow, 0, len(names))
+	for _, name := range names {
+		matrix = append(matrix, compareRow{Name: name, Scores: rows[name]})
+	}
+	return matrix
+}
+
+// compareRepos is the normalized repos of a comma separated ?repos= list, blanks dropped
+func compareRepos(list string) []string {
+	var repos []string
+	for _, repo := range strings.Split(list, ",") {
+		if repo = strings.TrimSpace(repo); repo != "" {
+			repos = append(repos, cleanRepoURL(repo))
+		}
+	}
+	return repos
+}
+
+// getCompare godoc
+// @Summary Compare the OSSF scorecards of several repos
+// @Description Look up the latest scorecard of each repo and lay the check scores out side by side:
+// @Description a column per repo, in the order given, and a row per check, listed by name.
+// @Description A check a repo has no score for is -1 in its column. Lookups run like a batch,
+// @Description at most BATCH_CONCURRENCY at a time and at most BATCH_MAX_ITEMS repos, and a
+// @Description lookup that fails reports its error code in place of the repo's score.
+// @Tags scorecard
+// @Produce json
+// @Param repos query string true "comma separated repos to compare, at least two, e.g. github.com/a/b,github.com/c/d"
+// @Param prefer query string false "which source to try first; defaults to PREFER_SOURCE or api" Enums(api, cli)
+// @Param refresh query bool false "skip the cache and the lookups shared within COALESCE_WINDOW_MS and fetch the scorecard again"
+// @Param X-Repo-Token header string false "forge token used instead of the configured one to scan a private repo; Authorization: Bearer also works"
+// @Success 200 {object} compareResponse
+// @Failure 400
+// @Failure 413
+// @Router /msapi/scorecard/compare [get]
+func getCompare(c *fiber.Ctx) error {
+	prefer, err := preference(c)
+	if err != nil {
+		return err
+	}
+
+	repos := compareRepos(c.Query("repos"))
+	if len(repos) < 2 {
+		return fiber.NewError(fiber.StatusBadRequest, "repos must list at least two repos")
+	}
+	if len(repos) > config.BatchMaxItems {
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, "repos exceeds BATCH_MAX_ITEMS")
+	}
+
+	req := lookupRequest{prefer: prefer, token: requestToken(c), refresh: c.QueryBool("refresh")}
+	columns := make([]compareRepo, len(repos))
+	results := make([]*ossf.JSONScorecardResultV2, len(repos))
+	runConcurrently(len(repos), config.BatchConcurrency, func(i int) {
+		columns[i], results[i] = compareLookup(repos[i], req)
+	})
+	return c.JSON(compareResponse{Repos: columns, Checks: compareScorecards(results)})
+}
+
+// compareLookup looks up the latest scorecard of one compared repo with the options of req
+func compareLookup(githubURL string, req lookupRequest) (compareRepo, *ossf.JSONScorecardResultV2) {
+	column := compareRepo{Repo: githubURL}
+	failed := func(err error) (compareRepo, *ossf.JSONScorecardResultV2) {
+		_, code := lookupErrorStatus(err)
+		column.Error = &errorResponse{Code: code, Message: err.Error()}
+		return column, nil
+	}
+
+	if err := validateRepoURL(githubURL); err != nil {
+		return failed(err)
+	}
+	req.repo = githubURL
+	result, source, err := coalescedLookup(req)
+	if err != nil {
+		return failed(err)
+	}
+
+	recordScored(githubURL)
+	score := float64(result.AggregateScore)
+	column.Commit, column.Date, column.Score, column.Source = result.Repo.Commit, result.Date, &score, source
+	return column, result
+}
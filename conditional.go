@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	ossf "github.com/ossf/scorecard/v5/pkg/scorecard"
+)
+
+// scorecardETag is the weak entity tag of a result sent as mime: it changes with the repo,
+// the commit and the analysis date, and so only when the upstream scores the repo again
+func scorecardETag(result *ossf.JSONScorecardResultV2, mime string) string {
+	sum := sha256.Sum256([]byte(result.Repo.Name + "\x00" + result.Repo.Commit + "\x00" + result.Date + "\x00" + mime))
+	return `W/"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// notModified sets the ETag of result sent as mime and its analysis date as Last-Modified,
+// and reports whether the client's copy is still current, in which case a 304 is sent
+// instead of the body. If-Modified-Since is only considered without If-None-Match.
+func notModified(c *fiber.Ctx, result *ossf.JSONScorecardResultV2, mime string) bool {
+	etag := scorecardETag(result, mime)
+	c.Set(fiber.HeaderETag, etag)
+
+	modified, dated := analysisDate(result)
+	if dated {
+		c.Set(fiber.HeaderLastModified, modified.UTC().Format(http.TimeFormat))
+	}
+
+	if match := c.Get(fiber.HeaderIfNoneMatch); match != "" {
+		return etagMatches(match, etag)
+	}
+	since, err := http.ParseTime(c.Get(fiber.HeaderIfModifiedSince))
+	return dated && err == nil && !modified.Truncate(time.Second).After(since)
+}
+
+// setCacheControl tells downstream caches how long they may keep the scorecard of result.
+// A scorecard of the requested commit never changes and is immutable for SCORECARD_PINNED_MAX_AGE;
+// any other may be rescored and is kept for SCORECARD_MAX_AGE. One fetched with a caller's
+// token is private, so shared caches do not hand a private repo's scorecard to others.
+func setCacheControl(c *fiber.Ctx, result *ossf.JSONScorecardResultV2, commitSha string) {
+	scope := "public"
+	if requestToken(c) != "" {
+		scope = "private"
+	}
+
+	pinned := commitSha != "" && result.Repo.Commit == commitSha
+	maxAge := config.MaxAge
+	if pinned {
+		maxAge = config.PinnedMaxAge
+	}
+	switch {
+	case maxAge <= 0:
+		c.Set(fiber.HeaderCacheControl, scope+", no-cache")
+	case pinned:
+		c.Set(fiber.HeaderCacheControl, scope+", max-age="+strconv.Itoa(int(maxAge.Seconds()))+", immutable")
+	default:
+		c.Set(fiber.HeaderCacheControl, scope+", max-age="+strconv.Itoa(int(maxAge.Seconds())))
+	}
+}
+
+// analysisDate parses the date of a result, an RFC 3339 time or, from older scorecards, a day
+func analysisDate(result *ossf.JSONScorecardResultV2) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if date, err := time.Parse(layout, result.Date); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// etagMatches compares the tags listed in an If-None-Match header with etag, weakly as
+// RFC 9110 asks for If-None-Match
+func etagMatches(ifNoneMatch, etag string) bool {
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,717 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2/middleware/compress"
+	"github.com/redis/go-redis/v9"
+)
+
+// compressionLevels are the COMPRESSION settings
+var compressionLevels = map[string]compress.Level{
+	"off":     compress.LevelDisabled,
+	"speed":   compress.LevelBestSpeed,
+	"default": compress.LevelDefault,
+	"best":    compress.LevelBestCompression,
+}
+
+// Config is every setting the microservice reads from the environment. It is loaded once
+// at startup by loadConfig and handed to setupRoutes; nothing else reads the environment.
+type Config struct {
+	Port        string // MS_PORT, as ":port"
+	RoutePrefix string // ROUTE_PREFIX, as "/name" without a trailing slash
+
+	Compression compress.Level // COMPRESSION, off, speed, default or best, of gzip, deflate and brotli responses
+
+	GitHubToken string // GITHUB_TOKEN, enables the scan fallback and the repo probe
+	GitLabToken string // GITLAB_AUTH_TOKEN, used to scan GitLab repos when set
+
+	// GH_HOST, github.com or a GitHub Enterprise Server host. The scorecard library reads
+	// GH_HOST from the environment itself, so scans and GITHUB_TOKEN go to this instance.
+	GitHubHost   string
+	GitHubAPIURL string // GITHUB_API_URL, defaults to https://api.github.com or https://GH_HOST/api/v3
+
+	DepsDevAPIURL string // DEPS_DEV_API_URL, resolves package urls to their source repo
+	GoProxyURL    string // GO_PROXY_URL, the module proxy consulted when a Go module has no go-import tag
+	// GO_IMPORT_HOSTS, comma separated, the hosts whose ?go-get=1 pages are read for a Go module's
+	// go-import tag; the modules of any other host are resolved through GO_PROXY_URL alone
+	GoImportHosts []string
+	GoModuleTTL   time.Duration // GO_MODULE_TTL, e.g. "1h", how long a resolved Go module path is reused, zero to resolve every lookup
+
+	// SCORECARD_API_URLS, comma separated, the OpenSSF API and then the mirrors or proxies of it
+	// that are failed over to, in order, on a server error or a timeout
+	ScorecardAPIURLs []string
+	// SCORECARD_API_MIRROR_URL, e.g. https://mirror.example.com/projects, a mirror of the OpenSSF
+	// API asked too when the API has not answered within HEDGE_DELAY_MS
+	ScorecardMirrorURL string
+	HedgeDelay         time.Duration // HEDGE_DELAY_MS, the latency budget of the OpenSSF API before the mirror is asked
+
+	BitbucketUsername    string // BITBUCKET_USERNAME, with BITBUCKET_APP_PASSWORD clones private Bitbucket repos
+	BitbucketAppPassword string // BITBUCKET_APP_PASSWORD
+	AzureDevOpsToken     string // AZURE_DEVOPS_AUTH_TOKEN, a personal access token for private Azure DevOps repos
+	AdminToken           string // ADMIN_TOKEN, the admin routes are disabled when empty
+
+	GitLabHosts []string // gitlab.com and the self-hosted GitLab hosts in GITLAB_HOSTS, comma separated
+	CloneHosts  []string // codeberg.org and the hosts in CLONE_HOSTS, comma separated, scanned from a clone
+
+	ReadOnly     bool   // READ_ONLY, initial read-only mode
+	StrictDecode bool   // STRICT_DECODE, reject upstream fields not in JSONScorecardResultV2
+	PreserveCase bool   // PRESERVE_CASE, leave the repo path case untouched
+	PreferSource string // PREFER_SOURCE, api or cli
+
+	IncludeUnmappedChecks bool // INCLUDE_UNMAPPED_CHECKS, default for ?include_unmapped=
+
+	SlowRequestThreshold time.Duration // SLOW_REQUEST_THRESHOLD_MS, zero disables the slow log
+	UpstreamHealthWindow time.Duration // UPSTREAM_HEALTH_WINDOW, e.g. "15m"
+	CoalesceWindow       time.Duration // COALESCE_WINDOW_MS, zero still shares in-flight fetches
+	ScanTimeout          time.Duration // SCAN_TIMEOUT, e.g. "10m", the longest an in-process scan may run
+	ScanQueueTimeout     time.Duration // SCAN_QUEUE_TIMEOUT, e.g. "2m", the longest a scan waits for its turn
+	BadgeMaxAge          time.Duration // BADGE_MAX_AGE, e.g. "1h", how long badges may be cached downstream
+	CacheTTL             time.Duration // SCORECARD_CACHE_TTL, e.g. "1h", how long results are cached, zero disables the cache
+	NegativeCacheTTL     time.Duration // SCORECARD_NEGATIVE_CACHE_TTL, e.g. "5m", how long misses are cached, zero disables it
+	StaleTTL             time.Duration // SCORECARD_STALE_TTL, e.g. "24h", how long expired results are served while refetched
+	FallbackTTL          time.Duration // SCORECARD_FALLBACK_TTL, e.g. "24h", how much longer they are kept to serve when the upstream fails
+	MaxAge               time.Duration // SCORECARD_MAX_AGE, e.g. "5m", how long unpinned scorecards may be cached downstream
+	PinnedMaxAge         time.Duration // SCORECARD_PINNED_MAX_AGE, e.g. "720h", the same for scorecards of the requested commit
+
+	CacheBackend     string         // CACHE_BACKEND, memory, redis, memcached or file; else implied by REDIS_URL, MEMCACHED_SERVERS or CACHE_DIR
+	Redis            *redis.Options // REDIS_URL, e.g. redis://host:6379/0, required by the redis backend
+	MemcachedServers []string       // MEMCACHED_SERVERS, comma separated host:port, required by the memcached backend
+	CacheDir         string         // CACHE_DIR, e.g. a mounted volume, created if missing, required by the file backend
+	CacheMaxEntries  int            // CACHE_MAX_ENTRIES, the most results the memory cache holds before evicting
+	CacheMaxBytes    int            // CACHE_MAX_BYTES, the approximate size the memory cache is kept under
+	CacheTTLJitter   int            // CACHE_TTL_JITTER, e.g. 10, the percent a cached entry's TTL is randomly moved by either way
+
+	CacheMetricsInterval time.Duration // CACHE_METRICS_INTERVAL, e.g. "30s", how often the cache footprint on /metrics is updated
+
+	DistinctReposLimit int // DISTINCT_REPOS_LIMIT, how many distinct repos the usage stats and history remember
+	HistoryLimit       int // HISTORY_LIMIT, how many snapshots the history keeps per repo
+
+	GlobalOutboundConcurrency int // GLOBAL_OUTBOUND_CONCURRENCY, zero means unlimited
+
+	OutboundConnectTimeout  time.Duration // OUTBOUND_CONNECT_TIMEOUT, e.g. "5s", to connect and finish the TLS handshake
+	OutboundTimeout         time.Duration // OUTBOUND_TIMEOUT, e.g. "30s", the longest one outbound HTTP attempt may take
+	OutboundRetries         int           // OUTBOUND_RETRIES, how often a failed outbound call is tried again, zero for never
+	OutboundRetryBackoff    time.Duration // OUTBOUND_RETRY_BACKOFF, e.g. "500ms", the first wait, doubled on every retry
+	OutboundMaxThrottleWait time.Duration // OUTBOUND_MAX_THROTTLE_WAIT, e.g. "30s", the longest a call waits out a 429's Retry-After
+	OutboundMaxIdlePerHost  int           // OUTBOUND_MAX_IDLE_CONNS_PER_HOST, kept-alive connections reused per upstream host
+	OutboundIdleTimeout     time.Duration // OUTBOUND_IDLE_CONN_TIMEOUT, e.g. "90s", how long an unused connection is kept
+	OutboundDisableHTTP2    bool          // OUTBOUND_DISABLE_HTTP2, stay on HTTP/1.1 for proxies that mishandle HTTP/2
+	OutboundDNSTTL          time.Duration // OUTBOUND_DNS_TTL, e.g. "5m", how long a resolved upstream host is reused, zero to resolve every dial
+	// OUTBOUND_STATIC_HOSTS, comma separated host=ip pairs, e.g. api.securityscorecards.dev=10.0.0.5,
+	// dialled without resolving; a host may be listed more than once
+	OutboundStaticHosts map[string][]string
+
+	BreakerFailures int           // BREAKER_FAILURES, the OpenSSF API failures in a row that open the circuit breaker
+	BreakerCooldown time.Duration // BREAKER_COOLDOWN, e.g. "30s", how long it stays open before a probe call
+
+	BatchConcurrency int // BATCH_CONCURRENCY, lookups a batch request runs at once
+	BatchMaxItems    int // BATCH_MAX_ITEMS, the largest batch accepted
+	// SCORECARD_MAX_CONCURRENCY, the lookups the batch, SBOM, compare and diff requests and
+	// the cache warming run at once between them
+	ScorecardMaxConcurrency int
+
+	SBOMConcurrency   int // SBOM_CONCURRENCY, components an SBOM request resolves and repos it scores at once
+	SBOMMaxComponents int // SBOM_MAX_COMPONENTS, the most components of an SBOM that are scored, the rest are left out
+
+	ScanConcurrency int // SCAN_CONCURRENCY, in-process scans run at once
+	ScanQueueLength int // SCAN_QUEUE_LENGTH, scans waiting for a turn before more are refused
+
+	// REPO_REWRITE_RULES and then the lines of REPO_REWRITE_RULES_FILE, "regex => replacement" each
+	RepoRewriteRules []rewriteRule
+
+	GradeScale []gradeThreshold // GRADE_THRESHOLDS, e.g. "A:9,B:7,C:5,D:3,F:0"
+
+	RefCommitConflict string // REF_COMMIT_CONFLICT, error, prefer_commit or prefer_ref
+
+	WarmRepos     string        // WARM_REPOS, comma separated repos whose scorecards are fetched ahead of lookups
+	WarmReposFile string        // WARM_REPOS_FILE, more of them a line each, read again every round
+	WarmInterval  time.Duration // WARM_INTERVAL, e.g. "30m", how often they are fetched again, zero only at startup
+
+	HotRepos            int           // HOT_REPOS, how many of the most requested repos are refreshed before they expire, zero for none
+	HotReposTracked     int           // HOT_REPOS_TRACKED, how many repos are counted to find them
+	HotReposConcurrency int           // HOT_REPOS_CONCURRENCY, refreshes run at once
+	HotReposInterval    time.Duration // HOT_REPOS_INTERVAL, e.g. "1m", how often the scorecards about to expire are refreshed
+
+	// PERSIST_SCORECARDS, store every fetched scorecard in the ArangoDB named by the scec-commons
+	// ARANGO_URL, or ARANGO_HOST and ARANGO_PORT, ARANGO_USER and ARANGO_PASS
+	PersistScorecards bool
+	// STORE_PARALLEL_FETCH, ask the upstream while the store is read and keep the fresher
+	// scorecard, for warm-up jobs; it costs an upstream call for every stored scorecard read
+	StoreParallelFetch bool
+
+	AggregateCheck     string  // AGGREGATE_CHECK, log, flag or off
+	AggregateTolerance float64 // AGGREGATE_TOLERANCE
+
+	// MIDDLEWARE_FAIL_MODE, open or closed for every middleware or comma separated name=mode
+	// pairs, e.g. "compress=closed"; a middleware not named keeps its default, see defaultFailModes
+	MiddlewareFailModes map[string]string
+
+	TLSCertFile     string   // TLS_CERT_FILE
+	TLSKeyFile      string   // TLS_KEY_FILE
+	TLSMinVersion   uint16   // TLS_MIN_VERSION, 1.2 or 1.3
+	TLSCipherSuites []uint16 // TLS_CIPHER_SUITES, comma separated Go suite names
+}
+
+// defaultConfig is the configuration used when no environment variable is set
+func defaultConfig() *Config {
+	return &Config{
+		Port:                    ":8083",
+		GitHubHost:              defaultGitHubHost,
+		GitHubAPIURL:            defaultGitHubAPIURL,
+		DepsDevAPIURL:           "https://api.deps.dev/v3",
+		GoProxyURL:              "https://proxy.golang.org",
+		GoModuleTTL:             time.Hour,
+		ScorecardAPIURLs:        []string{defaultScorecardAPIURL},
+		HedgeDelay:              500 * time.Millisecond,
+		GitLabHosts:             []string{"gitlab.com"},
+		CloneHosts:              []string{"codeberg.org"},
+		PreferSource:            preferAPI,
+		SlowRequestThreshold:    5 * time.Second,
+		UpstreamHealthWindow:    15 * time.Minute,
+		CoalesceWindow:          50 * time.Millisecond,
+		ScanTimeout:             10 * time.Minute,
+		ScanQueueTimeout:        2 * time.Minute,
+		BadgeMaxAge:             time.Hour,
+		CacheTTL:                time.Hour,
+		NegativeCacheTTL:        5 * time.Minute,
+		FallbackTTL:             24 * time.Hour,
+		MaxAge:                  5 * time.Minute,
+		PinnedMaxAge:            30 * 24 * time.Hour,
+		CacheBackend:            cacheMemory,
+		CacheMaxEntries:         10000,
+		CacheMaxBytes:           256 << 20,
+		CacheTTLJitter:          10,
+		CacheMetricsInterval:    30 * time.Second,
+		DistinctReposLimit:      100000,
+		HistoryLimit:            100,
+		OutboundConnectTimeout:  5 * time.Second,
+		OutboundTimeout:         30 * time.Second,
+		OutboundRetries:         2,
+		OutboundRetryBackoff:    500 * time.Millisecond,
+		OutboundMaxThrottleWait: 30 * time.Second,
+		OutboundMaxIdlePerHost:  32,
+		OutboundIdleTimeout:     90 * time.Second,
+		OutboundDNSTTL:          5 * time.Minute,
+		BreakerFailures:         5,
+		BreakerCooldown:         30 * time.Second,
+		BatchConcurrency:        8,
+		BatchMaxItems:           1000,
+		SBOMConcurrency:         8,
+		SBOMMaxComponents:       1000,
+		ScorecardMaxConcurrency: 32,
+		ScanConcurrency:         2,
+		ScanQueueLength:         20,
+		GradeScale:              defaultGradeScale,
+		HotReposTracked:         10000,
+		HotReposConcurrency:     4,
+		HotReposInterval:        time.Minute,
+		RefCommitConflict:       conflictError,
+		AggregateCheck:          "log",
+		AggregateTolerance:      0.1,
+		MiddlewareFailModes:     maps.Clone(defaultFailModes),
+		TLSMinVersion:           tlsVersions["1.2"],
+		TLSCipherSuites:         defaultCipherSuites,
+	}
+}
+
+// config is the active configuration, replaced by setupRoutes
+var config = defaultConfig()
+
+// loadConfig builds a Config from getenv, normally os.Getenv, starting from the defaults.
+// Malformed values are reported rather than silently replaced by the default.
+func loadConfig(getenv func(string) string) (*Config, error) {
+	cfg := defaultConfig()
+
+	if port := getenv("MS_PORT"); port != "" {
+		cfg.Port = ":" + port
+	}
+	if prefix := strings.Trim(getenv("ROUTE_PREFIX"), "/"); prefix != "" {
+		cfg.RoutePrefix = "/" + prefix
+	}
+	if v := getenv("COMPRESSION"); v != "" {
+		level, ok := compressionLevels[strings.ToLower(v)]
+		if !ok {
+			return nil, fmt.Errorf("COMPRESSION must be off, speed, default or best, got %q", v)
+		}
+		cfg.Compression = level
+	}
+	cfg.GitHubToken = getenv("GITHUB_TOKEN")
+	cfg.GitLabToken = getenv("GITLAB_AUTH_TOKEN")
+	if host := strings.ToLower(strings.TrimSpace(getenv("GH_HOST"))); host != "" && host != defaultGitHubHost {
+		cfg.GitHubHost = host
+		cfg.GitHubAPIURL = "https://" + host + "/api/v3"
+	}
+	if err := envURL(getenv, "GITHUB_API_URL", &cfg.GitHubAPIURL); err != nil {
+		return nil, err
+	}
+	if err := envURL(getenv, "DEPS_DEV_API_URL", &cfg.DepsDevAPIURL); err != nil {
+		return nil, err
+	}
+	if err := envURL(getenv, "GO_PROXY_URL", &cfg.GoProxyURL); err != nil {
+		return nil, err
+	}
+	cfg.GoImportHosts = envHosts(getenv, "GO_IMPORT_HOSTS")
+	if err := envTTL(getenv, "GO_MODULE_TTL", &cfg.GoModuleTTL); err != nil {
+		return nil, err
+	}
+	if err := envURLs(getenv, "SCORECARD_API_URLS", &cfg.ScorecardAPIURLs); err != nil {
+		return nil, err
+	}
+	if err := envURL(getenv, "SCORECARD_API_MIRROR_URL", &cfg.ScorecardMirrorURL); err != nil {
+		return nil, err
+	}
+	if err := envMillis(getenv, "HEDGE_DELAY_MS", &cfg.HedgeDelay); err != nil {
+		return nil, err
+	}
+	cfg.BitbucketUsername = getenv("BITBUCKET_USERNAME")
+	cfg.BitbucketAppPassword = getenv("BITBUCKET_APP_PASSWORD")
+	cfg.AzureDevOpsToken = getenv("AZURE_DEVOPS_AUTH_TOKEN")
+	cfg.AdminToken = getenv("ADMIN_TOKEN")
+
+	cfg.GitLabHosts = append(cfg.GitLabHosts, envHosts(getenv, "GITLAB_HOSTS")...)
+	cfg.CloneHosts = append(cfg.CloneHosts, envHosts(getenv, "CLONE_HOSTS")...)
+
+	var err error
+	if cfg.ReadOnly, err = envBool(getenv, "READ_ONLY"); err != nil {
+		return nil, err
+	}
+	if cfg.StrictDecode, err = envBool(getenv, "STRICT_DECODE"); err != nil {
+		return nil, err
+	}
+	if cfg.PreserveCase, err = envBool(getenv, "PRESERVE_CASE"); err != nil {
+		return nil, err
+	}
+	if cfg.IncludeUnmappedChecks, err = envBool(getenv, "INCLUDE_UNMAPPED_CHECKS"); err != nil {
+		return nil, err
+	}
+
+	if prefer := getenv("PREFER_SOURCE"); prefer != "" {
+		if prefer != preferAPI && prefer != preferCLI {
+			return nil, fmt.Errorf("PREFER_SOURCE must be api or cli, got %q", prefer)
+		}
+		cfg.PreferSource = prefer
+	}
+
+	if err := envMillis(getenv, "SLOW_REQUEST_THRESHOLD_MS", &cfg.SlowRequestThreshold); err != nil {
+		return nil, err
+	}
+	if err := envMillis(getenv, "COALESCE_WINDOW_MS", &cfg.CoalesceWindow); err != nil {
+		return nil, err
+	}
+	if err := envDuration(getenv, "UPSTREAM_HEALTH_WINDOW", &cfg.UpstreamHealthWindow); err != nil {
+		return nil, err
+	}
+	if err := envDuration(getenv, "SCAN_TIMEOUT", &cfg.ScanTimeout); err != nil {
+		return nil, err
+	}
+	if err := envDuration(getenv, "SCAN_QUEUE_TIMEOUT", &cfg.ScanQueueTimeout); err != nil {
+		return nil, err
+	}
+	if err := envDuration(getenv, "BADGE_MAX_AGE", &cfg.BadgeMaxAge); err != nil {
+		return nil, err
+	}
+
+	if err := envTTL(getenv, "SCORECARD_CACHE_TTL", &cfg.CacheTTL); err != nil {
+		return nil, err
+	}
+	if err := envTTL(getenv, "SCORECARD_NEGATIVE_CACHE_TTL", &cfg.NegativeCacheTTL); err != nil {
+		return nil, err
+	}
+	if err := envTTL(getenv, "SCORECARD_STALE_TTL", &cfg.StaleTTL); err != nil {
+		return nil, err
+	}
+	if err := envTTL(getenv, "SCORECARD_FALLBACK_TTL", &cfg.FallbackTTL); err != nil {
+		return nil, err
+	}
+	if err := envTTL(getenv, "SCORECARD_MAX_AGE", &cfg.MaxAge); err != nil {
+		return nil, err
+	}
+	if err := envTTL(getenv, "SCORECARD_PINNED_MAX_AGE", &cfg.PinnedMaxAge); err != nil {
+		return nil, err
+	}
+	if v := getenv("REDIS_URL"); v != "" {
+		if cfg.Redis, err = redis.ParseURL(v); err != nil {
+			return nil, fmt.Errorf("REDIS_URL must be a redis:// or rediss:// url: %w", err)
+		}
+		cfg.CacheBackend = cacheRedis
+	}
+	if cfg.MemcachedServers = envHosts(getenv, "MEMCACHED_SERVERS"); len(cfg.MemcachedServers) > 0 && cfg.Redis == nil {
+		cfg.CacheBackend = cacheMemcached
+	}
+	if cfg.CacheDir = getenv("CACHE_DIR"); cfg.CacheDir != "" && cfg.CacheBackend == cacheMemory {
+		cfg.CacheBackend = cacheFile
+	}
+	if backend := getenv("CACHE_BACKEND"); backend != "" {
+		if backend != cacheMemory && backend != cacheRedis && backend != cacheMemcached && backend != cacheFile {
+			return nil, fmt.Errorf("CACHE_BACKEND must be memory, redis, memcached or file, got %q", backend)
+		}
+		cfg.CacheBackend = backend
+	}
+	if cfg.CacheBackend == cacheRedis && cfg.Redis == nil {
+		return nil, fmt.Errorf("CACHE_BACKEND redis needs REDIS_URL")
+	}
+	if cfg.CacheBackend == cacheMemcached && len(cfg.MemcachedServers) == 0 {
+		return nil, fmt.Errorf("CACHE_BACKEND memcached needs MEMCACHED_SERVERS")
+	}
+	if cfg.CacheBackend == cacheFile {
+		if cfg.CacheDir == "" {
+			return nil, fmt.Errorf("CACHE_BACKEND file needs CACHE_DIR")
+		}
+		if err := os.MkdirAll(cfg.CacheDir, 0o750); err != nil {
+			return nil, fmt.Errorf("creating CACHE_DIR: %w", err)
+		}
+	}
+	if err := envPositive(getenv, "CACHE_MAX_ENTRIES", &cfg.CacheMaxEntries); err != nil {
+		return nil, err
+	}
+	if err := envPositive(getenv, "CACHE_MAX_BYTES", &cfg.CacheMaxBytes); err != nil {
+		return nil, err
+	}
+	if v := getenv("CACHE_TTL_JITTER"); v != "" {
+		jitter, err := strconv.Atoi(v)
+		if err != nil || jitter < 0 || jitter > 50 {
+			return nil, fmt.Errorf("CACHE_TTL_JITTER must be a percentage from 0 to 50, got %q", v)
+		}
+		cfg.CacheTTLJitter = jitter
+	}
+	if err := envDuration(getenv, "CACHE_METRICS_INTERVAL", &cfg.CacheMetricsInterval); err != nil {
+		return nil, err
+	}
+
+	if err := envPositive(getenv, "DISTINCT_REPOS_LIMIT", &cfg.DistinctReposLimit); err != nil {
+		return nil, err
+	}
+	if err := envPositive(getenv, "HISTORY_LIMIT", &cfg.HistoryLimit); err != nil {
+		return nil, err
+	}
+
+	if v := getenv("GLOBAL_OUTBOUND_CONCURRENCY"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("GLOBAL_OUTBOUND_CONCURRENCY must be a non-negative number, got %q", v)
+		}
+		cfg.GlobalOutboundConcurrency = limit
+	}
+
+	if err := envDuration(getenv, "OUTBOUND_CONNECT_TIMEOUT", &cfg.OutboundConnectTimeout); err != nil {
+		return nil, err
+	}
+	if err := envDuration(getenv, "OUTBOUND_TIMEOUT", &cfg.OutboundTimeout); err != nil {
+		return nil, err
+	}
+	if v := getenv("OUTBOUND_RETRIES"); v != "" {
+		retries, err := strconv.Atoi(v)
+		if err != nil || retries < 0 {
+			return nil, fmt.Errorf("OUTBOUND_RETRIES must be a non-negative number, got %q", v)
+		}
+		cfg.OutboundRetries = retries
+	}
+	if err := envDuration(getenv, "OUTBOUND_RETRY_BACKOFF", &cfg.OutboundRetryBackoff); err != nil {
+		return nil, err
+	}
+	if err := envDuration(getenv, "OUTBOUND_MAX_THROTTLE_WAIT", &cfg.OutboundMaxThrottleWait); err != nil {
+		return nil, err
+	}
+	if err := envPositive(getenv, "OUTBOUND_MAX_IDLE_CONNS_PER_HOST", &cfg.OutboundMaxIdlePerHost); err != nil {
+		return nil, err
+	}
+	if err := envDuration(getenv, "OUTBOUND_IDLE_CONN_TIMEOUT", &cfg.OutboundIdleTimeout); err != nil {
+		return nil, err
+	}
+	if cfg.OutboundDisableHTTP2, err = envBool(getenv, "OUTBOUND_DISABLE_HTTP2"); err != nil {
+		return nil, err
+	}
+	if err := envTTL(getenv, "OUTBOUND_DNS_TTL", &cfg.OutboundDNSTTL); err != nil {
+		return nil, err
+	}
+	if cfg.OutboundStaticHosts, err = envStaticHosts(getenv, "OUTBOUND_STATIC_HOSTS"); err != nil {
+		return nil, err
+	}
+	if err := envPositive(getenv, "BREAKER_FAILURES", &cfg.BreakerFailures); err != nil {
+		return nil, err
+	}
+	if err := envDuration(getenv, "BREAKER_COOLDOWN", &cfg.BreakerCooldown); err != nil {
+		return nil, err
+	}
+
+	if err := envPositive(getenv, "BATCH_CONCURRENCY", &cfg.BatchConcurrency); err != nil {
+		return nil, err
+	}
+	if err := envPositive(getenv, "BATCH_MAX_ITEMS", &cfg.BatchMaxItems); err != nil {
+		return nil, err
+	}
+	if err := envPositive(getenv, "SBOM_CONCURRENCY", &cfg.SBOMConcurrency); err != nil {
+		return nil, err
+	}
+	if err := envPositive(getenv, "SBOM_MAX_COMPONENTS", &cfg.SBOMMaxComponents); err != nil {
+		return nil, err
+	}
+	if err := envPositive(getenv, "SCORECARD_MAX_CONCURRENCY", &cfg.ScorecardMaxConcurrency); err != nil {
+		return nil, err
+	}
+	if err := envPositive(getenv, "SCAN_CONCURRENCY", &cfg.ScanConcurrency); err != nil {
+		return nil, err
+	}
+	if err := envPositive(getenv, "SCAN_QUEUE_LENGTH", &cfg.ScanQueueLength); err != nil {
+		return nil, err
+	}
+
+	rules := getenv("REPO_REWRITE_RULES")
+	if path := getenv("REPO_REWRITE_RULES_FILE"); path != "" {
+		data, err := os.ReadFile(path) // #nosec G304
+		if err != nil {
+			return nil, fmt.Errorf("reading REPO_REWRITE_RULES_FILE: %w", err)
+		}
+		rules += "\n" + string(data)
+	}
+	if cfg.RepoRewriteRules, err = parseRewriteRules(rules); err != nil {
+		return nil, err
+	}
+
+	cfg.WarmRepos = getenv("WARM_REPOS")
+	cfg.WarmReposFile = getenv("WARM_REPOS_FILE")
+	if err := envTTL(getenv, "WARM_INTERVAL", &cfg.WarmInterval); err != nil {
+		return nil, err
+	}
+	if v := getenv("HOT_REPOS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("HOT_REPOS must be a non-negative number, got %q", v)
+		}
+		cfg.HotRepos = n
+	}
+	if err := envPositive(getenv, "HOT_REPOS_TRACKED", &cfg.HotReposTracked); err != nil {
+		return nil, err
+	}
+	if err := envPositive(getenv, "HOT_REPOS_CONCURRENCY", &cfg.HotReposConcurrency); err != nil {
+		return nil, err
+	}
+	if err := envDuration(getenv, "HOT_REPOS_INTERVAL", &cfg.HotReposInterval); err != nil {
+		return nil, err
+	}
+	if cfg.PersistScorecards, err = envBool(getenv, "PERSIST_SCORECARDS"); err != nil {
+		return nil, err
+	}
+	if cfg.StoreParallelFetch, err = envBool(getenv, "STORE_PARALLEL_FETCH"); err != nil {
+		return nil, err
+	}
+
+	if v := getenv("GRADE_THRESHOLDS"); v != "" {
+		if cfg.GradeScale, err = parseGradeScale(v); err != nil {
+			return nil, err
+		}
+	}
+
+	if policy := getenv("REF_COMMIT_CONFLICT"); policy != "" {
+		if policy != conflictError && policy != conflictPreferCommit && policy != conflictPreferRef {
+			return nil, fmt.Errorf("REF_COMMIT_CONFLICT must be error, prefer_commit or prefer_ref, got %q", policy)
+		}
+		cfg.RefCommitConflict = policy
+	}
+
+	if check := getenv("AGGREGATE_CHECK"); check != "" {
+		if check != "log" && check != "flag" && check != "off" {
+			return nil, fmt.Errorf("AGGREGATE_CHECK must be log, flag or off, got %q", check)
+		}
+		cfg.AggregateCheck = check
+	}
+	if v := getenv("AGGREGATE_TOLERANCE"); v != "" {
+		tolerance, err := strconv.ParseFloat(v, 64)
+		if err != nil || tolerance < 0 {
+			return nil, fmt.Errorf("AGGREGATE_TOLERANCE must be a non-negative number, got %q", v)
+		}
+		cfg.AggregateTolerance = tolerance
+	}
+
+	if err := envFailModes(getenv, "MIDDLEWARE_FAIL_MODE", cfg.MiddlewareFailModes); err != nil {
+		return nil, err
+	}
+
+	cfg.TLSCertFile = getenv("TLS_CERT_FILE")
+	cfg.TLSKeyFile = getenv("TLS_KEY_FILE")
+	if v := getenv("TLS_MIN_VERSION"); v != "" {
+		var ok bool
+		if cfg.TLSMinVersion, ok = tlsVersions[v]; !ok {
+			return nil, fmt.Errorf("unsupported TLS_MIN_VERSION %q, use 1.2 or 1.3", v)
+		}
+	}
+	if names := getenv("TLS_CIPHER_SUITES"); names != "" {
+		if cfg.TLSCipherSuites, err = parseCipherSuites(names); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// envBool reads an optional true/false variable; unset means false
+func envBool(getenv func(string) string, name string) (bool, error) {
+	v := getenv(name)
+	if v == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false, got %q", name, v)
+	}
+	return b, nil
+}
+
+// envMillis reads an optional non-negative millisecond count into d, leaving d alone when unset
+func envMillis(getenv func(string) string, name string, d *time.Duration) error {
+	v := getenv(name)
+	if v == "" {
+		return nil
+	}
+
+	ms, err := strconv.Atoi(v)
+	if err != nil || ms < 0 {
+		return fmt.Errorf("%s must be a non-negative number of milliseconds, got %q", name, v)
+	}
+	*d = time.Duration(ms) * time.Millisecond
+	return nil
+}
+
+// envPositive reads an optional positive integer into n, leaving n alone when unset
+func envPositive(getenv func(string) string, name string, n *int) error {
+	v := getenv(name)
+	if v == "" {
+		return nil
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil || i <= 0 {
+		return fmt.Errorf("%s must be a positive number, got %q", name, v)
+	}
+	*n = i
+	return nil
+}
+
+// envDuration reads an optional positive duration such as "15m" into d, leaving d alone when unset
+func envDuration(getenv func(string) string, name string, d *time.Duration) error {
+	v := getenv(name)
+	if v == "" {
+		return nil
+	}
+
+	parsed, err := time.ParseDuration(v)
+	if err != nil || parsed <= 0 {
+		return fmt.Errorf("%s must be a positive duration such as 15m, got %q", name, v)
+	}
+	*d = parsed
+	return nil
+}
+
+// envTTL reads an optional non-negative duration such as "1h" into d, leaving d alone when unset.
+// Zero turns off what the duration bounds.
+func envTTL(getenv func(string) string, name string, d *time.Duration) error {
+	v := getenv(name)
+	if v == "" {
+		return nil
+	}
+
+	parsed, err := time.ParseDuration(v)
+	if err != nil || parsed < 0 {
+		return fmt.Errorf("%s must be a non-negative duration such as 1h, got %q", name, v)
+	}
+	*d = parsed
+	return nil
+}
+
+// envURL reads an optional absolute url into s without its trailing slash, leaving s alone when unset
+func envURL(getenv func(string) string, name string, s *string) error {
+	v := getenv(name)
+	if v == "" {
+		return nil
+	}
+
+	u, err := url.Parse(v)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("%s must be an absolute url, got %q", name, v)
+	}
+	*s = strings.TrimRight(v, "/")
+	return nil
+}
+
+// envURLs reads an optional comma separated list of absolute urls into s, each without its
+// trailing slash, leaving s alone when unset
+func envURLs(getenv func(string) string, name string, s *[]string) error {
+	var urls []string
+	for _, v := range strings.Split(getenv(name), ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		u, err := url.Parse(v)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("%s must list absolute urls, got %q", name, v)
+		}
+		urls = append(urls, strings.TrimRight(v, "/"))
+	}
+	if len(urls) > 0 {
+		*s = urls
+	}
+	return nil
+}
+
+// envStaticHosts reads an optional comma separated list of host=ip pairs into the addresses of
+// each host, lowercased
+func envStaticHosts(getenv func(string) string, name string) (map[string][]string, error) {
+	hosts := make(map[string][]string)
+	for _, pair := range strings.Split(getenv(name), ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		host, ip, ok := strings.Cut(pair, "=")
+		host, ip = strings.ToLower(strings.TrimSpace(host)), strings.TrimSpace(ip)
+		if !ok || host == "" || net.ParseIP(ip) == nil {
+			return nil, fmt.Errorf("%s must list host=ip pairs, got %q", name, pair)
+		}
+		hosts[host] = append(hosts[host], ip)
+	}
+	return hosts, nil
+}
+
+// envHosts reads an optional comma separated list of host names, lowercased
+func envHosts(getenv func(string) string, name string) []string {
+	var hosts []string
+	for _, host := range strings.Split(getenv(name), ",") {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// envFailModes reads an optional open or closed for every middleware, or comma separated
+// name=mode pairs, into modes, which holds the middlewares known and their defaults
+func envFailModes(getenv func(string) string, name string, modes map[string]string) error {
+	valid := func(mode string) bool { return mode == failOpen || mode == failClosed }
+	v := strings.ToLower(strings.TrimSpace(getenv(name)))
+	if valid(v) {
+		for middleware := range modes {
+			modes[middleware] = v
+		}
+		return nil
+	}
+
+	for _, pair := range strings.Split(v, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		middleware, mode, _ := strings.Cut(pair, "=")
+		middleware, mode = strings.TrimSpace(middleware), strings.TrimSpace(mode)
+		if _, known := modes[middleware]; !known || !valid(mode) {
+			return fmt.Errorf("%s must be open, closed or name=mode pairs of admin and compress, got %q", name, pair)
+		}
+		modes[middleware] = mode
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"crypto/tls"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2/middleware/compress"
+)
+
+func TestConfigDefaults(t *testing.T) {
+	cfg := testConfig(t, nil)
+
+	if cfg.Port != ":8083" || cfg.RoutePrefix != "" || cfg.Compression != compress.LevelDefault {
+		t.Errorf("serving %q %q %v", cfg.Port, cfg.RoutePrefix, cfg.Compression)
+	}
+	if !slices.Equal(cfg.ScorecardAPIURLs, []string{defaultScorecardAPIURL}) || cfg.GitHubAPIURL != defaultGitHubAPIURL {
+		t.Errorf("upstreams %v and %s", cfg.ScorecardAPIURLs, cfg.GitHubAPIURL)
+	}
+	if !slices.Equal(cfg.GitLabHosts, []string{"gitlab.com"}) || !slices.Equal(cfg.CloneHosts, []string{"codeberg.org"}) {
+		t.Errorf("hosts %v and %v", cfg.GitLabHosts, cfg.CloneHosts)
+	}
+	if cfg.PreferSource != preferAPI || cfg.CoalesceWindow != 50*time.Millisecond || cfg.CacheBackend != cacheMemory {
+		t.Errorf("lookups %s, %v, %s", cfg.PreferSource, cfg.CoalesceWindow, cfg.CacheBackend)
+	}
+	if cfg.CacheTTL != time.Hour || cfg.NegativeCacheTTL != 5*time.Minute || cfg.StaleTTL != 0 {
+		t.Errorf("TTLs %v, %v, %v", cfg.CacheTTL, cfg.NegativeCacheTTL, cfg.StaleTTL)
+	}
+	if cfg.ReadOnly || cfg.StrictDecode || cfg.PreserveCase || cfg.PersistScorecards || cfg.GitHubToken != "" {
+		t.Errorf("a switch is on by default: %+v", cfg)
+	}
+	if cfg.TLSMinVersion != tls.VersionTLS12 || cfg.TLSCertFile != "" {
+		t.Errorf("TLS %x, %q", cfg.TLSMinVersion, cfg.TLSCertFile)
+	}
+}
+
+func TestConfigOverrides(t *testing.T) {
+	cfg := testConfig(t, map[string]string{
+		"MS_PORT":                     "9000",
+		"ROUTE_PREFIX":                "/scorecard/",
+		"COMPRESSION":                 "Best",
+		"GH_HOST":                     "GHE.example.com",
+		"SCORECARD_API_URLS":          "https://a.example.com/projects/, https://b.example.com/projects",
+		"GITLAB_HOSTS":                "gitlab.example.com",
+		"READ_ONLY":                   "true",
+		"PREFER_SOURCE":               "cli",
+		"COALESCE_WINDOW_MS":          "0",
+		"SCORECARD_CACHE_TTL":         "0",
+		"SCORECARD_STALE_TTL":         "10m",
+		"BATCH_CONCURRENCY":           "3",
+		"GLOBAL_OUTBOUND_CONCURRENCY": "0",
+		"TLS_MIN_VERSION":             "1.3",
+	})
+
+	if cfg.Port != ":9000" || cfg.RoutePrefix != "/scorecard" || cfg.Compression != compress.LevelBestCompression {
+		t.Errorf("serving %q %q %v", cfg.Port, cfg.RoutePrefix, cfg.Compression)
+	}
+	if cfg.GitHubHost != "ghe.example.com" || cfg.GitHubAPIURL != "https://ghe.example.com/api/v3" {
+		t.Errorf("GitHub %s at %s", cfg.GitHubHost, cfg.GitHubAPIURL)
+	}
+	if !slices.Equal(cfg.ScorecardAPIURLs, []string{"https://a.example.com/projects", "https://b.example.com/projects"}) {
+		t.Errorf("API urls %v", cfg.ScorecardAPIURLs)
+	}
+	if !slices.Equal(cfg.GitLabHosts, []string{"gitlab.com", "gitlab.example.com"}) {
+		t.Errorf("GitLab hosts %v", cfg.GitLabHosts)
+	}
+	if !cfg.ReadOnly || cfg.PreferSource != preferCLI || cfg.CoalesceWindow != 0 {
+		t.Errorf("lookups %v, %s, %v", cfg.ReadOnly, cfg.PreferSource, cfg.CoalesceWindow)
+	}
+	if cfg.CacheTTL != 0 || cfg.StaleTTL != 10*time.Minute || cfg.BatchConcurrency != 3 || cfg.GlobalOutboundConcurrency != 0 {
+		t.Errorf("cache %v %v, batch %d, outbound %d", cfg.CacheTTL, cfg.StaleTTL, cfg.BatchConcurrency, cfg.GlobalOutboundConcurrency)
+	}
+	if cfg.TLSMinVersion != tls.VersionTLS13 {
+		t.Errorf("TLS %x", cfg.TLSMinVersion)
+	}
+
+	// setupRoutes makes the Config the one every handler reads
+	newTestApp(t, map[string]string{"PREFER_SOURCE": "cli", "READ_ONLY": "true"})
+	if config.PreferSource != preferCLI || !readOnly.Load() {
+		t.Errorf("active config %s, read-only %v", config.PreferSource, readOnly.Load())
+	}
+}
+
+func TestConfigRejectsMalformedValues(t *testing.T) {
+	for name, value := range map[string]string{
+		"COMPRESSION":          "max",
+		"GITHUB_API_URL":       "api.github.com",
+		"SCORECARD_API_URLS":   "https://a.example.com,/relative",
+		"READ_ONLY":            "yes please",
+		"COALESCE_WINDOW_MS":   "-1",
+		"SCAN_TIMEOUT":         "0s",
+		"SCORECARD_CACHE_TTL":  "an hour",
+		"BATCH_CONCURRENCY":    "0",
+		"OUTBOUND_RETRIES":     "-2",
+		"CACHE_BACKEND":        "redis",
+		"HOT_REPOS":            "-1",
+		"MIDDLEWARE_FAIL_MODE": "auth=open",
+	} {
+		_, err := loadConfig(func(n string) string {
+			if n == name {
+				return value
+			}
+			return ""
+		})
+		if err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("%s=%s: %v, want an error naming it", name, value, err)
+		}
+	}
+}
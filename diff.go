@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	ossf "github.com/ossf/scorecard/v5/pkg/scorecard"
+)
+
+var errCommitNotScored = errors.New("no scorecard of this commit is available, only of a different commit")
+
+// diffSide is the scorecard a diff compares, as the commit it was computed for
+type diffSide struct {
+	Commit string  `json:"commit"`
+	Date   string  `json:"date"`
+	Score  float64 `json:"score"`
+	Source string  `json:"source"`
+}
+
+// checkDelta is the score of a check at both commits and the change from one to the other.
+// A score of -1 means the check was inconclusive or did not run at that commit.
+type checkDelta struct {
+	Name  string `json:"name"`
+	From  int    `json:"from"`
+	To    int    `json:"to"`
+	Delta int    `json:"delta"`
+}
+
+// diffResponse is the body returned by the diff endpoint. ScoreDelta is omitted when either
+// commit has no aggregate.
+type diffResponse struct {
+	Repo         string       `json:"repo"`
+	From         diffSide     `json:"from"`
+	To           diffSide     `json:"to"`
+	ScoreDelta   *float64     `json:"score_delta,omitempty"`
+	Improved     []checkDelta `json:"improved"`
+	Regressed    []checkDelta `json:"regressed"`
+	Unchanged    []checkDelta `json:"unchanged"`
+	Inconclusive []checkDelta `json:"inconclusive"`
+}
+
+// diffScorecards compares the check scores of two results. Checks are matched by name and
+// listed by name; a check without a score at either commit is inconclusive.
+func diffScorecards(from, to *ossf.JSONScorecardResultV2) diffResponse {
+	scores := map[string]*checkDelta{}
+	delta := func(name string) *checkDelta {
+		if d, ok := scores[name]; ok {
+			return d
+		}
+		d := &checkDelta{Name: name, From: -1, To: -1}
+		scores[name] = d
+		return d
+	}
+	for _, check := range from.Checks {
+		delta(check.Name).From = check.Score
+	}
+	for _, check := range to.Checks {
+		delta(check.Name).To = check.Score
+	}
+
+	names := make([]string, 0, len(scores))
+	for name := range scores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	diff := diffResponse{Improved: []checkDelta{}, Regressed: []checkDelta{}, Unchanged: []checkDelta{}, Inconclusive: []checkDelta{}}
+	for _, name := range names {
+		d := *scores[name]
+		if d.From < 0 || d.To < 0 {
+			diff.Inconclusive = append(diff.Inconclusive, d)
+			continue
+		}
+
+		d.Delta = d.To - d.From
+		switch {
+		case d.Delta > 0:
+			diff.Improved = append(diff.Improved, d)
+		case d.Delta < 0:
+			diff.Regressed = append(diff.Regressed, d)
+		default:
+			diff.Unchanged = append(diff.Unchanged, d)
+		}
+	}
+
+	if from.AggregateScore >= 0 && to.AggregateScore >= 0 {
+		change := math.Round(float64(to.AggregateScore-from.AggregateScore)*10) / 10
+		diff.ScoreDelta = &change
+	}
+	return diff
+}
+
+// scoreCommit looks up the scorecard of exactly one commit. The API answers a commit it has
+// not scored with the latest result, which is of no use for a comparison.
+func scoreCommit(req lookupRequest) (*ossf.JSONScorecardResultV2, string, error) {
+	commitSha := req.commit
+	result, source, err := coalescedLookup(req)
+	if err != nil {
+		return nil, source, err
+	}
+	if !strings.HasPrefix(strings.ToLower(result.Repo.Commit), strings.ToLower(commitSha)) {
+		return nil, source, fmt.Errorf("%w: %s", errCommitNotScored, commitSha)
+	}
+	return result, source, nil
+}
+
+// getDiff godoc
+// @Summary Compare the OSSF scorecards of two commits
+// @Description Look up the scorecards of the from and to commits of a repo and list which checks
+// @Description improved, regressed or stayed flat, each with its scores and delta. Checks without a score
+// @Description at either commit are listed as inconclusive, with -1 for the missing score. from and to
+// @Description may also be branches or tags, resolved as ?ref= is. Both commits must have been scored;
+// @Description the latest result is never substituted.
+// @Tags scorecard
+// @Produce json
+// @Param from query string true "commit sha, branch or tag of the baseline"
+// @Param to query string true "commit sha, branch or tag to compare with the baseline"
+// @Param prefer query string false "which source to try first; defaults to PREFER_SOURCE or api" Enums(api, cli)
+// @Param refresh query bool false "skip the cache and the lookups shared within COALESCE_WINDOW_MS and fetch the scorecard again"
+// @Param X-Repo-Token header string false "forge token used instead of the configured one to scan a private repo; Authorization: Bearer also works"
+// @Success 200 {object} diffResponse
+// @Success 202 {object} processingResponse "a scorecard still being computed"
+// @Failure 400 {object} errorResponse "INVALID_REPO or REF_NOT_RESOLVABLE"
+// @Failure 404 {object} errorResponse "COMMIT_NOT_SCORED, REF_NOT_FOUND or any lookup 404"
+// @Failure 502 {object} errorResponse "UPSTREAM_ERROR"
+// @Router /msapi/scorecard/:key/diff [get]
+func getDiff(c *fiber.Ctx) error {
+	prefer, err := preference(c)
+	if err != nil {
+		return err
+	}
+	if c.Query("from") == "" || c.Query("to") == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "both from and to are required")
+	}
+
+	githubURL, err := lookupRepo(c)
+	if err != nil {
+		return sendLookupError(c, err)
+	}
+
+	token := requestToken(c)
+	refresh := c.QueryBool("refresh")
+	refs := []string{c.Query("from"), c.Query("to")}
+	results := make([]*ossf.JSONScorecardResultV2, len(refs))
+	sides := make([]diffSide, len(refs))
+	errs := make([]error, len(refs))
+	runConcurrently(len(refs), config.BatchConcurrency, func(i int) {
+		commitSha, err := revision(githubURL, "", refs[i], token)
+		if err != nil {
+			errs[i] = err
+			return
+		}
+
+		var source string
+		req := lookupRequest{repo: githubURL, commit: commitSha, prefer: prefer, token: token, refresh: refresh}
+		if results[i], source, errs[i] = scoreCommit(req); errs[i] == nil {
+			r := results[i]
+			sides[i] = diffSide{Commit: r.Repo.Commit, Date: r.Date, Score: float64(r.AggregateScore), Source: source}
+		}
+	})
+	for _, err := range errs {
+		if err != nil {
+			return sendLookupError(c, err)
+		}
+	}
+
+	recordScored(githubURL)
+	diff := diffScorecards(results[0], results[1])
+	diff.Repo, diff.From, diff.To = githubURL, sides[0], sides[1]
+	return c.JSON(diff)
+}
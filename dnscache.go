@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// dnsMetrics counts the upstream host resolutions, the dials served from the cache or from
+// OUTBOUND_STATIC_HOSTS, and the dials that fell back to stale addresses when resolving failed
+var dnsMetrics = expvar.NewMap("scorecard_dns")
+
+// dnsCache dials the upstreams by addresses it resolved at most ttl ago, so a batch does not
+// resolve the same host for every new connection. Hosts of OUTBOUND_STATIC_HOSTS are never
+// resolved. When resolving fails, the last addresses of the host are used however old they
+// are, so a hiccup of the cluster DNS does not fail the calls to a host it already knew.
+type dnsCache struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	ttl      time.Duration
+	static   map[string][]string
+	resolved map[string]dnsEntry
+	lookups  singleflight.Group
+	resolver *net.Resolver
+	dialer   *net.Dialer
+}
+
+// dnsEntry is the addresses of a host and when they were resolved
+type dnsEntry struct {
+	addrs []string
+	at    time.Time
+}
+
+func newDNSCache(ttl time.Duration, static map[string][]string, dialer *net.Dialer, now func() time.Time) *dnsCache {
+	return &dnsCache{
+		now:      now,
+		ttl:      ttl,
+		static:   static,
+		resolved: make(map[string]dnsEntry),
+		resolver: net.DefaultResolver,
+		dialer:   dialer,
+	}
+}
+
+// dialContext is the transport's DialContext, trying the addresses of the host in turn
+func (d *dnsCache) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || net.ParseIP(host) != nil {
+		return d.dialer.DialContext(ctx, network, address)
+	}
+
+	addrs, err := d.addrs(ctx, strings.ToLower(host))
+	if err != nil {
+		return nil, err
+	}
+	var errs []error
+	for _, addr := range addrs {
+		conn, err := d.dialer.DialContext(ctx, network, net.JoinHostPort(addr, port))
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// addrs is the static, cached or freshly resolved addresses of host
+func (d *dnsCache) addrs(ctx context.Context, host string) ([]string, error) {
+	if addrs, ok := d.static[host]; ok {
+		dnsMetrics.Add("static", 1)
+		return addrs, nil
+	}
+
+	d.mu.Lock()
+	entry, ok := d.resolved[host]
+	d.mu.Unlock()
+	if ok && d.now().Sub(entry.at) < d.ttl {
+		dnsMetrics.Add("hits", 1)
+		return entry.addrs, nil
+	}
+
+	v, err, _ := d.lookups.Do(host, func() (any, error) {
+		dnsMetrics.Add("lookups", 1)
+		// not the caller's context, as the lookup is shared with the other dials waiting for it
+		lookupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), d.dialer.Timeout)
+		defer cancel()
+		return d.resolver.LookupHost(lookupCtx, host)
+	})
+	if err != nil {
+		if ok {
+			dnsMetrics.Add("stale", 1)
+			logger.Warn("resolving an upstream failed, dialling its last known addresses", zap.String("host", host), zap.Error(err))
+			return entry.addrs, nil
+		}
+		return nil, err
+	}
+
+	// kept with a zero ttl too, for the dials made while resolving fails
+	addrs := v.([]string)
+	d.mu.Lock()
+	d.resolved[host] = dnsEntry{addrs: addrs, at: d.now()}
+	d.mu.Unlock()
+	return addrs, nil
+}
@@ -23,23 +23,2451 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/admin/cache/stats": {
+            "get": {
+                "description": "Report the entries, hits, misses, hit ratio, evictions and approximate memory of the\nCACHE_BACKEND cache, to tune the cache TTLs and CACHE_MAX_ENTRIES and CACHE_MAX_BYTES with.\nHits and misses are counted by this instance since startup; with redis, memory and\nevictions are those of the whole Redis, and memcached reports neither nor its entries.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get the cache statistics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.CacheStats"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/readonly": {
+            "get": {
+                "description": "Report whether outbound API calls and scans are disabled",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get the read-only mode",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.readOnlyState"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Enable or disable outbound API calls and scans without a redeploy",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Set the read-only mode",
+                "parameters": [
+                    {
+                        "description": "desired state",
+                        "name": "state",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.readOnlyState"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.readOnlyState"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    }
+                }
+            }
+        },
         "/msapi/scorecard/:key": {
             "get": {
-                "description": "Get a scorecard for a repo and commit sha",
+                "description": "Get a scorecard for a repo and commit sha. A key on a host that is not a known forge,\ne.g. go.uber.org/zap, is first resolved as a Go module path, from its go-import meta tag on\nthe GO_IMPORT_HOSTS and else from GO_PROXY_URL, and the resolved repo is returned in the\nX-Resolved-Repo header. Scorecards are cached for SCORECARD_CACHE_TTL unless fetched with a\ncaller's token, and a repo without one is remembered for SCORECARD_NEGATIVE_CACHE_TTL. Past\nits TTL a cached scorecard is still returned for SCORECARD_STALE_TTL, flagged with\nX-Scorecard-Stale: true, while it is fetched again, and for SCORECARD_FALLBACK_TTL more,\nflagged the same, when the OpenSSF API fails or BREAKER_FAILURES failures in a row have\npaused calls to it for BREAKER_COOLDOWN.\nThe ETag and Last-Modified change only when the repo is scored again; send them back in\nIf-None-Match or If-Modified-Since to get a 304. Cache-Control lets shared caches keep a\nscorecard of the requested commit, immutable, for SCORECARD_PINNED_MAX_AGE and any other for\nSCORECARD_MAX_AGE; one fetched with a caller's token is private.",
                 "consumes": [
                     "*/*"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/x-protobuf"
                 ],
                 "tags": [
                     "scorecard"
                 ],
                 "summary": "Get the OSSF scorecard for a repo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "commit sha",
+                        "name": "commit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "branch or tag to score, resolved to its commit through the forge; REF_COMMIT_CONFLICT decides when it disagrees with commit",
+                        "name": "ref",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "ignore commit and ref and return the latest, unpinned scorecard",
+                        "name": "latest",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "protobuf"
+                        ],
+                        "type": "string",
+                        "description": "response format overriding the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "include per-check scores, reasons and documentation links",
+                        "name": "verbose",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "list the checks as verbose does, each with its details",
+                        "name": "details",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add aggregate_present, the equal-weighted mean of the checks that ran",
+                        "name": "aggregate_present",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated fields to return, in order, in any case, e.g. score,pinned,branchprotection",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add meta with the scored repo, commit, analysis date and scorecard version",
+                        "name": "provenance",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add grade, the aggregate as a letter on the GRADE_THRESHOLDS scale or N/A without an aggregate",
+                        "name": "include_grade",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "list only the checks in these comma separated risk tiers, e.g. Critical,High",
+                        "name": "risk",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add unmapped_checks, the scores of checks with no scorecard field; defaults to INCLUDE_UNMAPPED_CHECKS",
+                        "name": "include_unmapped",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "api",
+                            "cli"
+                        ],
+                        "type": "string",
+                        "description": "which source to try first; defaults to PREFER_SOURCE or api",
+                        "name": "prefer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "skip the cache and the lookups shared within COALESCE_WINDOW_MS and fetch the scorecard again",
+                        "name": "refresh",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "forge token used instead of the configured one to scan a private repo; Authorization: Bearer also works",
+                        "name": "X-Repo-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.scorecardResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "scorecard still being computed",
+                        "schema": {
+                            "$ref": "#/definitions/main.processingResponse"
+                        }
+                    },
+                    "304": {
+                        "description": "the scorecard If-None-Match or If-Modified-Since describes is still current"
+                    },
+                    "400": {
+                        "description": "INVALID_REPO, REF_NOT_RESOLVABLE or REF_COMMIT_CONFLICT",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "REQUEST_TOKEN_INVALID",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "REPO_NOT_FOUND, SCORECARD_NOT_COMPUTED, NO_SCORECARD, MODULE_NOT_FOUND or REF_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable"
+                    },
+                    "502": {
+                        "description": "UPSTREAM_ERROR or TOKEN_INVALID",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "READ_ONLY, no cached scorecard, or SCAN_BUSY, the scan queue is full",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "UPSTREAM_TIMEOUT",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/msapi/scorecard/:key/badge": {
+            "get": {
+                "description": "Render the aggregate score as a badge for READMEs and the Ortelius UI, green from 8,\nyellow from 4 and red below 2. The badge is served with a public Cache-Control of\nBADGE_MAX_AGE so image proxies and browsers do not look the repo up on every page\nview. A repo that cannot be scored gets a grey badge that is not cached.",
+                "produces": [
+                    "image/svg+xml"
+                ],
+                "tags": [
+                    "scorecard"
+                ],
+                "summary": "Get an SVG badge of the OSSF scorecard score",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "commit sha",
+                        "name": "commit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "branch or tag to score, resolved to its commit through the forge",
+                        "name": "ref",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "ignore commit and ref and use the latest, unpinned result",
+                        "name": "latest",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "api",
+                            "cli"
+                        ],
+                        "type": "string",
+                        "description": "which source to try first; defaults to PREFER_SOURCE or api",
+                        "name": "prefer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "skip the cache and the lookups shared within COALESCE_WINDOW_MS and fetch the scorecard again",
+                        "name": "refresh",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "SVG badge",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/msapi/scorecard/:key/diff": {
+            "get": {
+                "description": "Look up the scorecards of the from and to commits of a repo and list which checks\nimproved, regressed or stayed flat, each with its scores and delta. Checks without a score\nat either commit are listed as inconclusive, with -1 for the missing score. from and to\nmay also be branches or tags, resolved as ?ref= is. Both commits must have been scored;\nthe latest result is never substituted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scorecard"
+                ],
+                "summary": "Compare the OSSF scorecards of two commits",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "commit sha, branch or tag of the baseline",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "commit sha, branch or tag to compare with the baseline",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "api",
+                            "cli"
+                        ],
+                        "type": "string",
+                        "description": "which source to try first; defaults to PREFER_SOURCE or api",
+                        "name": "prefer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "skip the cache and the lookups shared within COALESCE_WINDOW_MS and fetch the scorecard again",
+                        "name": "refresh",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "forge token used instead of the configured one to scan a private repo; Authorization: Bearer also works",
+                        "name": "X-Repo-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.diffResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "a scorecard still being computed",
+                        "schema": {
+                            "$ref": "#/definitions/main.processingResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_REPO or REF_NOT_RESOLVABLE",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "COMMIT_NOT_SCORED, REF_NOT_FOUND or any lookup 404",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "UPSTREAM_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/msapi/scorecard/:key/history": {
+            "get": {
+                "description": "Return the distinct scorecards this instance has returned for the repo since startup,\noldest analysis date first, each with its commit, aggregate and check scores. At most\nHISTORY_LIMIT snapshots are kept per repo. Lookups made with a caller's token are not recorded.\nWith PERSIST_SCORECARDS set the snapshots are read from ArangoDB instead, every one\nrecorded since persistence was turned on, on any instance.\nWith at, a day or an RFC 3339 time, only the snapshot in effect then is returned, the\nlatest analysed no later than at, e.g. the score of the repo when a release shipped.\nSnapshots come per_page at a time, at most 500, and total counts them all.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scorecard"
+                ],
+                "summary": "Get the recorded scorecards of a repo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "a day, e.g. 2024-05-01, or an RFC 3339 time",
+                        "name": "at",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page, from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, 50 by default and at most 500",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.historyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_REPO or an invalid at or page",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/msapi/scorecard/:key/raw": {
+            "get": {
+                "description": "Return the JSONScorecardResultV2 document behind a scorecard, as the API or the scan\nproduced it, with every check's reason and details. The key, commit, ref and latest\nare resolved as on the scorecard endpoint. The source is returned in the X-Scorecard-Source header.\nThe ETag and Last-Modified change only when the repo is scored again; send them back in\nIf-None-Match or If-Modified-Since to get a 304. Cache-Control is set as on the scorecard endpoint.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scorecard"
+                ],
+                "summary": "Get the OpenSSF result for a repo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "commit sha",
+                        "name": "commit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "branch or tag to score, resolved to its commit through the forge; REF_COMMIT_CONFLICT decides when it disagrees with commit",
+                        "name": "ref",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "ignore commit and ref and return the latest, unpinned result",
+                        "name": "latest",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "api",
+                            "cli"
+                        ],
+                        "type": "string",
+                        "description": "which source to try first; defaults to PREFER_SOURCE or api",
+                        "name": "prefer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "skip the cache and the lookups shared within COALESCE_WINDOW_MS and fetch the scorecard again",
+                        "name": "refresh",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "forge token used instead of the configured one to scan a private repo; Authorization: Bearer also works",
+                        "name": "X-Repo-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "JSONScorecardResultV2",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "202": {
+                        "description": "scorecard still being computed",
+                        "schema": {
+                            "$ref": "#/definitions/main.processingResponse"
+                        }
+                    },
+                    "304": {
+                        "description": "the result If-None-Match or If-Modified-Since describes is still current"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/msapi/scorecard/:key/shield": {
+            "get": {
+                "description": "Describe the score badge in the shields.io endpoint schema, for\nhttps://img.shields.io/endpoint?url=..., with the colors of the SVG badge. cacheSeconds\nis BADGE_MAX_AGE, and a repo that cannot be scored is returned as an isError badge.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scorecard"
+                ],
+                "summary": "Get the OSSF scorecard score as a shields.io endpoint badge",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "commit sha",
+                        "name": "commit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "branch or tag to score, resolved to its commit through the forge",
+                        "name": "ref",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "ignore commit and ref and use the latest, unpinned result",
+                        "name": "latest",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "api",
+                            "cli"
+                        ],
+                        "type": "string",
+                        "description": "which source to try first; defaults to PREFER_SOURCE or api",
+                        "name": "prefer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "skip the cache and the lookups shared within COALESCE_WINDOW_MS and fetch the scorecard again",
+                        "name": "refresh",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.shieldsEndpoint"
+                        }
+                    }
+                }
+            }
+        },
+        "/msapi/scorecard/batch": {
+            "post": {
+                "description": "Score a list of repo and commit pairs in one call. Lookups run concurrently, at most\nBATCH_CONCURRENCY at a time and SCORECARD_MAX_CONCURRENCY across all batch, SBOM,\ncompare and diff requests, and results are returned in the order given.\nA lookup that fails reports its error code in place of the scorecard.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scorecard"
+                ],
+                "summary": "Get OSSF scorecards for many repos",
+                "parameters": [
+                    {
+                        "description": "repos to score",
+                        "name": "items",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.batchItem"
+                            }
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "include per-check scores, reasons and documentation links",
+                        "name": "verbose",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "list the checks as verbose does, each with its details",
+                        "name": "details",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add aggregate_present, the equal-weighted mean of the checks that ran",
+                        "name": "aggregate_present",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add meta with the scored repo, commit, analysis date and scorecard version",
+                        "name": "provenance",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add grade, the aggregate as a letter on the GRADE_THRESHOLDS scale or N/A without an aggregate",
+                        "name": "include_grade",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "list only the checks in these comma separated risk tiers, e.g. Critical,High",
+                        "name": "risk",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add unmapped_checks, the scores of checks with no scorecard field; defaults to INCLUDE_UNMAPPED_CHECKS",
+                        "name": "include_unmapped",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "forge token used instead of the configured one to scan a private repo; Authorization: Bearer also works",
+                        "name": "X-Repo-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.batchResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large"
+                    }
+                }
+            }
+        },
+        "/msapi/scorecard/cache/:key": {
+            "delete": {
+                "description": "Drop the cached scorecards and misses of every commit of a repo, or with no repo the\nwhole cache, so the next lookups go to the upstream. Use it when the upstream returned\nwrong data or after rotating a leaked token. memcached cannot list its keys, so there only\nthe repo's latest scorecard is dropped and purging everything flushes the whole memcached.\nRequires ADMIN_TOKEN, sent as X-Admin-Token.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Purge cached scorecards",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.cachePurge"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_REPO",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    }
+                }
+            }
+        },
+        "/msapi/scorecard/checks": {
+            "get": {
+                "description": "For every check the scorecard library documents: its short and full description, risk\nlevel, remediation steps, tags, supported repo types, documentation link and, when it is\nmapped into the scorecard, its model field",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scorecard"
+                ],
+                "summary": "Describe the OpenSSF checks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.checkInfo"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/msapi/scorecard/compare": {
+            "get": {
+                "description": "Look up the latest scorecard of each repo and lay the check scores out side by side:\na column per repo, in the order given, and a row per check, listed by name.\nA check a repo has no score for is -1 in its column. Lookups run like a batch,\nat most BATCH_CONCURRENCY at a time and at most BATCH_MAX_ITEMS repos, and a\nlookup that fails reports its error code in place of the repo's score.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scorecard"
+                ],
+                "summary": "Compare the OSSF scorecards of several repos",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "comma separated repos to compare, at least two, e.g. github.com/a/b,github.com/c/d",
+                        "name": "repos",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "api",
+                            "cli"
+                        ],
+                        "type": "string",
+                        "description": "which source to try first; defaults to PREFER_SOURCE or api",
+                        "name": "prefer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "skip the cache and the lookups shared within COALESCE_WINDOW_MS and fetch the scorecard again",
+                        "name": "refresh",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "forge token used instead of the configured one to scan a private repo; Authorization: Bearer also works",
+                        "name": "X-Repo-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.compareResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large"
+                    }
+                }
+            }
+        },
+        "/msapi/scorecard/map": {
+            "post": {
+                "description": "Collapse a raw OpenSSF JSONScorecardResultV2 document into a scorecard without any network access",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/x-protobuf"
+                ],
+                "tags": [
+                    "scorecard"
+                ],
+                "summary": "Map a raw OSSF scorecard",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "commit sha used to decide if the result is pinned",
+                        "name": "commit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "protobuf"
+                        ],
+                        "type": "string",
+                        "description": "response format overriding the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "include per-check scores, reasons and documentation links",
+                        "name": "verbose",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "list the checks as verbose does, each with its details",
+                        "name": "details",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add aggregate_present, the equal-weighted mean of the checks that ran",
+                        "name": "aggregate_present",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated fields to return, in order, in any case, e.g. score,pinned,branchprotection",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add meta with the scored repo, commit, analysis date and scorecard version",
+                        "name": "provenance",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add grade, the aggregate as a letter on the GRADE_THRESHOLDS scale or N/A without an aggregate",
+                        "name": "include_grade",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "list only the checks in these comma separated risk tiers, e.g. Critical,High",
+                        "name": "risk",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add unmapped_checks, the scores of checks with no scorecard field; defaults to INCLUDE_UNMAPPED_CHECKS",
+                        "name": "include_unmapped",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.scorecardResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "406": {
+                        "description": "Not Acceptable"
+                    }
+                }
+            }
+        },
+        "/msapi/scorecard/metadata": {
+            "get": {
+                "description": "For every check mapped into the scorecard: its model field and JSON key,\nOpenSSF risk tier, the weight of that tier in the aggregate and its documentation",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scorecard"
+                ],
+                "summary": "List the supported checks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/main.checkMetadata"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/msapi/scorecard/normalize": {
+            "get": {
+                "description": "Show how a repo url is normalized before lookup, which transformations were applied\nand, when a REPO_REWRITE_RULES rule matches, the url the lookup is rewritten to",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scorecard"
+                ],
+                "summary": "Normalize a repo url",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "raw repo url",
+                        "name": "url",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.normalizedURL"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    }
+                }
+            }
+        },
+        "/msapi/scorecard/org/evaluate": {
+            "post": {
+                "description": "List the repos of a GitHub org or user, archived ones left out, and evaluate the scorecard\nof each against the policy: an aggregate of at least min_score and at least the given\nscore in each named check, where a missing or inconclusive check fails. At most limit repos\nare evaluated, BATCH_MAX_ITEMS by default and at most, looked up BATCH_CONCURRENCY at a\ntime. Requires ADMIN_TOKEN, sent as X-Admin-Token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Evaluate a policy across the repos of an org",
+                "parameters": [
+                    {
+                        "description": "org as host/org, e.g. github.com/ortelius, and the policy",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.orgEvaluateRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "forge token used instead of the configured one to list the org and scan its private repos; Authorization: Bearer also works",
+                        "name": "X-Repo-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.orgEvaluation"
+                        }
+                    },
+                    "400": {
+                        "description": "ORG_NOT_LISTABLE, an invalid org or policy or a limit past BATCH_MAX_ITEMS",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "ORG_NOT_FOUND",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "UPSTREAM_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "READ_ONLY",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/msapi/scorecard/package/{ecosystem}/{name}": {
+            "get": {
+                "description": "Resolve a package name to its source repo through deps.dev and return that repo's\nscorecard, e.g. npm/lodash, pypi/requests or maven/com.fasterxml.jackson.core:jackson-databind.\nWithout a version the package's default version is resolved. The resolved repo is\nreturned in the X-Resolved-Repo header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scorecard"
+                ],
+                "summary": "Get the OSSF scorecard for an ecosystem package",
+                "parameters": [
+                    {
+                        "enum": [
+                            "npm",
+                            "pypi",
+                            "maven",
+                            "golang"
+                        ],
+                        "type": "string",
+                        "description": "package ecosystem",
+                        "name": "ecosystem",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "package name, scope or module path included",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "package version to resolve",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "protobuf"
+                        ],
+                        "type": "string",
+                        "description": "response format overriding the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "include per-check scores, reasons and documentation links",
+                        "name": "verbose",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "list the checks as verbose does, each with its details",
+                        "name": "details",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add aggregate_present, the equal-weighted mean of the checks that ran",
+                        "name": "aggregate_present",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated fields to return, in order, in any case, e.g. score,pinned,branchprotection",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add meta with the scored repo, commit, analysis date and scorecard version",
+                        "name": "provenance",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add grade, the aggregate as a letter on the GRADE_THRESHOLDS scale or N/A without an aggregate",
+                        "name": "include_grade",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "list only the checks in these comma separated risk tiers, e.g. Critical,High",
+                        "name": "risk",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add unmapped_checks, the scores of checks with no scorecard field; defaults to INCLUDE_UNMAPPED_CHECKS",
+                        "name": "include_unmapped",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "api",
+                            "cli"
+                        ],
+                        "type": "string",
+                        "description": "which source to try first; defaults to PREFER_SOURCE or api",
+                        "name": "prefer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "skip the cache and the lookups shared within COALESCE_WINDOW_MS and fetch the scorecard again",
+                        "name": "refresh",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.scorecardResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "scorecard still being computed",
+                        "schema": {
+                            "$ref": "#/definitions/main.processingResponse"
+                        }
+                    },
+                    "304": {
+                        "description": "the scorecard If-None-Match or If-Modified-Since describes is still current"
+                    },
+                    "400": {
+                        "description": "INVALID_PACKAGE",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "PACKAGE_NOT_FOUND, NO_SOURCE_REPO or any repo lookup 404",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "UPSTREAM_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "READ_ONLY",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/msapi/scorecard/purl": {
+            "get": {
+                "description": "Resolve a purl to its source repo and return that repo's scorecard. pkg:github,\npkg:gitlab and pkg:bitbucket purls name the repo, with the version, a tag, branch or sha,\nresolved to a commit as ?ref= is; npm, pypi, maven and golang purls are resolved through\ndeps.dev. The resolved repo is returned in the X-Resolved-Repo header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scorecard"
+                ],
+                "summary": "Get the OSSF scorecard for a package url",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "package url, e.g. pkg:npm/express@4.18.2 or pkg:github/ortelius/scec-scorecard@v1.0.0",
+                        "name": "purl",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "protobuf"
+                        ],
+                        "type": "string",
+                        "description": "response format overriding the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "include per-check scores, reasons and documentation links",
+                        "name": "verbose",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "list the checks as verbose does, each with its details",
+                        "name": "details",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add aggregate_present, the equal-weighted mean of the checks that ran",
+                        "name": "aggregate_present",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated fields to return, in order, in any case, e.g. score,pinned,branchprotection",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add meta with the scored repo, commit, analysis date and scorecard version",
+                        "name": "provenance",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add grade, the aggregate as a letter on the GRADE_THRESHOLDS scale or N/A without an aggregate",
+                        "name": "include_grade",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "list only the checks in these comma separated risk tiers, e.g. Critical,High",
+                        "name": "risk",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add unmapped_checks, the scores of checks with no scorecard field; defaults to INCLUDE_UNMAPPED_CHECKS",
+                        "name": "include_unmapped",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "api",
+                            "cli"
+                        ],
+                        "type": "string",
+                        "description": "which source to try first; defaults to PREFER_SOURCE or api",
+                        "name": "prefer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "skip the cache and the lookups shared within COALESCE_WINDOW_MS and fetch the scorecard again",
+                        "name": "refresh",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "forge token used instead of the configured one to scan a private repo; Authorization: Bearer also works",
+                        "name": "X-Repo-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.scorecardResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "scorecard still being computed",
+                        "schema": {
+                            "$ref": "#/definitions/main.processingResponse"
+                        }
+                    },
+                    "304": {
+                        "description": "the scorecard If-None-Match or If-Modified-Since describes is still current"
+                    },
+                    "400": {
+                        "description": "INVALID_PURL, INVALID_REPO or REF_NOT_RESOLVABLE",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "PACKAGE_NOT_FOUND, NO_SOURCE_REPO, REF_NOT_FOUND or any repo lookup 404",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "UPSTREAM_ERROR or TOKEN_INVALID",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "READ_ONLY",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/msapi/scorecard/regression": {
+            "post": {
+                "description": "Look up the scorecard of a commit and compare it with the baseline, the latest scorecard\nstored for another commit of the repo and analysed no later than this one. Every check is\nlisted with its scores and delta, -1 for a score that is missing at either commit, and\nregressed is true when the aggregate or any check dropped. Without a baseline, baseline is\nnull and only the current scorecard is given. Needs PERSIST_SCORECARDS.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scorecard"
+                ],
+                "summary": "Check a commit's OSSF scorecard for a regression",
+                "parameters": [
+                    {
+                        "description": "repo and commit sha to check",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.regressionRequest"
+                        }
+                    },
+                    {
+                        "enum": [
+                            "api",
+                            "cli"
+                        ],
+                        "type": "string",
+                        "description": "which source to try first; defaults to PREFER_SOURCE or api",
+                        "name": "prefer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "skip the cache and the lookups shared within COALESCE_WINDOW_MS and fetch the scorecard again",
+                        "name": "refresh",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "forge token used instead of the configured one to scan a private repo; Authorization: Bearer also works",
+                        "name": "X-Repo-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.regressionResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "a scorecard still being computed",
+                        "schema": {
+                            "$ref": "#/definitions/main.processingResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "INVALID_REPO or a body without repo and commit",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "COMMIT_NOT_SCORED or any lookup 404",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "STORE_DISABLED",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "UPSTREAM_ERROR",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "STORE_UNAVAILABLE",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/msapi/scorecard/sbom": {
+            "post": {
+                "description": "Resolve every component of a CycloneDX or SPDX 2.x JSON SBOM, nested ones included, to\nits source repo and score each distinct repo once. A component resolves through its vcs\nexternal reference or git+ downloadLocation, else its purl as on the purl endpoint. Components that resolve to no repo are\nlisted under unresolved. Components are resolved and repos scored SBOM_CONCURRENCY at\na time. Only the first SBOM_MAX_COMPONENTS components are scored, and truncated is set\nwhen there were more; total_components counts them all.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scorecard"
+                ],
+                "summary": "Get the OSSF scorecards for the components of an SBOM",
+                "parameters": [
+                    {
+                        "description": "CycloneDX or SPDX 2.x JSON SBOM",
+                        "name": "sbom",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "include per-check scores, reasons and documentation links",
+                        "name": "verbose",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "list the checks as verbose does, each with its details",
+                        "name": "details",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add aggregate_present, the equal-weighted mean of the checks that ran",
+                        "name": "aggregate_present",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add meta with the scored repo, commit, analysis date and scorecard version",
+                        "name": "provenance",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add grade, the aggregate as a letter on the GRADE_THRESHOLDS scale or N/A without an aggregate",
+                        "name": "include_grade",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "list only the checks in these comma separated risk tiers, e.g. Critical,High",
+                        "name": "risk",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add unmapped_checks, the scores of checks with no scorecard field; defaults to INCLUDE_UNMAPPED_CHECKS",
+                        "name": "include_unmapped",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "forge token used instead of the configured one to scan a private repo; Authorization: Bearer also works",
+                        "name": "X-Repo-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.sbomResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    }
+                }
+            }
+        },
+        "/msapi/scorecard/stream/:key": {
+            "get": {
+                "description": "Server-Sent Events reporting each lookup stage (started, api-miss, cli-started, done)\nfollowed by the scorecard in a result event, or an error event. A cached or stored scorecard\nis sent without asking the upstream, so only started and done come before it.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "scorecard"
+                ],
+                "summary": "Stream the OSSF scorecard lookup for a repo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "commit sha",
+                        "name": "commit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "branch or tag to score, resolved to its commit through the forge; REF_COMMIT_CONFLICT decides when it disagrees with commit",
+                        "name": "ref",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "ignore commit and ref and return the latest, unpinned scorecard",
+                        "name": "latest",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "api",
+                            "cli"
+                        ],
+                        "type": "string",
+                        "description": "which source to try first; defaults to PREFER_SOURCE or api",
+                        "name": "prefer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "forge token used instead of the configured one to scan a private repo; Authorization: Bearer also works",
+                        "name": "X-Repo-Token",
+                        "in": "header"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    }
+                }
+            }
+        },
+        "/msapi/scorecards": {
+            "get": {
+                "description": "List the latest stored scorecard of every repo, lowest aggregate first, so the repos\nbelow a threshold can be found without looking each one up. minScore and maxScore bound\nthe aggregate; lt and gte bound the score of the check named by check, and a repo whose\ncheck is missing or inconclusive does not match them. Results come per_page at a time,\nat most 500, and total counts every match. org lists only the repos of one org, and\nsummary adds the mean and median aggregate of every match, how many are below the\nbelow threshold and the worst of them, lowest first. Needs PERSIST_SCORECARDS.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scorecard"
+                ],
+                "summary": "Query the stored scorecards",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "host and owner of the repos to list, e.g. github.com/ortelius",
+                        "name": "org",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "lowest aggregate",
+                        "name": "minScore",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "highest aggregate",
+                        "name": "maxScore",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "check name, e.g. Branch-Protection",
+                        "name": "check",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "the check scores below this",
+                        "name": "lt",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "the check scores at least this",
+                        "name": "gte",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page, from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, 50 by default and at most 500",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "older name of per_page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "add summary, the rollup of every match",
+                        "name": "summary",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "aggregate the summary counts repos below, 5 by default",
+                        "name": "below",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "how many of the lowest scoring repos the summary lists, 5 by default and at most 500",
+                        "name": "worst",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.scorecardPage"
+                        }
+                    },
+                    "400": {
+                        "description": "an invalid filter or page",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "STORE_DISABLED",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "STORE_UNAVAILABLE",
+                        "schema": {
+                            "$ref": "#/definitions/main.errorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "main.CacheStats": {
+            "type": "object",
+            "properties": {
+                "backend": {
+                    "type": "string"
+                },
+                "entries": {
+                    "type": "integer"
+                },
+                "evictions": {
+                    "type": "integer"
+                },
+                "hit_ratio": {
+                    "type": "number"
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "memory_bytes": {
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.batchItem": {
+            "type": "object",
+            "properties": {
+                "commit": {
+                    "type": "string"
+                },
+                "ref": {
+                    "type": "string"
+                },
+                "repo": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.batchResult": {
+            "type": "object",
+            "properties": {
+                "commit": {
+                    "type": "string"
+                },
+                "error": {
+                    "$ref": "#/definitions/main.errorResponse"
+                },
+                "repo": {
+                    "type": "string"
+                },
+                "scorecard": {
+                    "$ref": "#/definitions/main.scorecardResponse"
+                }
+            }
+        },
+        "main.cachePurge": {
+            "type": "object",
+            "properties": {
+                "purged": {
+                    "type": "integer"
+                },
+                "repo": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.checkDelta": {
+            "type": "object",
+            "properties": {
+                "delta": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.checkDetail": {
+            "type": "object",
+            "properties": {
+                "details": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "documentation": {
+                    "$ref": "#/definitions/main.checkDocumentation"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "risk": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.checkDocumentation": {
+            "type": "object",
+            "properties": {
+                "short": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.checkInfo": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "documentation_url": {
+                    "type": "string"
+                },
+                "field": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "remediation": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "repo_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "risk": {
+                    "type": "string"
+                },
+                "short": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "main.checkMetadata": {
+            "type": "object",
+            "properties": {
+                "documentation_url": {
+                    "type": "string"
+                },
+                "field": {
+                    "type": "string"
+                },
+                "json_key": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "risk": {
+                    "type": "string"
+                },
+                "weight": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.compareRepo": {
+            "type": "object",
+            "properties": {
+                "commit": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "error": {
+                    "$ref": "#/definitions/main.errorResponse"
+                },
+                "repo": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "source": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.compareResponse": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.compareRow"
+                    }
+                },
+                "repos": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.compareRepo"
+                    }
+                }
+            }
+        },
+        "main.compareRow": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "scores": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "main.diffResponse": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "$ref": "#/definitions/main.diffSide"
+                },
+                "improved": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.checkDelta"
+                    }
+                },
+                "inconclusive": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.checkDelta"
+                    }
+                },
+                "regressed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.checkDelta"
+                    }
+                },
+                "repo": {
+                    "type": "string"
+                },
+                "score_delta": {
+                    "type": "number"
+                },
+                "to": {
+                    "$ref": "#/definitions/main.diffSide"
+                },
+                "unchanged": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.checkDelta"
+                    }
+                }
+            }
+        },
+        "main.diffSide": {
+            "type": "object",
+            "properties": {
+                "commit": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "source": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.errorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.historyResponse": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "per_page": {
+                    "type": "integer"
+                },
+                "repo": {
+                    "type": "string"
+                },
+                "snapshots": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.snapshot"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.normalizedURL": {
+            "type": "object",
+            "properties": {
+                "host": {
+                    "type": "string"
+                },
+                "input": {
+                    "type": "string"
+                },
+                "normalized": {
+                    "type": "string"
+                },
+                "rewritten": {
+                    "type": "string"
+                },
+                "stripped": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "main.orgEvaluateRequest": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "org": {
+                    "type": "string"
+                },
+                "policy": {
+                    "$ref": "#/definitions/main.scorecardPolicy"
+                }
+            }
+        },
+        "main.orgEvaluation": {
+            "type": "object",
+            "properties": {
+                "org": {
+                    "type": "string"
+                },
+                "repos": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.repoEvaluation"
+                    }
+                },
+                "summary": {
+                    "$ref": "#/definitions/main.orgSummary"
+                }
+            }
+        },
+        "main.orgSummary": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "passed": {
+                    "type": "integer"
+                },
+                "repos": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.processingResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.readOnlyState": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "main.regressionRequest": {
+            "type": "object",
+            "properties": {
+                "commit": {
+                    "type": "string"
+                },
+                "repo": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.regressionResponse": {
+            "type": "object",
+            "properties": {
+                "baseline": {
+                    "$ref": "#/definitions/main.diffSide"
+                },
+                "checks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.checkDelta"
+                    }
+                },
+                "current": {
+                    "$ref": "#/definitions/main.diffSide"
+                },
+                "regressed": {
+                    "type": "boolean"
+                },
+                "repo": {
+                    "type": "string"
+                },
+                "score_delta": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.repoEvaluation": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/main.errorResponse"
+                },
+                "pass": {
+                    "type": "boolean"
+                },
+                "repo": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "violations": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "main.responseMeta": {
+            "type": "object",
+            "properties": {
+                "aggregate_consistent": {
+                    "type": "boolean"
+                },
+                "commit": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "repo": {
+                    "type": "string"
+                },
+                "scorecard_commit": {
+                    "type": "string"
+                },
+                "scorecard_version": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.sbomComponent": {
+            "type": "object",
+            "properties": {
+                "bom_ref": {
+                    "type": "string"
+                },
+                "error": {
+                    "$ref": "#/definitions/main.errorResponse"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "purl": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "main.sbomResponse": {
+            "type": "object",
+            "properties": {
+                "scorecards": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.sbomResult"
+                    }
+                },
+                "total_components": {
+                    "type": "integer"
+                },
+                "truncated": {
+                    "type": "boolean"
+                },
+                "unresolved": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.sbomComponent"
+                    }
+                }
+            }
+        },
+        "main.sbomResult": {
+            "type": "object",
+            "properties": {
+                "commit": {
+                    "type": "string"
+                },
+                "components": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.sbomComponent"
+                    }
+                },
+                "error": {
+                    "$ref": "#/definitions/main.errorResponse"
+                },
+                "repo": {
+                    "type": "string"
+                },
+                "scorecard": {
+                    "$ref": "#/definitions/main.scorecardResponse"
+                }
+            }
+        },
+        "main.scorecardPage": {
+            "type": "object",
+            "properties": {
+                "page": {
+                    "type": "integer"
+                },
+                "per_page": {
+                    "type": "integer"
+                },
+                "scorecards": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.storedSummary"
+                    }
+                },
+                "summary": {
+                    "$ref": "#/definitions/main.scorecardSummary"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.scorecardPolicy": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "min_score": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.scorecardResponse": {
+            "type": "object",
+            "properties": {
+                "aggregate_present": {
+                    "type": "number"
+                },
+                "binary_artifacts": {
+                    "type": "number"
+                },
+                "branch_protection": {
+                    "type": "number"
+                },
+                "checks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.checkDetail"
+                    }
+                },
+                "ci_tests": {
+                    "type": "number"
+                },
+                "cii_best_practices": {
+                    "type": "number"
+                },
+                "code_review": {
+                    "type": "number"
+                },
+                "commit_sha": {
+                    "type": "string"
+                },
+                "contributors": {
+                    "type": "number"
+                },
+                "dangerous_workflow": {
+                    "type": "number"
+                },
+                "dependency_update_tool": {
+                    "type": "number"
+                },
+                "fuzzing": {
+                    "type": "number"
+                },
+                "grade": {
+                    "type": "string"
+                },
+                "license": {
+                    "type": "number"
+                },
+                "maintained": {
+                    "type": "number"
+                },
+                "meta": {
+                    "$ref": "#/definitions/main.responseMeta"
+                },
+                "packaging": {
+                    "type": "number"
+                },
+                "pinned": {
+                    "type": "boolean"
+                },
+                "pinned_dependencies": {
+                    "type": "number"
+                },
+                "resolved_ref": {
+                    "type": "string"
+                },
+                "sast": {
+                    "type": "number"
+                },
+                "sbom": {
+                    "type": "number"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "security_policy": {
+                    "type": "number"
+                },
+                "signed_releases": {
+                    "type": "number"
+                },
+                "token_permissions": {
+                    "type": "number"
+                },
+                "unmapped_checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "vulnerabilities": {
+                    "type": "number"
+                },
+                "webhooks": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.scorecardSummary": {
+            "type": "object",
+            "properties": {
+                "below": {
+                    "type": "integer"
+                },
+                "mean": {
+                    "type": "number"
+                },
+                "median": {
+                    "type": "number"
+                },
+                "repos": {
+                    "type": "integer"
+                },
+                "threshold": {
+                    "type": "number"
+                },
+                "worst": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/main.storedSummary"
+                    }
+                }
+            }
+        },
+        "main.shieldsEndpoint": {
+            "type": "object",
+            "properties": {
+                "cacheSeconds": {
+                    "type": "integer"
+                },
+                "color": {
+                    "type": "string"
+                },
+                "isError": {
+                    "type": "boolean"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "schemaVersion": {
+                    "type": "integer"
+                }
+            }
+        },
+        "main.snapshot": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "commit": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                }
+            }
+        },
+        "main.storedSummary": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
                     }
+                },
+                "commit": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "repo": {
+                    "type": "string"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "stored": {
+                    "type": "string"
                 }
             }
         }
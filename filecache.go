@@ -0,0 +1,169 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// fileCache is the Cache kept as a JSON file per entry in CACHE_DIR, typically a mounted
+// volume, so cached scorecards survive restarts. With a long SCORECARD_STALE_TTL a
+// restarted instance keeps serving the repos it has seen while the upstream is
+// unreachable. Expired files are removed when read and swept out at startup; disk
+// failures are logged and treated as misses.
+type fileCache struct {
+	cacheCounters
+	dir string
+	now func() time.Time
+}
+
+// fileEntry is the content of a cache file: the key it is for, so a purge can match it,
+// and when it expires
+type fileEntry struct {
+	Key   string     `json:"key"`
+	Until time.Time  `json:"until"`
+	Entry CacheEntry `json:"entry"`
+}
+
+func newFileCache(dir string, now func() time.Time) *fileCache {
+	c := &fileCache{dir: dir, now: now}
+	go c.sweep()
+	return c
+}
+
+// path is the file of key, named by its hash as keys hold slashes
+func (c *fileCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// load reads the cache file at path
+func (c *fileCache) load(path string) (fileEntry, error) {
+	data, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return fileEntry{}, err
+	}
+	var entry fileEntry
+	err = json.Unmarshal(data, &entry)
+	return entry, err
+}
+
+func (c *fileCache) Get(key string) (CacheEntry, bool) {
+	path := c.path(key)
+	entry, err := c.load(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return c.read(CacheEntry{}, false)
+	case err != nil || entry.Key != key:
+		logger.Warn("file cache entry is unreadable", zap.String("key", key), zap.Error(err))
+		return c.read(CacheEntry{}, false)
+	case !c.now().Before(entry.Until):
+		c.remove(path)
+		c.evictions.Add(1)
+		return c.read(CacheEntry{}, false)
+	}
+	return c.read(entry.Entry, true)
+}
+
+// Set writes the entry to a temporary file renamed over the old one, so a reader never
+// sees a partly written entry
+func (c *fileCache) Set(key string, entry CacheEntry, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(fileEntry{Key: key, Until: c.now().Add(ttl), Entry: entry})
+	if err != nil {
+		logger.Warn("file cache entry cannot be encoded", zap.String("key", key), zap.Error(err))
+		return
+	}
+
+	tmp, err := os.CreateTemp(c.dir, ".entry-*")
+	if err != nil {
+		logger.Warn("file cache write failed", zap.String("key", key), zap.Error(err))
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path(key))
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		logger.Warn("file cache write failed", zap.String("key", key), zap.Error(err))
+	}
+}
+
+func (c *fileCache) Invalidate(key string) {
+	c.remove(c.path(key))
+}
+
+// remove deletes a cache file, which may already be gone
+func (c *fileCache) remove(path string) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		logger.Warn("file cache removal failed", zap.String("path", path), zap.Error(err))
+	}
+}
+
+// walk calls fn with the path, entry and size of every readable cache file
+func (c *fileCache) walk(fn func(path string, entry fileEntry, size int64)) {
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		logger.Warn("file cache listing failed", zap.String("dir", c.dir), zap.Error(err))
+		return
+	}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(c.dir, file.Name())
+		entry, err := c.load(path)
+		info, statErr := file.Info()
+		if err != nil || statErr != nil {
+			continue
+		}
+		fn(path, entry, info.Size())
+	}
+}
+
+// sweep removes the expired cache files
+func (c *fileCache) sweep() {
+	now := c.now()
+	c.walk(func(path string, entry fileEntry, _ int64) {
+		if !now.Before(entry.Until) {
+			c.remove(path)
+			c.evictions.Add(1)
+		}
+	})
+}
+
+func (c *fileCache) Purge(prefix string) int {
+	purged := 0
+	c.walk(func(path string, entry fileEntry, _ int64) {
+		if strings.HasPrefix(entry.Key, prefix) {
+			c.remove(path)
+			purged++
+		}
+	})
+	return purged
+}
+
+// Stats counts the cache files and their size on disk as memory
+func (c *fileCache) Stats() CacheStats {
+	stats := c.stats(CacheStats{Backend: cacheFile})
+	c.walk(func(_ string, _ fileEntry, size int64) {
+		stats.Entries++
+		stats.MemoryBytes += size
+	})
+	return stats
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultGitHubHost   = "github.com"
+	defaultGitHubAPIURL = "https://api.github.com"
+)
+
+var (
+	errRepoNotFound         = errors.New("the repository does not exist or is not visible to the configured token")
+	errScorecardNotComputed = errors.New("OpenSSF has not computed a scorecard for this repository; retry later or pass ?commit= so the commit can be scanned")
+	errTokenInvalid         = errors.New("GitHub rejected the configured GITHUB_TOKEN; it is invalid or has expired and must be replaced")
+)
+
+// authFailureSignatures appear in scorecard scan errors when GitHub rejects the token
+var authFailureSignatures = []string{"bad credentials", "401 unauthorized", "requires authentication"}
+
+// isAuthFailure reports whether a scan error shows GitHub rejecting the token
+func isAuthFailure(message string) bool {
+	message = strings.ToLower(message)
+	for _, signature := range authFailureSignatures {
+		if strings.Contains(message, signature) {
+			return true
+		}
+	}
+	return false
+}
+
+// errorResponse is the structured body returned for lookups that did not produce a scorecard
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// isGitHubRepo reports whether a normalized repo url is on github.com or the GH_HOST instance
+func isGitHubRepo(repoURL string) bool {
+	host, _, _ := strings.Cut(repoURL, "/")
+	return host == defaultGitHubHost || host == config.GitHubHost
+}
+
+// onConfiguredGitHub reports whether a normalized repo url is on the GH_HOST instance, the
+// one GITHUB_TOKEN and GITHUB_API_URL belong to
+func onConfiguredGitHub(repoURL string) bool {
+	host, _, _ := strings.Cut(repoURL, "/")
+	return host == config.GitHubHost
+}
+
+// isGitHubEnterprise reports whether a normalized repo url is on a GH_HOST other than
+// github.com. The OpenSSF API only scores github.com, so these repos are always scanned.
+func isGitHubEnterprise(repoURL string) bool {
+	return config.GitHubHost != defaultGitHubHost && onConfiguredGitHub(repoURL)
+}
+
+// githubRepoExists asks GITHUB_API_URL whether host/owner/repo exists on the GH_HOST instance.
+// A 401 means the token was rejected, which says nothing about the repo.
+func githubRepoExists(githubURL, token string) (bool, error) {
+	_, path, _ := strings.Cut(githubURL, "/")
+
+	release := outbound.acquire()
+	resp, err := client.R().SetAuthToken(token).Get(config.GitHubAPIURL + "/repos/" + path)
+	release()
+	if err != nil {
+		return false, err
+	}
+
+	switch resp.StatusCode() {
+	case fiber.StatusOK:
+		return true, nil
+	case fiber.StatusNotFound:
+		return false, nil
+	case fiber.StatusUnauthorized:
+		return false, errTokenInvalid
+	default:
+		return false, errors.New("github repo probe returned " + resp.Status())
+	}
+}
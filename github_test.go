@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestAnAPI404IsToldApartByTheGitHubProbe(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		token  string
+		probe  int // the probe's status, zero for an existing repo
+		status int
+		code   string
+	}{
+		{"existing repo", "token", 0, fiber.StatusNotFound, "SCORECARD_NOT_COMPUTED"},
+		{"missing repo", "token", fiber.StatusNotFound, fiber.StatusNotFound, "REPO_NOT_FOUND"},
+		{"rejected token", "token", fiber.StatusUnauthorized, fiber.StatusBadGateway, "TOKEN_INVALID"},
+		{"no token to probe with", "", 0, fiber.StatusNotFound, "NO_SCORECARD"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newFakeAPI(t)
+			github := newFakeAPI(t)
+			if tt.probe == 0 {
+				github.serve("repos/a/b", `{"full_name":"a/b"}`)
+			} else {
+				github.fail("repos/a/b", tt.probe)
+			}
+			app := newTestApp(t, map[string]string{
+				"SCORECARD_API_URLS": api.URL,
+				"GITHUB_API_URL":     github.URL,
+				"GITHUB_TOKEN":       tt.token,
+				"OUTBOUND_RETRIES":   "0",
+			})
+
+			status, body := get(t, app, "/msapi/scorecard/github.com/a/b")
+			var resp errorResponse
+			mustJSON(t, body, &resp)
+			if status != tt.status || resp.Code != tt.code {
+				t.Errorf("status %d, code %s, want %d %s", status, resp.Code, tt.status, tt.code)
+			}
+			if resp.Message == "" {
+				t.Error("no message")
+			}
+
+			probes := github.called("repos/a/b")
+			if tt.token == "" && probes != 0 {
+				t.Errorf("probed %d times without a token", probes)
+			}
+			if tt.token != "" {
+				if probes != 1 {
+					t.Errorf("probed %d times", probes)
+				}
+				if got := github.header("repos/a/b").Get(fiber.HeaderAuthorization); got != "Bearer "+tt.token {
+					t.Errorf("probe sent %q", got)
+				}
+			}
+			if tt.code == "SCORECARD_NOT_COMPUTED" && !strings.Contains(resp.Message, "commit") {
+				t.Errorf("message %q does not suggest scanning a commit", resp.Message)
+			}
+		})
+	}
+}
+
+func TestAScanRefusedByGitHubIsATokenError(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		token  string // the caller's
+		status int
+		code   string
+		warned int
+	}{
+		{"GITHUB_TOKEN", "", fiber.StatusBadGateway, "TOKEN_INVALID", 1},
+		{"caller's token", "caller-token", fiber.StatusUnauthorized, "REQUEST_TOKEN_INVALID", 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := observeLogs(t, zapcore.WarnLevel)
+			api := newFakeAPI(t)
+			github := newFakeAPI(t)
+			github.serve("repos/a/b", `{"full_name":"a/b"}`)
+			scanned := stubScan(t, "", nil, errors.New("GET https://api.github.com/graphql: 401 Bad credentials []"))
+			app := newTestApp(t, map[string]string{
+				"SCORECARD_API_URLS": api.URL,
+				"GITHUB_API_URL":     github.URL,
+				"GITHUB_TOKEN":       "expired-token",
+			})
+
+			req := httptest.NewRequest(fiber.MethodGet, "/msapi/scorecard/github.com/a/b?commit="+sha(1), nil)
+			if tt.token != "" {
+				req.Header.Set("X-Repo-Token", tt.token)
+			}
+			status, body := doRequest(t, app, req)
+			var resp errorResponse
+			mustJSON(t, body, &resp)
+			if status != tt.status || resp.Code != tt.code {
+				t.Errorf("status %d, code %s, want %d %s", status, resp.Code, tt.status, tt.code)
+			}
+			if scanned.Load() != 1 {
+				t.Errorf("scanned %d times", scanned.Load())
+			}
+			if n := logs.FilterMessage("scorecard scan was refused by GitHub, GITHUB_TOKEN is invalid or expired").Len(); n != tt.warned {
+				t.Errorf("warned %d times about GITHUB_TOKEN, want %d", n, tt.warned)
+			}
+		})
+	}
+
+	for message, want := range map[string]bool{
+		"401 Unauthorized":                      true,
+		"GET /repos/a/b: Bad credentials":       true,
+		"This endpoint requires authentication": true,
+		"404 Not Found":                         false,
+		"context deadline exceeded":             false,
+	} {
+		if got := isAuthFailure(message); got != want {
+			t.Errorf("isAuthFailure(%q) = %v", message, got)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var errGoModuleNotFound = errors.New("the key is not a repo and no go-import meta tag or module proxy origin names the repository of this Go module")
+
+var (
+	metaTagPattern  = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	metaAttrPattern = regexp.MustCompile(`(?is)([a-z-]+)\s*=\s*["']([^"']*)["']`)
+)
+
+// isKnownForgeRepo reports whether a normalized repo url is on a host that is scored as
+// a repo, so it is never resolved as a Go module path
+func isKnownForgeRepo(repoURL string) bool {
+	_, cloned := cloneForgeFor(repoURL)
+	return cloned || isGitHubRepo(repoURL) || isGitLabRepo(repoURL)
+}
+
+// resolveGoModule finds the repository of a Go module path such as go.uber.org/zap, from
+// the go-import meta tag served for ?go-get=1 on the GO_IMPORT_HOSTS and else from the
+// origin GO_PROXY_URL reports for the latest version. Only git repositories are scored. A
+// repository found is reused for GO_MODULE_TTL and a module found to have none for
+// SCORECARD_NEGATIVE_CACHE_TTL; a failure of the go-import host or the proxy is not remembered.
+func resolveGoModule(modulePath string) (string, error) {
+	if entry, ok := goModules.get(modulePath); ok {
+		return entry.repo, entry.err
+	}
+	if readOnly.Load() {
+		return "", errReadOnly
+	}
+	return shared("module:"+modulePath, func() (string, error) {
+		repo, err := goImportRepo(modulePath)
+		if importErr := err; err != nil {
+			repo, err = goProxyRepo(modulePath)
+			// the proxy not knowing the module proves nothing when the go-import host failed
+			if errors.Is(err, errGoModuleNotFound) && !errors.Is(importErr, errGoModuleNotFound) {
+				err = importErr
+			}
+		}
+		switch {
+		case err == nil:
+			goModules.set(modulePath, repo, nil, config.GoModuleTTL)
+		case errors.Is(err, errGoModuleNotFound):
+			goModules.set(modulePath, "", err, config.NegativeCacheTTL)
+		}
+		return repo, err
+	})
+}
+
+// goModuleCache is what resolveGoModule found for each module path, until it expires
+type goModuleCache struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	modules map[string]goModuleEntry
+}
+
+// goModuleEntry is the repo of a module, or the error resolving it, and when it expires
+type goModuleEntry struct {
+	repo    string
+	err     error
+	expires time.Time
+}
+
+func newGoModuleCache(now func() time.Time) *goModuleCache {
+	return &goModuleCache{now: now, modules: make(map[string]goModuleEntry)}
+}
+
+// get is the unexpired entry remembered for modulePath
+func (g *goModuleCache) get(modulePath string) (goModuleEntry, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	entry, ok := g.modules[modulePath]
+	if !ok || !g.now().Before(entry.expires) {
+		delete(g.modules, modulePath)
+		return goModuleEntry{}, false
+	}
+	return entry, true
+}
+
+// set remembers the repo or error of modulePath for ttl, or not at all when ttl is zero
+func (g *goModuleCache) set(modulePath, repo string, err error, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.modules[modulePath] = goModuleEntry{repo: repo, err: err, expires: g.now().Add(ttl)}
+}
+
+// goModules is rebuilt by setupRoutes, forgetting every module resolved
+var goModules = newGoModuleCache(time.Now)
+
+// isGoImportHost reports whether host is one of the GO_IMPORT_HOSTS
+func isGoImportHost(host string) bool {
+	return slices.Contains(config.GoImportHosts, strings.ToLower(host))
+}
+
+// goImportRepo reads the repo root from the go-import meta tag whose prefix covers modulePath.
+// Only the GO_IMPORT_HOSTS are asked, so a key cannot make the service call an arbitrary host.
+func goImportRepo(modulePath string) (string, error) {
+	host, _, _ := strings.Cut(modulePath, "/")
+	if !isGoImportHost(host) {
+		return "", errGoModuleNotFound
+	}
+
+	release := outbound.acquire()
+	resp, err := client.R().Get("https://" + modulePath + "?go-get=1")
+	release()
+	if err != nil {
+		return "", upstreamError(fmt.Errorf("go-import: %w", err))
+	}
+	switch status := resp.StatusCode(); {
+	case status >= fiber.StatusInternalServerError:
+		return "", fmt.Errorf("%w: the go-import host returned %s", errUpstream, resp.Status())
+	case status != fiber.StatusOK:
+		return "", errGoModuleNotFound
+	}
+
+	for _, tag := range metaTagPattern.FindAllString(resp.String(), -1) {
+		attrs := map[string]string{}
+		for _, attr := range metaAttrPattern.FindAllStringSubmatch(tag, -1) {
+			attrs[strings.ToLower(attr[1])] = attr[2]
+		}
+		if attrs["name"] != "go-import" {
+			continue
+		}
+
+		fields := strings.Fields(attrs["content"])
+		if len(fields) != 3 || fields[1] != "git" {
+			continue
+		}
+		if prefix := fields[0]; modulePath == prefix || strings.HasPrefix(modulePath, prefix+"/") {
+			return cleanRepoURL(fields[2]), nil
+		}
+	}
+	return "", errGoModuleNotFound
+}
+
+// goProxyInfo is the part of a module proxy @latest response that names the repository
+type goProxyInfo struct {
+	Origin struct {
+		VCS string `json:"VCS"`
+		URL string `json:"URL"`
+	} `json:"Origin"`
+}
+
+// goProxyRepo reads the repository from the origin of the latest version on GO_PROXY_URL
+func goProxyRepo(modulePath string) (string, error) {
+	release := outbound.acquire()
+	resp, err := client.R().Get(config.GoProxyURL + "/" + escapeModulePath(modulePath) + "/@latest")
+	release()
+	if err != nil {
+		return "", upstreamError(fmt.Errorf("module proxy: %w", err))
+	}
+	switch status := resp.StatusCode(); {
+	case status == fiber.StatusNotFound || status == fiber.StatusGone:
+		return "", errGoModuleNotFound
+	case status != fiber.StatusOK:
+		return "", fmt.Errorf("%w: the module proxy returned %s", errUpstream, resp.Status())
+	}
+
+	var info goProxyInfo
+	if err := json.Unmarshal(resp.Body(), &info); err != nil || info.Origin.VCS != "git" || info.Origin.URL == "" {
+		return "", errGoModuleNotFound
+	}
+	return cleanRepoURL(info.Origin.URL), nil
+}
+
+// escapeModulePath applies the module proxy case encoding, where an upper case letter
+// becomes ! and its lower case form
+func escapeModulePath(modulePath string) string {
+	var b strings.Builder
+	for _, r := range modulePath {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// goImportServer serves over TLS, for every ?go-get=1 request, a go-import meta tag naming
+// repo as the root of the module at its host and /vanity. It returns the server and how
+// often it was asked.
+func goImportServer(t *testing.T, repo string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var asked atomic.Int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		asked.Add(1)
+		_, _ = io.WriteString(w, `<html><head><meta name="go-import" content="`+r.Host+`/vanity git https://`+repo+`"></head></html>`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &asked
+}
+
+// trustServer makes the outbound client trust the certificate of srv
+func trustServer(srv *httptest.Server) {
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+	client.SetTLSClientConfig(&tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12})
+}
+
+func TestGoImportOnlyAsksTheAllowedHosts(t *testing.T) {
+	srv, asked := goImportServer(t, "github.com/a/vanity")
+	host := strings.TrimPrefix(srv.URL, "https://")
+	proxy := newFakeAPI(t)
+	proxy.serve(host+"/vanity/@latest", `{"Origin":{"VCS":"git","URL":"https://github.com/a/proxied"}}`)
+
+	newTestApp(t, map[string]string{"GO_PROXY_URL": proxy.URL})
+	trustServer(srv)
+	repo, err := resolveGoModule(host + "/vanity")
+	if err != nil || repo != "github.com/a/proxied" {
+		t.Errorf("resolved to %q, %v, want the proxy's origin", repo, err)
+	}
+	if n := asked.Load(); n != 0 {
+		t.Errorf("a host off GO_IMPORT_HOSTS was asked %d times", n)
+	}
+
+	newTestApp(t, map[string]string{"GO_PROXY_URL": proxy.URL, "GO_IMPORT_HOSTS": host})
+	trustServer(srv)
+	repo, err = resolveGoModule(host + "/vanity/sub")
+	if err != nil || repo != "github.com/a/vanity" || asked.Load() != 1 {
+		t.Errorf("resolved to %q, %v after %d go-get requests, want the go-import root", repo, err, asked.Load())
+	}
+
+	if cfg := testConfig(t, nil); cfg.GoImportHosts != nil || cfg.GoModuleTTL != time.Hour {
+		t.Errorf("defaults %v, %v", cfg.GoImportHosts, cfg.GoModuleTTL)
+	}
+	cfg := testConfig(t, map[string]string{"GO_IMPORT_HOSTS": "Go.Example.com, k8s.io", "GO_MODULE_TTL": "10m"})
+	if !slices.Equal(cfg.GoImportHosts, []string{"go.example.com", "k8s.io"}) || cfg.GoModuleTTL != 10*time.Minute {
+		t.Errorf("GO_IMPORT_HOSTS gave %v, GO_MODULE_TTL %v", cfg.GoImportHosts, cfg.GoModuleTTL)
+	}
+}
+
+func TestGoModulesAreRemembered(t *testing.T) {
+	proxy := newFakeAPI(t)
+	proxy.serve("example.org/found/@latest", `{"Origin":{"VCS":"git","URL":"https://github.com/a/found"}}`)
+	proxy.fail("example.org/failing/@latest", http.StatusInternalServerError)
+	newTestApp(t, map[string]string{"GO_PROXY_URL": proxy.URL, "OUTBOUND_RETRIES": "0"})
+
+	for i := 0; i < 2; i++ {
+		if repo, err := resolveGoModule("example.org/found"); err != nil || repo != "github.com/a/found" {
+			t.Errorf("found: %q, %v", repo, err)
+		}
+		if _, err := resolveGoModule("example.org/missing"); !errors.Is(err, errGoModuleNotFound) {
+			t.Errorf("missing: %v", err)
+		}
+		if _, err := resolveGoModule("example.org/failing"); err == nil || errors.Is(err, errGoModuleNotFound) {
+			t.Errorf("failing: %v, want the proxy failure", err)
+		}
+	}
+	for module, want := range map[string]int{"found": 1, "missing": 1, "failing": 2} {
+		if n := proxy.called("example.org/" + module + "/@latest"); n != want {
+			t.Errorf("%s asked for %d times, want %d", module, n, want)
+		}
+	}
+
+	readOnly.Store(true)
+	if repo, err := resolveGoModule("example.org/found"); err != nil || repo != "github.com/a/found" {
+		t.Errorf("read-only, remembered: %q, %v", repo, err)
+	}
+	if _, err := resolveGoModule("example.org/other"); !errors.Is(err, errReadOnly) {
+		t.Errorf("read-only, not remembered: %v", err)
+	}
+	if n := proxy.called("example.org/other/@latest"); n != 0 {
+		t.Errorf("the proxy was asked %d times while read-only", n)
+	}
+}
+
+func TestGoImportFailureIsNotRemembered(t *testing.T) {
+	// the outbound client does not trust this server, so every go-get request fails to connect
+	srv, _ := goImportServer(t, "github.com/a/vanity")
+	host := strings.TrimPrefix(srv.URL, "https://")
+	proxy := newFakeAPI(t)
+	newTestApp(t, map[string]string{"GO_PROXY_URL": proxy.URL, "GO_IMPORT_HOSTS": host, "OUTBOUND_RETRIES": "0"})
+
+	for i := 0; i < 2; i++ {
+		if _, err := resolveGoModule(host + "/vanity"); !errors.Is(err, errUpstream) {
+			t.Errorf("resolved with %v, want an upstream error", err)
+		}
+	}
+	if n := proxy.called(host + "/vanity/@latest"); n != 2 {
+		t.Errorf("the proxy was asked %d times, want the failure not remembered", n)
+	}
+}
+
+func TestBatchResolvesGoModulePaths(t *testing.T) {
+	proxy := newFakeAPI(t)
+	proxy.serve("example.org/mod/@latest", `{"Origin":{"VCS":"git","URL":"https://github.com/a/mod"}}`)
+	api := newFakeAPI(t)
+	api.serve("github.com/a/mod", resultJSON("github.com/a/mod", sha(1), 7, nil))
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL, "GO_PROXY_URL": proxy.URL})
+
+	batch, _ := json.Marshal([]batchItem{{Repo: "example.org/mod"}})
+	req := httptest.NewRequest(fiber.MethodPost, "/msapi/scorecard/batch", strings.NewReader(string(batch)))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	status, body := doRequest(t, app, req)
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d, body %s", status, body)
+	}
+	var results []batchResult
+	mustJSON(t, body, &results)
+	if len(results) != 1 || results[0].Repo != "github.com/a/mod" || results[0].Scorecard == nil || results[0].Scorecard.Score != 7 {
+		t.Errorf("results %s, want the module scored as github.com/a/mod", body)
+	}
+}
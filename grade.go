@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// gradeNA is the grade of a scorecard without an aggregate score
+const gradeNA = "N/A"
+
+// gradeThreshold is the lowest aggregate that earns a letter
+type gradeThreshold struct {
+	Letter string
+	Min    float64
+}
+
+// defaultGradeScale is A>=9, B>=7, C>=5, D>=3 and F below
+var defaultGradeScale = []gradeThreshold{{"A", 9}, {"B", 7}, {"C", 5}, {"D", 3}, {"F", 0}}
+
+// parseGradeScale reads GRADE_THRESHOLDS, comma separated letter:min pairs from the best
+// grade down, e.g. "A:9,B:7,C:5,D:3,F:0". An aggregate below every minimum is graded
+// with the last letter.
+func parseGradeScale(s string) ([]gradeThreshold, error) {
+	var scale []gradeThreshold
+	for _, pair := range strings.Split(s, ",") {
+		letter, min, found := strings.Cut(strings.TrimSpace(pair), ":")
+		if !found || letter == "" {
+			return nil, fmt.Errorf("grade threshold %q is not of the form letter:min", pair)
+		}
+
+		value, err := strconv.ParseFloat(min, 64)
+		if err != nil {
+			return nil, fmt.Errorf("grade threshold %q: %w", pair, err)
+		}
+		if len(scale) > 0 && value >= scale[len(scale)-1].Min {
+			return nil, fmt.Errorf("grade thresholds must be listed from the highest minimum down, %q is out of order", pair)
+		}
+		scale = append(scale, gradeThreshold{Letter: letter, Min: value})
+	}
+	return scale, nil
+}
+
+// letterGrade maps an aggregate score to a letter on the configured scale.
+// A negative aggregate means OpenSSF could not compute one and is graded N/A.
+func letterGrade(aggregate float64) string {
+	if aggregate < 0 {
+		return gradeNA
+	}
+
+	scale := config.GradeScale
+	for _, threshold := range scale {
+		if aggregate >= threshold.Min {
+			return threshold.Letter
+		}
+	}
+	return scale[len(scale)-1].Letter
+}
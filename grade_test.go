@@ -0,0 +1,56 @@
+package main
+
+import "testing"
+
+func TestLetterGrades(t *testing.T) {
+	for _, tt := range []struct {
+		thresholds string
+		aggregate  float64
+		want       string
+	}{
+		{"", 10, "A"},
+		{"", 9, "A"},
+		{"", 8.9, "B"},
+		{"", 5, "C"},
+		{"", 3.2, "D"},
+		{"", 0, "F"},
+		{"", -1, gradeNA},
+		{"PASS:7,FAIL:4", 7.5, "PASS"},
+		{"PASS:7,FAIL:4", 5, "FAIL"},
+		{"PASS:7,FAIL:4", 1, "FAIL"}, // below every minimum, the last letter
+		{"PASS:7,FAIL:4", -1, gradeNA},
+	} {
+		newTestApp(t, map[string]string{"GRADE_THRESHOLDS": tt.thresholds})
+		if got := letterGrade(tt.aggregate); got != tt.want {
+			t.Errorf("%q: letterGrade(%v) = %s, want %s", tt.thresholds, tt.aggregate, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"A", "A:x", "B:7,A:9", ":5"} {
+		if _, err := parseGradeScale(bad); err == nil {
+			t.Errorf("GRADE_THRESHOLDS %q was accepted", bad)
+		}
+	}
+}
+
+func TestIncludeGrade(t *testing.T) {
+	app := newTestApp(t, map[string]string{"GRADE_THRESHOLDS": "A:8,B:6,C:0"})
+
+	for _, tt := range []struct {
+		query string
+		score float64
+		want  string
+	}{
+		{"?include_grade=true", 8.4, "A"},
+		{"?include_grade=true", 6.1, "B"},
+		{"?include_grade=true", -1, gradeNA},
+		{"", 8.4, ""},
+	} {
+		_, body := postMap(t, app, tt.query, resultJSON("github.com/a/b", sha(1), tt.score, nil))
+		var resp scorecardResponse
+		mustJSON(t, body, &resp)
+		if resp.Grade != tt.want {
+			t.Errorf("%s with %v: grade %q, want %q", tt.query, tt.score, resp.Grade, tt.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// upstreamHealth tracks the outcome of calls to the OpenSSF API so readiness can report on it
+type upstreamHealth struct {
+	mu                  sync.Mutex
+	now                 func() time.Time
+	window              time.Duration
+	started             time.Time
+	lastSuccess         time.Time
+	consecutiveFailures int
+}
+
+// readiness is the body returned by the readiness endpoint
+type readiness struct {
+	Status              string     `json:"status"`
+	LastUpstreamSuccess *time.Time `json:"last_upstream_success"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Breaker             string     `json:"breaker"`
+}
+
+func newUpstreamHealth(window time.Duration, now func() time.Time) *upstreamHealth {
+	return &upstreamHealth{now: now, window: window, started: now()}
+}
+
+func (h *upstreamHealth) recordSuccess() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastSuccess = h.now()
+	h.consecutiveFailures = 0
+}
+
+func (h *upstreamHealth) recordFailure() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.consecutiveFailures++
+}
+
+// record classifies an upstream call; transport errors and 5xx responses count as failures
+func (h *upstreamHealth) record(statusCode int, err error) {
+	if err != nil || statusCode >= fiber.StatusInternalServerError {
+		h.recordFailure()
+		return
+	}
+	h.recordSuccess()
+}
+
+// snapshot reports degraded when there has been no success within the window.
+// Until the first success the window is measured from startup.
+func (h *upstreamHealth) snapshot() readiness {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ready := readiness{Status: "ok", ConsecutiveFailures: h.consecutiveFailures}
+
+	since := h.started
+	if !h.lastSuccess.IsZero() {
+		last := h.lastSuccess
+		ready.LastUpstreamSuccess = &last
+		since = last
+	}
+
+	if h.now().Sub(since) > h.window {
+		ready.Status = "degraded"
+	}
+	return ready
+}
+
+// upstream is rebuilt by setupRoutes with UPSTREAM_HEALTH_WINDOW, the longest the upstream may go without a success
+var upstream = newUpstreamHealth(config.UpstreamHealthWindow, time.Now)
+
+// ReadinessCheck reports the state of the upstream OpenSSF API and of the circuit breaker
+func ReadinessCheck(c *fiber.Ctx) error {
+	ready := upstream.snapshot()
+	ready.Breaker = breaker.state()
+	return c.JSON(ready)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// fakeClock is a clock the tests move by hand
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func TestReadinessDegradesWithoutAnUpstreamSuccess(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	h := newUpstreamHealth(15*time.Minute, clock.now)
+
+	// until the first success the window runs from startup
+	clock.advance(14 * time.Minute)
+	if got := h.snapshot(); got.Status != "ok" || got.LastUpstreamSuccess != nil {
+		t.Fatalf("within the window after startup: %+v", got)
+	}
+	clock.advance(2 * time.Minute)
+	if got := h.snapshot(); got.Status != "degraded" {
+		t.Fatalf("past the window without a success: %+v", got)
+	}
+
+	h.record(fiber.StatusOK, nil)
+	success := clock.t
+	got := h.snapshot()
+	if got.Status != "ok" || got.LastUpstreamSuccess == nil || !got.LastUpstreamSuccess.Equal(success) {
+		t.Fatalf("after a success: %+v", got)
+	}
+
+	// failures are counted, but only the time since the last success degrades readiness
+	clock.advance(10 * time.Minute)
+	h.record(fiber.StatusServiceUnavailable, nil)
+	h.record(0, errors.New("connection refused"))
+	if got := h.snapshot(); got.Status != "ok" || got.ConsecutiveFailures != 2 {
+		t.Fatalf("failing within the window: %+v", got)
+	}
+	clock.advance(5*time.Minute + time.Second)
+	got = h.snapshot()
+	if got.Status != "degraded" || got.ConsecutiveFailures != 2 || !got.LastUpstreamSuccess.Equal(success) {
+		t.Fatalf("failing past the window: %+v", got)
+	}
+
+	// a 404 is an answer, so it counts as a success
+	h.record(fiber.StatusNotFound, nil)
+	if got := h.snapshot(); got.Status != "ok" || got.ConsecutiveFailures != 0 {
+		t.Fatalf("after recovering: %+v", got)
+	}
+}
+
+func TestReadinessEndpointReportsTheUpstream(t *testing.T) {
+	api := newFakeAPI(t)
+	api.serve("github.com/a/b", resultJSON("github.com/a/b", sha(1), 5, nil))
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL})
+
+	get(t, app, "/msapi/scorecard/github.com/a/b")
+	status, body := get(t, app, "/ready")
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d", status)
+	}
+	var ready readiness
+	mustJSON(t, body, &ready)
+	if ready.Status != "ok" || ready.LastUpstreamSuccess == nil || ready.Breaker == "" {
+		t.Errorf("readiness %+v", ready)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"expvar"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"go.uber.org/zap"
+)
+
+// hedgeMetrics counts the OpenSSF API calls hedged to SCORECARD_API_MIRROR_URL and how many
+// of them the mirror answered first
+var hedgeMetrics = expvar.NewMap("scorecard_hedge")
+
+// hedgeAnswer is the outcome of one leg of a hedged call
+type hedgeAnswer struct {
+	resp   *resty.Response
+	err    error
+	mirror bool
+}
+
+// answered reports whether the leg produced an answer worth returning: a response that is
+// not a server error
+func (a hedgeAnswer) answered() bool {
+	return a.err == nil && a.resp.StatusCode() < 500
+}
+
+// failoverMetrics counts the OpenSSF API calls failed over to the next entry of SCORECARD_API_URLS
+var failoverMetrics = expvar.NewMap("scorecard_failover")
+
+// apiGet calls the OpenSSF API for path, a repo and its query, trying the entries of
+// SCORECARD_API_URLS in order: a server error or a transport failure, timeouts included,
+// moves on to the next one. The first answer is returned, or the last entry's failure.
+func apiGet(path string) (*resty.Response, error) {
+	var (
+		resp *resty.Response
+		err  error
+	)
+	for i, base := range config.ScorecardAPIURLs {
+		resp, err = hedgedGet(base+"/"+path, path)
+		if (hedgeAnswer{resp: resp, err: err}).answered() || i == len(config.ScorecardAPIURLs)-1 {
+			break
+		}
+		failoverMetrics.Add("failed_over", 1)
+		logger.Warn("OpenSSF API call failed, trying the next SCORECARD_API_URLS entry",
+			zap.String("url", base), zap.Int("status", statusOf(resp)), zap.Error(err))
+	}
+	return resp, err
+}
+
+// statusOf is the status code of resp, or zero when the call failed without one
+func statusOf(resp *resty.Response) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.StatusCode()
+}
+
+// hedgedGet calls url, the API for path. With SCORECARD_API_MIRROR_URL set, the mirror is
+// asked too when url has not answered within HEDGE_DELAY_MS, and the first answer wins; the
+// other call is cancelled. When neither answers, url's failure is returned, or the mirror's
+// if url's never came.
+func hedgedGet(url, path string) (*resty.Response, error) {
+	if config.ScorecardMirrorURL == "" {
+		return client.R().Get(url)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	answers := make(chan hedgeAnswer, 2)
+	send := func(url string, mirror bool) {
+		resp, err := client.R().SetContext(ctx).Get(url)
+		answers <- hedgeAnswer{resp: resp, err: err, mirror: mirror}
+	}
+	go send(url, false)
+
+	delay := time.NewTimer(config.HedgeDelay)
+	defer delay.Stop()
+
+	var failed *hedgeAnswer
+	pending := 1
+	for {
+		select {
+		case <-delay.C:
+			pending++
+			hedgeMetrics.Add("hedged", 1)
+			go send(config.ScorecardMirrorURL+"/"+path, true)
+		case answer := <-answers:
+			pending--
+			if answer.answered() {
+				if answer.mirror {
+					hedgeMetrics.Add("mirror_won", 1)
+				}
+				return answer.resp, answer.err
+			}
+			if failed == nil || !answer.mirror {
+				failed = &answer
+			}
+			if pending == 0 {
+				return failed.resp, failed.err
+			}
+		}
+	}
+}
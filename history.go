@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	ossf "github.com/ossf/scorecard/v5/pkg/scorecard"
+	"go.uber.org/zap"
+)
+
+// snapshot is one scorecard a lookup returned for a repo: the commit, the analysis date,
+// the aggregate and the score of every check by name
+type snapshot struct {
+	Commit string         `json:"commit"`
+	Date   string         `json:"date"`
+	Score  float64        `json:"score"`
+	Checks map[string]int `json:"checks"`
+}
+
+// historyResponse is the body returned by the history endpoint. Total counts the snapshots
+// of every page.
+type historyResponse struct {
+	Repo      string     `json:"repo"`
+	At        string     `json:"at,omitempty"`
+	Page      int        `json:"page"`
+	PerPage   int        `json:"per_page"`
+	Total     int        `json:"total"`
+	Snapshots []snapshot `json:"snapshots"`
+}
+
+// scoreHistory keeps the distinct scorecards returned per repo, ordered by analysis date.
+// It keeps at most perRepo snapshots of a repo, dropping the oldest, and at most repos
+// repos; once full, new repos are not recorded.
+type scoreHistory struct {
+	mu      sync.Mutex
+	perRepo int
+	repos   int
+	byRepo  map[string][]snapshot
+}
+
+func newScoreHistory(perRepo, repos int) *scoreHistory {
+	return &scoreHistory{perRepo: perRepo, repos: repos, byRepo: make(map[string][]snapshot)}
+}
+
+// add records s unless the repo already has a snapshot of the same commit and date
+func (h *scoreHistory) add(repo string, s snapshot) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	snapshots, ok := h.byRepo[repo]
+	if !ok && len(h.byRepo) >= h.repos {
+		return
+	}
+	for _, seen := range snapshots {
+		if seen.Commit == s.Commit && seen.Date == s.Date {
+			return
+		}
+	}
+
+	snapshots = append(snapshots, s)
+	sort.SliceStable(snapshots, func(i, j int) bool { return snapshots[i].Date < snapshots[j].Date })
+	if len(snapshots) > h.perRepo {
+		snapshots = snapshots[len(snapshots)-h.perRepo:]
+	}
+	h.byRepo[repo] = snapshots
+}
+
+// get is a copy of the snapshots of a repo, oldest first, or only the latest analysed no
+// later than until when it is set
+func (h *scoreHistory) get(repo, until string) []snapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	snapshots := h.byRepo[repo]
+	if until == "" {
+		return append([]snapshot{}, snapshots...)
+	}
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if snapshots[i].Date <= until {
+			return []snapshot{snapshots[i]}
+		}
+	}
+	return []snapshot{}
+}
+
+// history is rebuilt by setupRoutes with HISTORY_LIMIT and DISTINCT_REPOS_LIMIT
+var history = newScoreHistory(config.HistoryLimit, config.DistinctReposLimit)
+
+// newSnapshot is the snapshot of an OpenSSF result
+func newSnapshot(result *ossf.JSONScorecardResultV2) snapshot {
+	s := snapshot{
+		Commit: result.Repo.Commit,
+		Date:   result.Date,
+		Score:  float64(result.AggregateScore),
+		Checks: make(map[string]int, len(result.Checks)),
+	}
+	for _, check := range result.Checks {
+		s.Checks[check.Name] = check.Score
+	}
+	return s
+}
+
+// historyUntil is the latest analysis date a history request for at includes, comparable
+// with the snapshot dates: a day includes all of it, a time is taken in UTC
+func historyUntil(at string) (string, error) {
+	if at == "" {
+		return "", nil
+	}
+	if day, err := time.Parse(time.DateOnly, at); err == nil {
+		return day.Format(time.DateOnly) + "T23:59:59Z", nil
+	}
+	if t, err := time.Parse(time.RFC3339, at); err == nil {
+		return t.UTC().Format(time.RFC3339), nil
+	}
+	return "", fmt.Errorf("at must be a day or an RFC 3339 time, got %q", at)
+}
+
+// recordHistory keeps a snapshot of the scorecard a lookup returned. Lookups with a
+// caller's token are not recorded, their repo may be private.
+func recordHistory(req lookupRequest, result *ossf.JSONScorecardResultV2) {
+	if req.token != "" || result == nil {
+		return
+	}
+	history.add(req.repo, newSnapshot(result))
+}
+
+// getHistory godoc
+// @Summary Get the recorded scorecards of a repo
+// @Description Return the distinct scorecards this instance has returned for the repo since startup,
+// @Description oldest analysis date first, each with its commit, aggregate and check scores. At most
+// @Description HISTORY_LIMIT snapshots are kept per repo. Lookups made with a caller's token are not recorded.
+// @Description With PERSIST_SCORECARDS set the snapshots are read from ArangoDB instead, every one
+// @Description recorded since persistence was turned on, on any instance.
+// @Description With at, a day or an RFC 3339 time, only the snapshot in effect then is returned, the
+// @Description latest analysed no later than at, e.g. the score of the repo when a release shipped.
+// @Description Snapshots come per_page at a time, at most 500, and total counts them all.
+// @Tags scorecard
+// @Produce json
+// @Param at query string false "a day, e.g. 2024-05-01, or an RFC 3339 time"
+// @Param page query int false "page, from 1"
+// @Param per_page query int false "page size, 50 by default and at most 500"
+// @Success 200 {object} historyResponse
+// @Failure 400 {object} errorResponse "INVALID_REPO or an invalid at or page"
+// @Router /msapi/scorecard/:key/history [get]
+func getHistory(c *fiber.Ctx) error {
+	githubURL, err := lookupRepo(c)
+	if err != nil {
+		return sendLookupError(c, err)
+	}
+	until, err := historyUntil(c.Query("at"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	page, err := parsePage(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	response := historyResponse{Repo: githubURL, At: c.Query("at"), Page: page.page, PerPage: page.perPage}
+	if store != nil {
+		if response.Snapshots, response.Total, err = store.history(githubURL, until, page.offset(), page.perPage); err == nil {
+			return c.JSON(response)
+		}
+		logger.Warn("reading the stored history failed, answering from memory", zap.String("repo", githubURL), zap.Error(err))
+	}
+	snapshots := history.get(githubURL, until)
+	response.Snapshots, response.Total = pageOf(snapshots, page), len(snapshots)
+	return c.JSON(response)
+}
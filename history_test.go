@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// historyPage gets target and decodes the history
+func historyPage(t *testing.T, app *fiber.App, target string) historyResponse {
+	t.Helper()
+	status, body := get(t, app, target)
+	if status != fiber.StatusOK {
+		t.Fatalf("%s: status %d, body %s", target, status, body)
+	}
+	var resp historyResponse
+	mustJSON(t, body, &resp)
+	return resp
+}
+
+func TestHistoryPages(t *testing.T) {
+	app := newTestApp(t, nil)
+	s := useMemoryStore(t)
+	for i := 1; i <= 5; i++ {
+		result := parseResult(t, resultJSON("github.com/a/b", sha(i), float64(i), nil))
+		result.Date = fmt.Sprintf("2024-05-0%dT00:00:00Z", i)
+		s.save("github.com/a/b", sourceAPI, result)
+		history.add("github.com/a/b", newSnapshot(result))
+	}
+
+	for _, stored := range []bool{true, false} {
+		if !stored {
+			store = nil
+		}
+		resp := historyPage(t, app, "/msapi/scorecard/github.com/a/b/history?page=3&per_page=2")
+		if resp.Page != 3 || resp.PerPage != 2 || resp.Total != 5 || len(resp.Snapshots) != 1 || resp.Snapshots[0].Commit != sha(5) {
+			t.Errorf("stored %v: last page %+v, want the fifth snapshot of 5", stored, resp)
+		}
+		if resp := historyPage(t, app, "/msapi/scorecard/github.com/a/b/history?page=4&per_page=2"); len(resp.Snapshots) != 0 || resp.Total != 5 {
+			t.Errorf("stored %v: past the last page %+v", stored, resp)
+		}
+		resp = historyPage(t, app, "/msapi/scorecard/github.com/a/b/history")
+		if resp.Page != 1 || resp.PerPage != defaultPerPage || len(resp.Snapshots) != 5 {
+			t.Errorf("stored %v: default page %+v", stored, resp)
+		}
+	}
+
+	for _, query := range []string{"per_page=501", "per_page=0", "page=0", "page=two"} {
+		if status, _ := get(t, app, "/msapi/scorecard/github.com/a/b/history?"+query); status != fiber.StatusBadRequest {
+			t.Errorf("%s: status %d", query, status)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// hotKey is a repo and commit as lookups ask for them, the commit empty for the latest
+type hotKey struct {
+	repo   string
+	commit string
+}
+
+// hotRepos counts the cached lookups of every repo and commit so the most requested can be
+// kept in the cache. It counts at most limit of them. The counts are halved every round, so
+// the ranking follows the current traffic and the repos that dropped to nothing make room.
+type hotRepos struct {
+	mu     sync.Mutex
+	limit  int
+	counts map[hotKey]int
+}
+
+func newHotRepos(limit int) *hotRepos {
+	return &hotRepos{limit: limit, counts: make(map[hotKey]int)}
+}
+
+func (h *hotRepos) add(repo, commit string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := hotKey{repo: repo, commit: commit}
+	if _, ok := h.counts[key]; !ok && len(h.counts) >= h.limit {
+		return
+	}
+	h.counts[key]++
+}
+
+// top is the n most requested, most requested first, and starts the next round
+func (h *hotRepos) top(n int) []hotKey {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	keys := make([]hotKey, 0, len(h.counts))
+	for key := range h.counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if h.counts[keys[i]] != h.counts[keys[j]] {
+			return h.counts[keys[i]] > h.counts[keys[j]]
+		}
+		return keys[i].repo+"@"+keys[i].commit < keys[j].repo+"@"+keys[j].commit
+	})
+
+	for key, count := range h.counts {
+		if count /= 2; count == 0 {
+			delete(h.counts, key)
+		} else {
+			h.counts[key] = count
+		}
+	}
+	return keys[:min(n, len(keys))]
+}
+
+// hot is rebuilt by setupRoutes with HOT_REPOS_TRACKED
+var hot = newHotRepos(config.HotReposTracked)
+
+// startRefreshing refreshes the cached scorecards of the HOT_REPOS most requested repos in
+// the background every HOT_REPOS_INTERVAL, so a hot repo's next lookup does not wait on the
+// upstream when its scorecard expires. It does nothing without HOT_REPOS or a cache.
+func startRefreshing(cfg *Config) {
+	if cfg.HotRepos == 0 || cfg.CacheTTL == 0 {
+		return
+	}
+
+	go func() {
+		for now := range time.Tick(cfg.HotReposInterval) {
+			refreshHotRepos(cfg, now)
+		}
+	}()
+}
+
+// refreshHotRepos fetches again the scorecards of the most requested repos that expire before
+// the next round, or are no longer cached, HOT_REPOS_CONCURRENCY at a time. A cached miss is
+// left to expire. Nothing is fetched in read-only mode.
+func refreshHotRepos(cfg *Config, now time.Time) {
+	if readOnly.Load() {
+		return
+	}
+
+	var due []hotKey
+	for _, key := range hot.top(cfg.HotRepos) {
+		entry, ok := cache.Get(repoCachePrefix(key.repo) + key.commit)
+		if ok && (entry.Miss != "" || entry.Expires.Sub(now) > cfg.HotReposInterval) {
+			continue
+		}
+		due = append(due, key)
+	}
+
+	var refreshed atomic.Int64
+	runConcurrently(len(due), cfg.HotReposConcurrency, func(i int) {
+		req := lookupRequest{repo: due[i].repo, commit: due[i].commit, prefer: cfg.PreferSource, refresh: true}
+		if _, _, err := coalescedLookup(req); err != nil {
+			logger.Warn("refreshing a hot repo failed", zap.String("repo", req.repo), zap.String("commit", req.commit), zap.Error(err))
+			return
+		}
+		refreshed.Add(1)
+	})
+	if len(due) > 0 {
+		logger.Info("hot repos refreshed", zap.Int("due", len(due)), zap.Int64("refreshed", refreshed.Load()))
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHotRepoIsRefreshedBeforeItExpires(t *testing.T) {
+	api := newFakeAPI(t)
+	api.serve("github.com/a/b", resultJSON("github.com/a/b", sha(1), 5, nil))
+	app := newTestApp(t, map[string]string{
+		"SCORECARD_API_URLS":  api.URL,
+		"SCORECARD_CACHE_TTL": "1h",
+		"CACHE_TTL_JITTER":    "0",
+		"HOT_REPOS":           "1",
+		"HOT_REPOS_INTERVAL":  "1m",
+	})
+
+	if status, _ := get(t, app, "/msapi/scorecard/github.com/a/b"); status != http.StatusOK {
+		t.Fatalf("status %d", status)
+	}
+	api.serve("github.com/a/b", resultJSON("github.com/a/b", sha(2), 9, nil))
+
+	// far from expiring, the entry is left alone
+	refreshHotRepos(config, time.Now())
+	if n := api.called("github.com/a/b"); n != 1 {
+		t.Fatalf("refreshed %d times long before expiry", n-1)
+	}
+
+	// the entry expires before the next round, it is fetched again without any lookup
+	get(t, app, "/msapi/scorecard/github.com/a/b")
+	refreshHotRepos(config, time.Now().Add(59*time.Minute+30*time.Second))
+	if n := api.called("github.com/a/b"); n != 2 {
+		t.Fatalf("API called %d times, want the lookup and the refresh", n)
+	}
+	entry, ok := cache.Get(repoCachePrefix("github.com/a/b"))
+	if !ok || entry.Result.AggregateScore != 9 {
+		t.Fatalf("cached %+v, want the refreshed scorecard", entry)
+	}
+	if entry.Expires.Before(time.Now().Add(59 * time.Minute)) {
+		t.Errorf("refreshed entry expires %v, want a new TTL", entry.Expires)
+	}
+}
+
+func TestHotReposRankAndBound(t *testing.T) {
+	h := newHotRepos(2)
+	for i := 0; i < 3; i++ {
+		h.add("github.com/a/hot", "")
+	}
+	h.add("github.com/a/cold", "")
+	h.add("github.com/a/untracked", "") // past the limit
+
+	top := h.top(5)
+	if len(top) != 2 || top[0].repo != "github.com/a/hot" || top[1].repo != "github.com/a/cold" {
+		t.Fatalf("top %+v", top)
+	}
+
+	// the cold repo decayed to nothing and made room
+	h.add("github.com/a/new", "")
+	h.add("github.com/a/new", "")
+	h.add("github.com/a/new", "")
+	top = h.top(1)
+	if len(top) != 1 || top[0].repo != "github.com/a/new" {
+		t.Fatalf("top %+v, want the repo requested most this round", top)
+	}
+}
+
+func TestHotRepoRefreshesAreBounded(t *testing.T) {
+	var running, most atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := most.Load()
+			if n <= m || most.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		repo := strings.TrimPrefix(r.URL.Path, "/")
+		_, _ = w.Write([]byte(resultJSON(repo, sha(1), 5, nil)))
+	}))
+	defer api.Close()
+	newTestApp(t, map[string]string{
+		"SCORECARD_API_URLS":    api.URL,
+		"HOT_REPOS":             "6",
+		"HOT_REPOS_CONCURRENCY": "2",
+	})
+
+	for _, repo := range []string{"a", "b", "c", "d", "e", "f"} {
+		hot.add("github.com/o/"+repo, "")
+	}
+	refreshHotRepos(config, time.Now())
+
+	if got := most.Load(); got != 2 {
+		t.Errorf("%d refreshes ran at once, want HOT_REPOS_CONCURRENCY", got)
+	}
+	for _, repo := range []string{"a", "b", "c", "d", "e", "f"} {
+		if _, ok := cache.Get(repoCachePrefix("github.com/o/" + repo)); !ok {
+			t.Errorf("github.com/o/%s was not refreshed", repo)
+		}
+	}
+}
@@ -8,12 +8,19 @@ package main
 
 import (
 	"github.com/ortelius/scec-commons/model"
-	_ "github.com/ortelius/scec-scorecard/docs"
+	"github.com/ortelius/scec-scorecard/docs"
 
+	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
 	"os"
-	"os/exec"
+	"reflect"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -24,7 +31,42 @@ import (
 	ossf "github.com/ossf/scorecard/v5/pkg/scorecard"
 )
 
-const scorecardAPIBaseURL = "https://api.securityscorecards.dev/projects/"
+// defaultScorecardAPIURL is the OpenSSF scorecard API, the only entry of SCORECARD_API_URLS by default
+const defaultScorecardAPIURL = "https://api.securityscorecards.dev/projects"
+
+var (
+	errScorecardProcessing = errors.New("scorecard is still being computed")
+	errNoScorecard         = errors.New("no scorecard is available for this repository")
+	errInvalidRepo         = errors.New("repo must be of the form host/owner/repo, e.g. github.com/ortelius/scec-scorecard")
+	errUpstream            = errors.New("an upstream API failed")
+	errUpstreamTimeout     = errors.New("an upstream API timed out")
+)
+
+// processingResponse is returned with 202 Accepted while the upstream is still scoring a repo
+type processingResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// stage orders accepted by ?prefer= and PREFER_SOURCE
+const (
+	preferAPI = "api"
+	preferCLI = "cli"
+)
+
+// progress stages reported by lookupScorecard
+const (
+	stageAPIMiss    = "api-miss"
+	stageCLIStarted = "cli-started"
+)
+
+// sources a scorecard can be served from
+const (
+	sourceAPI       = "api"
+	sourceAPILatest = "api-latest"
+	sourceCLI       = "cli"
+	sourceNone      = "none"
+)
 
 // InitLogger sets up the Zap Logger to log to the console in a human readable format
 func InitLogger() *zap.Logger {
@@ -37,220 +79,529 @@ func InitLogger() *zap.Logger {
 }
 
 var logger = InitLogger()
-var client = resty.New()
+
+// client is rebuilt by setupRoutes with the OUTBOUND_ timeouts and retries
+var client = newClient(config)
 
 // getScorecard godoc
 // @Summary Get the OSSF scorecard for a repo
-// @Description Get a scorecard for a repo and commit sha
+// @Description Get a scorecard for a repo and commit sha. A key on a host that is not a known forge,
+// @Description e.g. go.uber.org/zap, is first resolved as a Go module path, from its go-import meta tag on
+// @Description the GO_IMPORT_HOSTS and else from GO_PROXY_URL, and the resolved repo is returned in the
+// @Description X-Resolved-Repo header. Scorecards are cached for SCORECARD_CACHE_TTL unless fetched with a
+// @Description caller's token, and a repo without one is remembered for SCORECARD_NEGATIVE_CACHE_TTL. Past
+// @Description its TTL a cached scorecard is still returned for SCORECARD_STALE_TTL, flagged with
+// @Description X-Scorecard-Stale: true, while it is fetched again, and for SCORECARD_FALLBACK_TTL more,
+// @Description flagged the same, when the OpenSSF API fails or BREAKER_FAILURES failures in a row have
+// @Description paused calls to it for BREAKER_COOLDOWN.
+// @Description The ETag and Last-Modified change only when the repo is scored again; send them back in
+// @Description If-None-Match or If-Modified-Since to get a 304. Cache-Control lets shared caches keep a
+// @Description scorecard of the requested commit, immutable, for SCORECARD_PINNED_MAX_AGE and any other for
+// @Description SCORECARD_MAX_AGE; one fetched with a caller's token is private.
 // @Tags scorecard
 // @Accept */*
 // @Produce json
-// @Success 200
+// @Produce application/x-protobuf
+// @Param commit query string false "commit sha"
+// @Param ref query string false "branch or tag to score, resolved to its commit through the forge; REF_COMMIT_CONFLICT decides when it disagrees with commit"
+// @Param latest query bool false "ignore commit and ref and return the latest, unpinned scorecard"
+// @Param format query string false "response format overriding the Accept header" Enums(json, protobuf)
+// @Param verbose query bool false "include per-check scores, reasons and documentation links"
+// @Param details query bool false "list the checks as verbose does, each with its details"
+// @Param aggregate_present query bool false "add aggregate_present, the equal-weighted mean of the checks that ran"
+// @Param fields query string false "comma separated fields to return, in order, in any case, e.g. score,pinned,branchprotection"
+// @Param provenance query bool false "add meta with the scored repo, commit, analysis date and scorecard version"
+// @Param include_grade query bool false "add grade, the aggregate as a letter on the GRADE_THRESHOLDS scale or N/A without an aggregate"
+// @Param risk query string false "list only the checks in these comma separated risk tiers, e.g. Critical,High"
+// @Param include_unmapped query bool false "add unmapped_checks, the scores of checks with no scorecard field; defaults to INCLUDE_UNMAPPED_CHECKS"
+// @Param prefer query string false "which source to try first; defaults to PREFER_SOURCE or api" Enums(api, cli)
+// @Param refresh query bool false "skip the cache and the lookups shared within COALESCE_WINDOW_MS and fetch the scorecard again"
+// @Param X-Repo-Token header string false "forge token used instead of the configured one to scan a private repo; Authorization: Bearer also works"
+// @Success 200 {object} scorecardResponse
+// @Success 202 {object} processingResponse "scorecard still being computed"
+// @Success 304 "the scorecard If-None-Match or If-Modified-Since describes is still current"
+// @Failure 400 {object} errorResponse "INVALID_REPO, REF_NOT_RESOLVABLE or REF_COMMIT_CONFLICT"
+// @Failure 401 {object} errorResponse "REQUEST_TOKEN_INVALID"
+// @Failure 404 {object} errorResponse "REPO_NOT_FOUND, SCORECARD_NOT_COMPUTED, NO_SCORECARD, MODULE_NOT_FOUND or REF_NOT_FOUND"
+// @Failure 406
+// @Failure 502 {object} errorResponse "UPSTREAM_ERROR or TOKEN_INVALID"
+// @Failure 503 {object} errorResponse "READ_ONLY, no cached scorecard, or SCAN_BUSY, the scan queue is full"
+// @Failure 504 {object} errorResponse "UPSTREAM_TIMEOUT"
 // @Router /msapi/scorecard/:key [get]
 func getScorecard(c *fiber.Ctx) error {
-	var scorecard model.Scorecard
+	prefer, err := preference(c)
+	if err != nil {
+		return err
+	}
+
+	githubURL, commitSha, err := lookupKey(c)
+	if err != nil {
+		return sendLookupError(c, err)
+	}
+	return serveScorecard(c, githubURL, commitSha, prefer)
+}
+
+// lookupKey is the repo named by the :key path, validated, and the commit to score from
+// ?commit=, ?ref= and ?latest=
+func lookupKey(c *fiber.Ctx) (string, string, error) {
+	githubURL, err := lookupRepo(c)
+	if err != nil {
+		return "", "", err
+	}
+
+	commitSha, err := requestedRevision(c, githubURL)
+	if err != nil {
+		return "", "", err
+	}
+	return githubURL, commitSha, nil
+}
+
+// lookupRepo is the repo named by the :key path, validated. A key resolved as a Go module
+// path is reported with X-Resolved-Repo.
+func lookupRepo(c *fiber.Ctx) (string, error) {
+	githubURL, module, err := repoForKey(c.Params("*"))
+	if err != nil {
+		return "", err
+	}
+	if module {
+		c.Set("X-Resolved-Repo", githubURL)
+	}
+	return githubURL, nil
+}
+
+// repoForKey is the repo a key names, validated, and whether the key was resolved as a Go
+// module path. A key off the known forges may be a Go module path such as go.uber.org/zap.
+// When it resolves it is scored as its repo; when it does not it is looked up as given.
+func repoForKey(key string) (string, bool, error) {
+	githubURL := cleanRepoURL(key)
+	module := false
+
+	if !isKnownForgeRepo(githubURL) {
+		repo, err := resolveGoModule(githubURL)
+		switch {
+		case err == nil && validateRepoURL(repo) == nil:
+			githubURL, module = repo, true
+		case validateRepoURL(githubURL) != nil && err != nil:
+			return "", false, err
+		}
+	}
+	if err := validateRepoURL(githubURL); err != nil {
+		return "", false, err
+	}
+	return githubURL, module, nil
+}
 
-	repoURL := c.Params("*")
-	commitSha := c.Query("commit")
+// serveScorecard looks up a validated repo and writes the scorecard, or the error, shaped by
+// the request's response options with the Cache-Control of setCacheControl. A client whose
+// If-None-Match names the scorecard gets a 304.
+func serveScorecard(c *fiber.Ctx, githubURL, commitSha, prefer string) error {
+	opts, err := parseResponseOptions(c)
+	if err != nil {
+		return err
+	}
+	format, err := parseFormat(c)
+	if err != nil {
+		return err
+	}
+
+	result, source, err := requestLookup(c, githubURL, commitSha, prefer)
+	if err != nil {
+		return sendLookupError(c, err)
+	}
+
+	recordScored(githubURL)
+	c.Vary(fiber.HeaderAccept)
+	setCacheControl(c, result, commitSha)
+	if notModified(c, result, format.mime) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	return sendScorecard(c, format, newResponse(result, commitSha, source, opts))
+}
+
+// requestLookup runs the coalesced lookup for a request, with its token and ?refresh=, and
+// logs it when slow. A stale cached result is flagged with X-Scorecard-Stale.
+func requestLookup(c *fiber.Ctx, githubURL, commitSha, prefer string) (*ossf.JSONScorecardResultV2, string, error) {
+	start := time.Now()
+	req := lookupRequest{repo: githubURL, commit: commitSha, prefer: prefer, token: requestToken(c), refresh: c.QueryBool("refresh")}
+	req.onStale = func() { c.Set("X-Scorecard-Stale", "true") }
+	result, source, err := coalescedLookup(req)
+	logSlowRequest(githubURL, source, time.Since(start))
+	return result, source, err
+}
 
-	if repoURL == "" {
-		return c.JSON(scorecard)
+// sendLookupError writes the structured error body for a failed lookup, or the processing
+// body when the upstream has not finished scoring the repo
+func sendLookupError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, errScorecardProcessing) {
+		return c.Status(fiber.StatusAccepted).JSON(processingResponse{
+			Status:  "processing",
+			Message: "the scorecard for this repo is still being computed, retry later",
+		})
 	}
+	status, code := lookupErrorStatus(err)
+	return c.Status(status).JSON(errorResponse{Code: code, Message: err.Error()})
+}
+
+// validateRepoURL checks that a normalized repo url names a host, an owner and a repo
+func validateRepoURL(repoURL string) error {
+	parts := strings.Split(repoURL, "/")
+	if len(parts) < 3 || !strings.Contains(parts[0], ".") {
+		return errInvalidRepo
+	}
+	for _, part := range parts {
+		if part == "" {
+			return errInvalidRepo
+		}
+	}
+	return nil
+}
 
-	githubURL := cleanRepoURL(repoURL)
+// lookupErrorStatus maps a lookup error to its HTTP status and error code
+func lookupErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, errScorecardProcessing):
+		return fiber.StatusAccepted, "PROCESSING"
+	case errors.Is(err, errReadOnly):
+		return fiber.StatusServiceUnavailable, "READ_ONLY"
+	case errors.Is(err, errRepoNotFound):
+		return fiber.StatusNotFound, "REPO_NOT_FOUND"
+	case errors.Is(err, errScorecardNotComputed):
+		return fiber.StatusNotFound, "SCORECARD_NOT_COMPUTED"
+	case errors.Is(err, errTokenInvalid):
+		return fiber.StatusBadGateway, "TOKEN_INVALID"
+	case errors.Is(err, errRequestTokenInvalid):
+		return fiber.StatusUnauthorized, "REQUEST_TOKEN_INVALID"
+	case errors.Is(err, errNoScorecard):
+		return fiber.StatusNotFound, "NO_SCORECARD"
+	case errors.Is(err, errInvalidRepo):
+		return fiber.StatusBadRequest, "INVALID_REPO"
+	case errors.Is(err, errRefNotFound):
+		return fiber.StatusNotFound, "REF_NOT_FOUND"
+	case errors.Is(err, errRefNotResolvable):
+		return fiber.StatusBadRequest, "REF_NOT_RESOLVABLE"
+	case errors.Is(err, errRefCommitClash):
+		return fiber.StatusBadRequest, "REF_COMMIT_CONFLICT"
+	case errors.Is(err, errCommitNotScored):
+		return fiber.StatusNotFound, "COMMIT_NOT_SCORED"
+	case errors.Is(err, errInvalidPurl):
+		return fiber.StatusBadRequest, "INVALID_PURL"
+	case errors.Is(err, errInvalidPackage):
+		return fiber.StatusBadRequest, "INVALID_PACKAGE"
+	case errors.Is(err, errPackageNotFound):
+		return fiber.StatusNotFound, "PACKAGE_NOT_FOUND"
+	case errors.Is(err, errGoModuleNotFound):
+		return fiber.StatusNotFound, "MODULE_NOT_FOUND"
+	case errors.Is(err, errOrgNotFound):
+		return fiber.StatusNotFound, "ORG_NOT_FOUND"
+	case errors.Is(err, errOrgNotListable):
+		return fiber.StatusBadRequest, "ORG_NOT_LISTABLE"
+	case errors.Is(err, errNoSourceRepo), errors.Is(err, errNoComponentRepo):
+		return fiber.StatusNotFound, "NO_SOURCE_REPO"
+	case errors.Is(err, errUpstreamTimeout):
+		return fiber.StatusGatewayTimeout, "UPSTREAM_TIMEOUT"
+	case errors.Is(err, errScanBusy):
+		return fiber.StatusServiceUnavailable, "SCAN_BUSY"
+	case errors.Is(err, errStoreDisabled):
+		return fiber.StatusNotImplemented, "STORE_DISABLED"
+	case errors.Is(err, errStoreUnavailable):
+		return fiber.StatusServiceUnavailable, "STORE_UNAVAILABLE"
+	case errors.Is(err, errMiddlewareUnavailable):
+		return fiber.StatusServiceUnavailable, "MIDDLEWARE_UNAVAILABLE"
+	default:
+		return fiber.StatusBadGateway, "UPSTREAM_ERROR"
+	}
+}
+
+// lookupRequest describes a single scorecard lookup. progress, when set, is told about
+// each stage as it happens, and onStale when a stale cached result is returned. token is the
+// caller's forge token from requestToken. refresh skips the results cached and shared by
+// coalescedLookup.
+type lookupRequest struct {
+	repo     string
+	commit   string
+	prefer   string
+	token    string
+	refresh  bool
+	progress func(stage string)
+	onStale  func()
+}
+
+func (req lookupRequest) emit(stage string) {
+	if req.progress != nil {
+		req.progress(stage)
+	}
+}
+
+func (req lookupRequest) stale() {
+	if req.onStale != nil {
+		req.onStale()
+	}
+}
+
+// lookupScorecard tries the API for the commit, then the API for the latest result and
+// finally an in-process scan (the "cli" stage); with prefer set to "cli" the scan is tried
+// first and the API only on scan failure. Repos on a GitHub Enterprise GH_HOST are only
+// scanned. The second return value names the source that produced the result.
+// A result is only nil with an error: errNoScorecard when no source had a scorecard,
+// errUpstream or errUpstreamTimeout when the API failed and nothing else could score the
+// repo, and errScorecardProcessing when the upstream has not finished scoring it.
+func lookupScorecard(req lookupRequest) (*ossf.JSONScorecardResultV2, string, error) {
+	githubURL, commitSha := req.repo, req.commit
+
+	if readOnly.Load() {
+		return nil, sourceNone, errReadOnly
+	}
+
+	token := config.GitHubToken
+	if req.token != "" {
+		token = req.token
+	}
+	isGitHub := onConfiguredGitHub(githubURL)
+	cliEligible := scanEligible(githubURL, commitSha, token)
+
+	if req.prefer == preferCLI && cliEligible {
+		req.emit(stageCLIStarted)
+		if result, err := scanScoreCard(githubURL, commitSha, req.token); result != nil && err == nil {
+			return result, sourceCLI, nil
+		}
+		cliEligible = false // already tried, fall through to the API
+	}
 
-	fullURL := scorecardAPIBaseURL + githubURL
+	// The API never scores a GitHub Enterprise instance, so the scan is the only source
+	if isGitHubEnterprise(githubURL) {
+		if !cliEligible {
+			return nil, sourceNone, errNoScorecard
+		}
+		req.emit(stageCLIStarted)
+		result, err := scanScoreCard(githubURL, commitSha, req.token)
+		if result == nil && err == nil {
+			err = errNoScorecard
+		}
+		return result, sourceCLI, err
+	}
+
+	api := fetchFromAPI(githubURL, commitSha)
+	if api.done {
+		return api.result, api.source, api.err
+	}
+	req.emit(stageAPIMiss)
+
+	// A 404 is either a missing repo or one OpenSSF has not scored; the GitHub API tells them apart
+	if api.notFound && token != "" && isGitHub {
+		exists, err := githubRepoExists(githubURL, token)
+		if errors.Is(err, errTokenInvalid) && req.token != "" {
+			return nil, sourceNone, errRequestTokenInvalid
+		}
+		if errors.Is(err, errTokenInvalid) {
+			logger.Warn("GITHUB_TOKEN is invalid or expired, cannot tell a missing repo from an unscored one", zap.String("repo", githubURL))
+			return nil, sourceNone, err
+		}
+		if err == nil && !exists {
+			return nil, sourceNone, errRepoNotFound
+		}
+	}
+
+	// If failed and the forge can be scanned, fallback to scanning the commit
+	if cliEligible {
+		req.emit(stageCLIStarted)
+		result, err := scanScoreCard(githubURL, commitSha, req.token)
+		if result != nil || err != nil {
+			return result, sourceCLI, err
+		}
+	}
+
+	if api.notFound && token != "" && isGitHub {
+		return nil, sourceNone, errScorecardNotComputed
+	}
+	if api.err != nil {
+		return nil, sourceNone, api.err
+	}
+	return nil, sourceNone, errNoScorecard
+}
+
+// apiLookup is the outcome of querying the OpenSSF API. done means the lookup should
+// stop with result, source and err; otherwise later stages may still be tried, and err
+// holds the API failure to report if they do not produce a result either.
+type apiLookup struct {
+	result   *ossf.JSONScorecardResultV2
+	source   string
+	err      error
+	done     bool
+	notFound bool
+}
+
+// fetchFromAPI asks the API for the commit and, failing that, for the latest result
+func fetchFromAPI(githubURL, commitSha string) apiLookup {
+	path := githubURL
 	if commitSha != "" {
-		fullURL += "?commit=" + commitSha
+		path += "?commit=" + commitSha
 	}
 
-	resp, err := client.R().Get(fullURL)
+	if !breaker.allow() {
+		return apiLookup{source: sourceNone, err: errCircuitOpen, done: true}
+	}
+	release := outbound.acquire()
+	resp, err := apiGet(path)
+	release()
+	upstream.record(resp.StatusCode(), err)
+	breaker.record(resp.StatusCode(), err)
 	if err != nil {
-		return c.JSON(scorecard) // handle error
+		return apiLookup{source: sourceNone, err: upstreamError(err), done: true}
 	}
 
 	if resp.StatusCode() == fiber.StatusOK {
-		return c.JSON(parseScoreCard(resp, commitSha))
+		result, err := parseScoreCard(resp)
+		return apiLookup{result: result, source: sourceAPI, err: err, done: true}
 	}
+	notFound := resp.StatusCode() == fiber.StatusNotFound
+	apiErr := upstreamStatusError(resp)
 
 	// Retry without commitSha if the first attempt fails
 	if commitSha != "" {
-		fullURL = scorecardAPIBaseURL + githubURL
-		resp, err = client.R().Get(fullURL)
+		release := outbound.acquire()
+		resp, err = apiGet(githubURL)
+		release()
+		upstream.record(resp.StatusCode(), err)
+		breaker.record(resp.StatusCode(), err)
 		if err != nil {
-			return c.JSON(scorecard)
+			return apiLookup{source: sourceNone, err: upstreamError(err), done: true}
 		}
 
 		if resp.StatusCode() == fiber.StatusOK {
-			return c.JSON(parseScoreCard(resp, commitSha))
+			result, err := parseScoreCard(resp)
+			return apiLookup{result: result, source: sourceAPILatest, err: err, done: true}
 		}
+		notFound = resp.StatusCode() == fiber.StatusNotFound
+		apiErr = upstreamStatusError(resp)
 	}
 
-	// If failed and GITHUB_TOKEN is available, fallback to CLI
-	if token := os.Getenv("GITHUB_TOKEN"); token != "" && strings.Contains(githubURL, "github.com") && commitSha != "" {
-		return c.JSON(fetchScoreCardWithCLI(githubURL, commitSha))
-	}
-
-	return c.JSON(scorecard)
+	return apiLookup{source: sourceNone, err: apiErr, notFound: notFound}
 }
 
-func cleanRepoURL(repoURL string) string {
-	replacements := []struct {
-		old string
-		new string
-	}{
-		{"git+ssh://git@", ""},
-		{"git+https://", ""},
-		{"http://", ""},
-		{"https://", ""},
-		{"git:", ""},
-		{"git+", ""},
-		{".git", ""},
+// upstreamError classifies a failed call to the API as a timeout or another upstream failure
+func upstreamError(err error) error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Errorf("%w: %v", errUpstreamTimeout, err)
 	}
+	return fmt.Errorf("%w: %v", errUpstream, err)
+}
 
-	for _, repl := range replacements {
-		repoURL = strings.ReplaceAll(repoURL, repl.old, repl.new)
+// upstreamStatusError is the error for an API response that is neither a result nor a 404
+func upstreamStatusError(resp *resty.Response) error {
+	if resp.StatusCode() == fiber.StatusNotFound {
+		return nil
 	}
-	return repoURL
+	return fmt.Errorf("%w: the OpenSSF scorecard API returned %s", errUpstream, resp.Status())
 }
 
-func parseScoreCard(resp *resty.Response, commitSha string) *model.Scorecard {
-	var scorecard model.Scorecard
+// preference picks the stage order from ?prefer=, falling back to the PREFER_SOURCE default
+func preference(c *fiber.Ctx) (string, error) {
+	prefer := c.Query("prefer", config.PreferSource)
+	if prefer != preferAPI && prefer != preferCLI {
+		return "", fiber.NewError(fiber.StatusBadRequest, "prefer must be api or cli")
+	}
+	return prefer, nil
+}
 
-	var result ossf.JSONScorecardResultV2
-	if err := json.Unmarshal(resp.Body(), &result); err != nil {
-		return &scorecard
+// logSlowRequest warns when a lookup took longer than SLOW_REQUEST_THRESHOLD_MS
+func logSlowRequest(repo, source string, duration time.Duration) {
+	if config.SlowRequestThreshold <= 0 || duration < config.SlowRequestThreshold {
+		return
 	}
+	logger.Warn("slow scorecard lookup", zap.String("repo", repo), zap.Duration("duration", duration), zap.String("source", source))
+}
 
-	if result.Repo.Commit == commitSha {
-		scorecard.Pinned = true
-		scorecard.CommitSha = commitSha
+// mapScorecard godoc
+// @Summary Map a raw OSSF scorecard
+// @Description Collapse a raw OpenSSF JSONScorecardResultV2 document into a scorecard without any network access
+// @Tags scorecard
+// @Accept json
+// @Produce json
+// @Produce application/x-protobuf
+// @Param commit query string false "commit sha used to decide if the result is pinned"
+// @Param format query string false "response format overriding the Accept header" Enums(json, protobuf)
+// @Param verbose query bool false "include per-check scores, reasons and documentation links"
+// @Param details query bool false "list the checks as verbose does, each with its details"
+// @Param aggregate_present query bool false "add aggregate_present, the equal-weighted mean of the checks that ran"
+// @Param fields query string false "comma separated fields to return, in order, in any case, e.g. score,pinned,branchprotection"
+// @Param provenance query bool false "add meta with the scored repo, commit, analysis date and scorecard version"
+// @Param include_grade query bool false "add grade, the aggregate as a letter on the GRADE_THRESHOLDS scale or N/A without an aggregate"
+// @Param risk query string false "list only the checks in these comma separated risk tiers, e.g. Critical,High"
+// @Param include_unmapped query bool false "add unmapped_checks, the scores of checks with no scorecard field; defaults to INCLUDE_UNMAPPED_CHECKS"
+// @Success 200 {object} scorecardResponse
+// @Failure 400
+// @Failure 406
+// @Router /msapi/scorecard/map [post]
+func mapScorecard(c *fiber.Ctx) error {
+	opts, err := parseResponseOptions(c)
+	if err != nil {
+		return err
 	}
 
-	scorecard.Score = float32(result.AggregateScore)
+	format, err := parseFormat(c)
+	if err != nil {
+		return err
+	}
 
-	for _, check := range result.Checks {
-		name := check.Name
-		score := float32(check.Score)
-
-		switch name {
-		case "Maintained":
-			scorecard.Maintained = score
-		case "Code-Review":
-			scorecard.CodeReview = score
-		case "CII-Best-Practices":
-			scorecard.CIIBestPractices = score
-		case "License":
-			scorecard.License = score
-		case "Signed-Releases":
-			scorecard.SignedReleases = score
-		case "Dangerous-Workflow":
-			scorecard.DangerousWorkflow = score
-		case "Packaging":
-			scorecard.Packaging = score
-		case "Token-Permissions":
-			scorecard.TokenPermissions = score
-		case "Branch-Protection":
-			scorecard.BranchProtection = score
-		case "Binary-Artifacts":
-			scorecard.BinaryArtifacts = score
-		case "Pinned-Dependencies":
-			scorecard.PinnedDependencies = score
-		case "Security-Policy":
-			scorecard.SecurityPolicy = score
-		case "Fuzzing":
-			scorecard.Fuzzing = score
-		case "SAST":
-			scorecard.SAST = score
-		case "Vulnerabilities":
-			scorecard.Vulnerabilities = score
-		case "CI-Tests":
-			scorecard.CITests = score
-		case "Contributors":
-			scorecard.Contributors = score
-		case "Dependency-Update-Tool":
-			scorecard.DependencyUpdateTool = score
-		case "SBOM":
-			scorecard.SBOM = score
-		case "Webhooks":
-			scorecard.Webhooks = score
-		}
+	var result ossf.JSONScorecardResultV2
+	if err := decodeResult(c.Body(), &result); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
-	return &scorecard
+
+	return sendScorecard(c, format, newResponse(&result, c.Query("commit"), sourceNone, opts))
 }
 
-func fetchScoreCardWithCLI(repoURL, commitSha string) *model.Scorecard {
-	var scorecard model.Scorecard
-	var out strings.Builder
+func parseScoreCard(resp *resty.Response) (*ossf.JSONScorecardResultV2, error) {
+	var result ossf.JSONScorecardResultV2
+	if err := decodeResult(resp.Body(), &result); err != nil {
+		return nil, fmt.Errorf("%w: unreadable result: %v", errUpstream, err)
+	}
 
-	cmd := exec.Command("scorecard", "--repo="+repoURL, "--commit="+commitSha, "--format", "json") // #nosec G204
-	cmd.Stdout = &out
+	if isProcessing(&result) {
+		return nil, errScorecardProcessing
+	}
+	return &result, nil
+}
 
-	err := cmd.Run()
-	if err != nil {
-		return &scorecard
+// decodeResult unmarshals an OpenSSF result. With STRICT_DECODE=true fields that are
+// not part of JSONScorecardResultV2 are logged and rejected to catch upstream schema drift.
+func decodeResult(data []byte, result *ossf.JSONScorecardResultV2) error {
+	if !config.StrictDecode {
+		return json.Unmarshal(data, result)
 	}
 
-	var result ossf.JSONScorecardResultV2
-	if err := json.Unmarshal([]byte(out.String()), &result); err != nil {
-		return &scorecard
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(result); err != nil {
+		logger.Warn("upstream result does not match the expected schema", zap.Error(err))
+		return fmt.Errorf("strict decode of scorecard result: %w", err)
 	}
+	return nil
+}
+
+// isProcessing detects the placeholder the API returns for repos it has not finished scoring
+func isProcessing(result *ossf.JSONScorecardResultV2) bool {
+	return result.AggregateScore == -1 && len(result.Checks) == 0
+}
 
-	if result.Repo.Commit == commitSha {
+// mapChecks collapses an OpenSSF result into a model.Scorecard
+func mapChecks(result *ossf.JSONScorecardResultV2, commitSha string) *model.Scorecard {
+	var scorecard model.Scorecard
+
+	if commitSha != "" && result.Repo.Commit == commitSha {
 		scorecard.Pinned = true
 		scorecard.CommitSha = commitSha
 	}
 
 	scorecard.Score = float32(result.AggregateScore)
 
+	fields := reflect.ValueOf(&scorecard).Elem()
 	for _, check := range result.Checks {
-		name := check.Name
-		score := float32(check.Score)
-
-		switch name {
-		case "Maintained":
-			scorecard.Maintained = score
-		case "Code-Review":
-			scorecard.CodeReview = score
-		case "CII-Best-Practices":
-			scorecard.CIIBestPractices = score
-		case "License":
-			scorecard.License = score
-		case "Signed-Releases":
-			scorecard.SignedReleases = score
-		case "Dangerous-Workflow":
-			scorecard.DangerousWorkflow = score
-		case "Packaging":
-			scorecard.Packaging = score
-		case "Token-Permissions":
-			scorecard.TokenPermissions = score
-		case "Branch-Protection":
-			scorecard.BranchProtection = score
-		case "Binary-Artifacts":
-			scorecard.BinaryArtifacts = score
-		case "Pinned-Dependencies":
-			scorecard.PinnedDependencies = score
-		case "Security-Policy":
-			scorecard.SecurityPolicy = score
-		case "Fuzzing":
-			scorecard.Fuzzing = score
-		case "SAST":
-			scorecard.SAST = score
-		case "Vulnerabilities":
-			scorecard.Vulnerabilities = score
-		case "CI-Tests":
-			scorecard.CITests = score
-		case "Contributors":
-			scorecard.Contributors = score
-		case "Dependency-Update-Tool":
-			scorecard.DependencyUpdateTool = score
-		case "SBOM":
-			scorecard.SBOM = score
-		case "Webhooks":
-			scorecard.Webhooks = score
+		if field, ok := checkFields[check.Name]; ok {
+			fields.FieldByName(field).SetFloat(float64(check.Score))
 		}
 	}
-
 	return &scorecard
 }
 
@@ -259,12 +610,59 @@ func HealthCheck(c *fiber.Ctx) error {
 	return c.SendString("OK")
 }
 
-// setupRoutes defines maps the routes to the functions
-func setupRoutes(app *fiber.App) {
-
-	app.Get("/swagger/*", swagger.HandlerDefault) // handle displaying the swagger
-	app.Get("/msapi/scorecard/*", getScorecard)   // repo + ?commit=<sha>
-	app.Get("/health", HealthCheck)               // kubernetes health check
+// setupRoutes applies cfg and maps the routes to the functions, all mounted under cfg.RoutePrefix
+func setupRoutes(app *fiber.App, cfg *Config) {
+	config = cfg
+	readOnly.Store(cfg.ReadOnly)
+	upstream = newUpstreamHealth(cfg.UpstreamHealthWindow, time.Now)
+	breaker = newCircuitBreaker(cfg.BreakerFailures, cfg.BreakerCooldown, time.Now)
+	lookups = newCoalescer(cfg.CoalesceWindow)
+	cache = newCache(cfg)
+	scoredRepos = newRepoSet(cfg.DistinctReposLimit)
+	hot = newHotRepos(cfg.HotReposTracked)
+	history = newScoreHistory(cfg.HistoryLimit, cfg.DistinctReposLimit)
+	outbound = newOutboundLimiter(cfg.GlobalOutboundConcurrency)
+	goModules = newGoModuleCache(time.Now)
+	batchWorkers = newOutboundLimiter(cfg.ScorecardMaxConcurrency)
+	scans = newScanQueue(cfg.ScanConcurrency, cfg.ScanQueueLength, cfg.ScanQueueTimeout)
+	client = newClient(cfg)
+	compressor = newCompressor(cfg.Compression)
+
+	docs.SwaggerInfo.BasePath = cfg.RoutePrefix + "/msapi/scorecard"
+
+	router := app.Group(cfg.RoutePrefix)
+	router.Use(compressResponses)
+	router.Get("/swagger/*", swagger.HandlerDefault)                         // handle displaying the swagger
+	router.Post("/msapi/scorecard/map", mapScorecard)                        // raw OpenSSF json in, scorecard out
+	router.Post("/msapi/scorecard/batch", getBatch)                          // many repos in one call
+	router.Post("/msapi/scorecard/sbom", getSBOMScorecards)                  // CycloneDX or SPDX SBOM components
+	router.Post("/msapi/scorecard/regression", getRegression)                // {repo, commit} against the stored baseline
+	router.Post("/msapi/scorecard/org/evaluate", adminAuth, evaluateOrg)     // a policy across the repos of an org
+	router.Get("/msapi/scorecard/normalize", getNormalizedURL)               // ?url=<raw> repo normalization
+	router.Get("/msapi/scorecard/metadata", getMetadata)                     // check names, fields, risk and weights
+	router.Get("/msapi/scorecard/checks", getChecks)                         // check descriptions from the library docs
+	router.Get("/msapi/scorecard/compare", getCompare)                       // ?repos=a,b,c check score matrix
+	router.Get("/msapi/scorecard/purl", getPurlScorecard)                    // ?purl=<package url>
+	router.Get("/msapi/scorecard/package/:ecosystem/*", getPackageScorecard) // ecosystem/name + ?version=
+	router.Get("/msapi/scorecard/stream/*", streamScorecard)                 // SSE progress for long lookups
+	router.Get("/msapi/scorecard/*/raw", getRawScorecard)                    // untouched OpenSSF result
+	router.Get("/msapi/scorecard/*/badge", getBadge)                         // SVG badge of the score
+	router.Get("/msapi/scorecard/*/shield", getShield)                       // shields.io endpoint badge json
+	router.Get("/msapi/scorecard/*/history", getHistory)                     // snapshots recorded per repo
+	router.Get("/msapi/scorecard/*/diff", getDiff)                           // ?from=<sha>&to=<sha> check deltas
+	router.Get("/msapi/scorecard/*", getScorecard)                           // repo + ?commit=<sha>
+	router.Delete("/msapi/scorecard/cache/*", adminAuth, purgeCache)         // one repo or, without one, everything
+	router.Get("/msapi/scorecards", queryScorecards)                         // stored scorecards, filtered and paged
+	router.Get("/health", HealthCheck)                                       // kubernetes health check
+	router.Get("/ready", ReadinessCheck)                                     // upstream readiness detail
+	router.Get("/metrics", MetricsHandler)                                   // expvar metrics
+	router.Get("/stats", StatsHandler)                                       // distinct repos scored
+	router.Get("/version", VersionHandler)                                   // scorecard library version
+
+	admin := router.Group("/admin", adminAuth)
+	admin.Get("/readonly", getReadOnly)
+	admin.Put("/readonly", setReadOnly)
+	admin.Get("/cache/stats", getCacheStats)
 
 }
 
@@ -289,15 +687,40 @@ func setupRoutes(app *fiber.App) {
 // @host localhost:3000
 // @BasePath /msapi/scorecard
 func main() {
-	port := os.Getenv("MS_PORT")
-	if port == "" {
-		port = ":8083"
-	} else {
-		port = ":" + port
+	cfg, err := loadConfig(os.Getenv)
+	if err != nil {
+		logger.Sugar().Fatalf("Invalid configuration: %v", err)
+	}
+	port := cfg.Port
+
+	if cfg.PersistScorecards {
+		if store, err = openStore(); err != nil {
+			logger.Sugar().Fatalf("Failed to open the scorecard store: %v", err)
+		}
+	}
+
+	app := fiber.New()     // create a new fiber application
+	setupRoutes(app, cfg)  // define the routes for this microservice
+	startWarming(cfg)      // fetch the hot repos ahead of their first lookup
+	startRefreshing(cfg)   // and keep the most requested from expiring
+	startCacheMetrics(cfg) // publish the cache footprint
+
+	tlsCfg, err := tlsConfig(cfg)
+	if err != nil {
+		logger.Sugar().Fatalf("Failed to configure TLS: %v", err)
+	}
+
+	if tlsCfg != nil {
+		ln, err := tls.Listen("tcp", port, tlsCfg)
+		if err != nil {
+			logger.Sugar().Fatalf("Failed get the microservice running: %v", err)
+		}
+		if err := app.Listener(ln); err != nil { // serve TLS on the configured listener
+			logger.Sugar().Fatalf("Failed get the microservice running: %v", err)
+		}
+		return
 	}
 
-	app := fiber.New()                       // create a new fiber application
-	setupRoutes(app)                         // define the routes for this microservice
 	if err := app.Listen(port); err != nil { // start listening for incoming connections
 		logger.Sugar().Fatalf("Failed get the microservice running: %v", err)
 	}
@@ -0,0 +1,616 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ortelius/scec-commons/model"
+	"github.com/ortelius/scec-scorecard/docs"
+	"github.com/ossf/scorecard/v5/checker"
+	"github.com/ossf/scorecard/v5/clients"
+	ossf "github.com/ossf/scorecard/v5/pkg/scorecard"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// testConfig is the Config loadConfig builds from env alone
+func testConfig(t *testing.T, env map[string]string) *Config {
+	t.Helper()
+	cfg, err := loadConfig(func(name string) string { return env[name] })
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	return cfg
+}
+
+// newTestApp is the service configured from env. The globals setupRoutes replaces are reset
+// to the defaults when the test ends.
+func newTestApp(t *testing.T, env map[string]string) *fiber.App {
+	t.Helper()
+	app := fiber.New()
+	setupRoutes(app, testConfig(t, env))
+	t.Cleanup(func() {
+		setupRoutes(fiber.New(), defaultConfig())
+		store = nil
+	})
+	return app
+}
+
+// doRequest sends req to app and returns the status and body of the response
+func doRequest(t *testing.T, app *fiber.App, req *http.Request) (int, string) {
+	t.Helper()
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", req.Method, req.URL, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading %s: %v", req.URL, err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+// observeLogs records what is logged at level and above until the test ends
+func observeLogs(t *testing.T, level zapcore.Level) *observer.ObservedLogs {
+	t.Helper()
+	core, logs := observer.New(level)
+	previous := logger
+	logger = zap.New(core)
+	t.Cleanup(func() { logger = previous })
+	return logs
+}
+
+// get is doRequest for a GET of target
+func get(t *testing.T, app *fiber.App, target string) (int, string) {
+	t.Helper()
+	return doRequest(t, app, httptest.NewRequest(fiber.MethodGet, target, nil))
+}
+
+// fakeAPI stands in for the OpenSSF API, and for any other upstream a test points at it.
+// It answers the paths it was given a body for and 404s the rest, and counts the calls.
+type fakeAPI struct {
+	*httptest.Server
+	mu      sync.Mutex
+	bodies  map[string]string
+	status  map[string]int
+	calls   map[string]int
+	headers map[string]http.Header
+	delay   time.Duration
+}
+
+func newFakeAPI(t *testing.T) *fakeAPI {
+	t.Helper()
+	f := &fakeAPI{
+		bodies:  make(map[string]string),
+		status:  make(map[string]int),
+		calls:   make(map[string]int),
+		headers: make(map[string]http.Header),
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/")
+		if r.URL.RawQuery != "" {
+			path += "?" + r.URL.RawQuery
+		}
+
+		f.mu.Lock()
+		f.calls[path]++
+		f.headers[path] = r.Header.Clone()
+		body, ok := f.bodies[path]
+		status := f.status[path]
+		delay := f.delay
+		f.mu.Unlock()
+		time.Sleep(delay)
+
+		switch {
+		case status != 0:
+			w.WriteHeader(status)
+			_, _ = io.WriteString(w, body)
+		case ok:
+			w.Header().Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			_, _ = io.WriteString(w, body)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// serve answers path, e.g. github.com/a/b?commit=sha, with body
+func (f *fakeAPI) serve(path, body string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.bodies[path] = body
+}
+
+// fail answers path with status
+func (f *fakeAPI) fail(path string, status int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.status[path] = status
+}
+
+// slow delays every answer by d
+func (f *fakeAPI) slow(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.delay = d
+}
+
+// called is how often path was asked for
+func (f *fakeAPI) called(path string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[path]
+}
+
+// header is the header of the last call for path
+func (f *fakeAPI) header(path string) http.Header {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.headers[path]
+}
+
+// memoryStore is a scorecardStore in memory for the PERSIST_SCORECARDS features. It saves
+// synchronously, so a test sees a lookup's scorecard stored as soon as the lookup returns.
+type memoryStore struct {
+	mu   sync.Mutex
+	docs map[string]storedScorecard
+}
+
+// useMemoryStore stores scorecards in a new memoryStore until the test ends
+func useMemoryStore(t *testing.T) *memoryStore {
+	t.Helper()
+	s := &memoryStore{docs: make(map[string]storedScorecard)}
+	previous := store
+	store = s
+	t.Cleanup(func() { store = previous })
+	return s
+}
+
+func (s *memoryStore) save(repo, source string, result *ossf.JSONScorecardResultV2) {
+	if result == nil || result.Repo.Commit == "" {
+		return
+	}
+	snap := newSnapshot(result)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.docs[storeKey(repo, result.Repo.Commit)] = storedScorecard{
+		Repo: repo, Commit: snap.Commit, Date: snap.Date, Score: snap.Score, Checks: snap.Checks,
+		Source: source, Stored: time.Now().UTC(), Result: result,
+	}
+}
+
+func (s *memoryStore) load(repo, commit string) (storedScorecard, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doc, ok := s.docs[storeKey(repo, commit)]
+	return doc, ok && commit != ""
+}
+
+func (s *memoryStore) history(repo, until string, offset, limit int) ([]snapshot, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snapshots := []snapshot{}
+	for _, doc := range s.docs {
+		if doc.Repo == repo && (until == "" || doc.Date <= until) {
+			snapshots = append(snapshots, newSnapshot(doc.Result))
+		}
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Date < snapshots[j].Date })
+	if until != "" && len(snapshots) > 0 {
+		snapshots = snapshots[len(snapshots)-1:]
+	}
+	start := min(offset, len(snapshots))
+	return snapshots[start:min(start+limit, len(snapshots))], len(snapshots), nil
+}
+
+func (s *memoryStore) query(filter scorecardFilter, offset, limit int) ([]storedSummary, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	latest := map[string]storedScorecard{}
+	for _, doc := range s.docs {
+		if seen, ok := latest[doc.Repo]; !ok || doc.Date > seen.Date {
+			latest[doc.Repo] = doc
+		}
+	}
+
+	matches := []storedSummary{}
+	for _, doc := range latest {
+		check, ok := doc.Checks[filter.check]
+		switch {
+		case filter.org != "" && !strings.HasPrefix(doc.Repo, filter.org+"/"),
+			filter.minScore != nil && doc.Score < *filter.minScore,
+			filter.maxScore != nil && doc.Score > *filter.maxScore,
+			filter.check != "" && (!ok || check < 0),
+			filter.checkLT != nil && check >= *filter.checkLT,
+			filter.checkGTE != nil && check < *filter.checkGTE:
+			continue
+		}
+		matches = append(matches, storedSummary{
+			Repo: doc.Repo, Commit: doc.Commit, Date: doc.Date, Score: doc.Score, Checks: doc.Checks, Source: doc.Source, Stored: doc.Stored,
+		})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score < matches[j].Score
+		}
+		return matches[i].Repo < matches[j].Repo
+	})
+	start := min(offset, len(matches))
+	return matches[start:min(start+limit, len(matches))], len(matches), nil
+}
+
+func (s *memoryStore) baseline(repo, commit, date string) (storedScorecard, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var found storedScorecard
+	for _, doc := range s.docs {
+		if doc.Repo == repo && doc.Commit != commit && doc.Date <= date && doc.Date > found.Date {
+			found = doc
+		}
+	}
+	return found, found.Result != nil, nil
+}
+
+// parseResult decodes an OpenSSF result document such as resultJSON's
+func parseResult(t *testing.T, body string) *ossf.JSONScorecardResultV2 {
+	t.Helper()
+	var r ossf.JSONScorecardResultV2
+	mustJSON(t, body, &r)
+	return &r
+}
+
+// resultJSON is an OpenSSF result document of repo at commit with the given aggregate and
+// check scores, each check with a reason, a detail and its documentation
+func resultJSON(repo, commit string, score float64, checks map[string]int) string {
+	list := make([]map[string]any, 0, len(checks))
+	for _, name := range sortedKeys(checks) {
+		list = append(list, map[string]any{
+			"name":    name,
+			"score":   checks[name],
+			"reason":  name + " reason",
+			"details": []string{name + " detail"},
+			"documentation": map[string]string{
+				"url":   "https://github.com/ossf/scorecard/blob/main/docs/checks.md#" + strings.ToLower(name),
+				"short": name + " short",
+			},
+		})
+	}
+
+	body, _ := json.Marshal(map[string]any{
+		"date":      "2024-05-01T00:00:00Z",
+		"repo":      map[string]string{"name": repo, "commit": commit},
+		"scorecard": map[string]string{"version": "v5.0.0", "commit": "abc123"},
+		"score":     score,
+		"checks":    list,
+	})
+	return string(body)
+}
+
+// stubScan stands in for the scorecard library's scan until the test ends. Each scan reports
+// the repo at commit with the given check scores, or fails with err, and is counted.
+func stubScan(t *testing.T, commit string, checks map[string]int, err error) *atomic.Int32 {
+	t.Helper()
+	var scanned atomic.Int32
+	previous := runScan
+	runScan = func(_ context.Context, repo clients.Repo, _ ...ossf.Option) (ossf.Result, error) {
+		scanned.Add(1)
+		if err != nil {
+			return ossf.Result{}, err
+		}
+
+		result := ossf.Result{
+			Repo:      ossf.RepoInfo{Name: repo.URI(), CommitSHA: commit},
+			Date:      time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC),
+			Scorecard: ossf.ScorecardInfo{Version: "v5.0.0", CommitSHA: "def456"},
+		}
+		for _, name := range sortedKeys(checks) {
+			result.Checks = append(result.Checks, checker.CheckResult{
+				Name:    name,
+				Score:   checks[name],
+				Reason:  name + " reason",
+				Details: []checker.CheckDetail{{Type: checker.DetailInfo, Msg: checker.LogMessage{Text: name + " detail"}}},
+			})
+		}
+		return result, nil
+	}
+	t.Cleanup(func() { runScan = previous })
+	return &scanned
+}
+
+// sortedKeys is the keys of m in order
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sseEvents is the names of the events of a Server-Sent Events body, in order, and the data
+// of the last event of each name
+func sseEvents(body string) ([]string, map[string]string) {
+	var names []string
+	data := make(map[string]string)
+	for _, block := range strings.Split(body, "\n\n") {
+		var name string
+		for _, line := range strings.Split(block, "\n") {
+			if v, ok := strings.CutPrefix(line, "event: "); ok {
+				name = v
+				names = append(names, v)
+			} else if v, ok := strings.CutPrefix(line, "data: "); ok && name != "" {
+				data[name] = v
+			}
+		}
+	}
+	return names, data
+}
+
+// mustJSON unmarshals body into v
+func mustJSON(t *testing.T, body string, v any) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(body), v); err != nil {
+		t.Fatalf("unmarshalling %q: %v", body, err)
+	}
+}
+
+// sha is a 40 character commit sha made of n
+func sha(n int) string {
+	return fmt.Sprintf("%040x", n)
+}
+
+func TestSlowLookupIsLogged(t *testing.T) {
+	api := newFakeAPI(t)
+	api.serve("github.com/a/b", resultJSON("github.com/a/b", sha(1), 5, nil))
+	app := newTestApp(t, map[string]string{
+		"SCORECARD_API_URLS":        api.URL,
+		"SLOW_REQUEST_THRESHOLD_MS": "50",
+	})
+	logs := observeLogs(t, zapcore.WarnLevel)
+
+	// answered well within the threshold
+	get(t, app, "/msapi/scorecard/github.com/a/b?refresh=true")
+	if n := logs.FilterMessage("slow scorecard lookup").Len(); n != 0 {
+		t.Fatalf("%d slow lookups logged for a fast one", n)
+	}
+
+	api.slow(100 * time.Millisecond)
+	get(t, app, "/msapi/scorecard/github.com/a/b?refresh=true")
+	slow := logs.FilterMessage("slow scorecard lookup").All()
+	if len(slow) != 1 {
+		t.Fatalf("%d slow lookups logged, want 1", len(slow))
+	}
+	fields := slow[0].ContextMap()
+	if fields["repo"] != "github.com/a/b" || fields["source"] != sourceAPI {
+		t.Errorf("slow lookup logged with %v", fields)
+	}
+	if d, ok := fields["duration"].(time.Duration); !ok || d < 100*time.Millisecond {
+		t.Errorf("slow lookup logged a duration of %v", fields["duration"])
+	}
+}
+
+func TestSlowLookupLogIsOffAtZero(t *testing.T) {
+	api := newFakeAPI(t)
+	api.serve("github.com/a/b", resultJSON("github.com/a/b", sha(1), 5, nil))
+	api.slow(20 * time.Millisecond)
+	app := newTestApp(t, map[string]string{
+		"SCORECARD_API_URLS":        api.URL,
+		"SLOW_REQUEST_THRESHOLD_MS": "0",
+	})
+	logs := observeLogs(t, zapcore.WarnLevel)
+
+	get(t, app, "/msapi/scorecard/github.com/a/b")
+	if n := logs.FilterMessage("slow scorecard lookup").Len(); n != 0 {
+		t.Errorf("%d slow lookups logged with the log turned off", n)
+	}
+}
+
+func TestStillProcessingResultIsAccepted(t *testing.T) {
+	api := newFakeAPI(t)
+	api.serve("github.com/a/b", `{"date":"2024-05-01","repo":{"name":"github.com/a/b","commit":""},"score":-1,"checks":[]}`)
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL})
+
+	status, body := get(t, app, "/msapi/scorecard/github.com/a/b")
+	if status != fiber.StatusAccepted {
+		t.Fatalf("status %d, body %s", status, body)
+	}
+	var processing processingResponse
+	mustJSON(t, body, &processing)
+	if processing.Status != "processing" || processing.Message == "" {
+		t.Errorf("body %+v", processing)
+	}
+}
+
+// postMap posts a raw result to the map endpoint with query
+func postMap(t *testing.T, app *fiber.App, query, raw string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodPost, "/msapi/scorecard/map"+query, strings.NewReader(raw))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return doRequest(t, app, req)
+}
+
+func TestStrictDecodeRejectsUnknownFields(t *testing.T) {
+	raw := strings.Replace(resultJSON("github.com/a/b", sha(1), 5, nil), `"date"`, `"surprise":true,"date"`, 1)
+
+	lenient := newTestApp(t, nil)
+	if status, body := postMap(t, lenient, "", raw); status != fiber.StatusOK {
+		t.Errorf("lenient: status %d, body %s", status, body)
+	}
+
+	strict := newTestApp(t, map[string]string{"STRICT_DECODE": "true"})
+	logs := observeLogs(t, zapcore.WarnLevel)
+	status, body := postMap(t, strict, "", raw)
+	if status != fiber.StatusBadRequest || !strings.Contains(body, "surprise") {
+		t.Errorf("strict: status %d, body %s", status, body)
+	}
+	if logs.FilterMessage("upstream result does not match the expected schema").Len() != 1 {
+		t.Error("strict: the unknown field was not logged")
+	}
+	if status, body := postMap(t, strict, "", resultJSON("github.com/a/b", sha(1), 5, nil)); status != fiber.StatusOK {
+		t.Errorf("strict, known fields only: status %d, body %s", status, body)
+	}
+}
+
+func TestStrictDecodeFailsAnUpstreamResultWithUnknownFields(t *testing.T) {
+	api := newFakeAPI(t)
+	api.serve("github.com/a/b", strings.Replace(resultJSON("github.com/a/b", sha(1), 5, nil), `"date"`, `"surprise":true,"date"`, 1))
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL, "STRICT_DECODE": "true"})
+
+	status, body := get(t, app, "/msapi/scorecard/github.com/a/b")
+	if status != fiber.StatusBadGateway || !strings.Contains(body, "UPSTREAM_ERROR") {
+		t.Errorf("status %d, body %s", status, body)
+	}
+}
+
+func TestMapScorecardMapsARawResult(t *testing.T) {
+	app := newTestApp(t, nil)
+	raw := resultJSON("github.com/a/b", sha(1), 6.4, map[string]int{
+		"Branch-Protection": 3,
+		"Code-Review":       10,
+		"Token-Permissions": -1,
+		"SBOM":              0,
+	})
+
+	status, body := postMap(t, app, "?commit="+sha(1), raw)
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d, body %s", status, body)
+	}
+	var got scorecardResponse
+	mustJSON(t, body, &got)
+	want := model.Scorecard{CommitSha: sha(1), Pinned: true, Score: 6.4, BranchProtection: 3, CodeReview: 10, TokenPermissions: -1}
+	if got.Scorecard != want {
+		t.Errorf("mapped %+v", got.Scorecard)
+	}
+
+	// not the scored commit, so not pinned
+	_, body = postMap(t, app, "?commit="+sha(2), raw)
+	mustJSON(t, body, &got)
+	if got.Pinned || got.CommitSha != "" || got.Score != 6.4 {
+		t.Errorf("mapped for another commit %+v", got.Scorecard)
+	}
+
+	if status, _ := postMap(t, app, "", "{not json"); status != fiber.StatusBadRequest {
+		t.Errorf("malformed body: status %d", status)
+	}
+}
+
+func TestRoutesAreMountedUnderTheRoutePrefix(t *testing.T) {
+	api := newFakeAPI(t)
+	api.serve("github.com/a/b", resultJSON("github.com/a/b", sha(1), 5, nil))
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL, "ROUTE_PREFIX": "/scorecard/"})
+
+	if status, body := get(t, app, "/scorecard/msapi/scorecard/github.com/a/b"); status != fiber.StatusOK {
+		t.Errorf("prefixed lookup: status %d, body %s", status, body)
+	}
+	if status, _ := get(t, app, "/scorecard/health"); status != fiber.StatusOK {
+		t.Errorf("prefixed health: status %d", status)
+	}
+	if status, _ := get(t, app, "/msapi/scorecard/github.com/a/b"); status != fiber.StatusNotFound {
+		t.Errorf("unprefixed lookup: status %d", status)
+	}
+	if docs.SwaggerInfo.BasePath != "/scorecard/msapi/scorecard" {
+		t.Errorf("swagger base path %s", docs.SwaggerInfo.BasePath)
+	}
+}
+
+func TestRoutePrefixDefaultsToTheRoot(t *testing.T) {
+	newTestApp(t, nil)
+	if docs.SwaggerInfo.BasePath != "/msapi/scorecard" {
+		t.Errorf("swagger base path %s", docs.SwaggerInfo.BasePath)
+	}
+}
+
+func TestPreferOrdersTheStages(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		query     string
+		preferEnv string
+		apiHas    bool
+		scanFails bool
+		order     string
+		ref       string // resolved_ref, which names the source
+	}{
+		{"api first by default", "", "", true, false, "api", "commit"},
+		{"api then the scan", "&prefer=api", "", false, false, "api,cli", "cli"},
+		{"scan first", "&prefer=cli", "", true, false, "cli", "cli"},
+		{"api when the scan fails", "&prefer=cli", "", true, true, "cli,api", "commit"},
+		{"server default", "", "cli", true, false, "cli", "cli"},
+		{"request overrides the server default", "&prefer=api", "cli", true, false, "api", "commit"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newFakeAPI(t)
+			if tt.apiHas {
+				api.serve("github.com/a/b?commit="+sha(1), resultJSON("github.com/a/b", sha(1), 5, nil))
+			}
+			github := newFakeAPI(t)
+			github.serve("repos/a/b", `{"full_name":"a/b"}`)
+
+			var scanErr error
+			if tt.scanFails {
+				scanErr = errors.New("scan failed")
+			}
+			scanned := stubScan(t, sha(1), map[string]int{"License": 10}, scanErr)
+			apiCalls := func() int { return api.called("github.com/a/b?commit="+sha(1)) + api.called("github.com/a/b") }
+			var apiBeforeScan atomic.Int32
+			scan := runScan
+			runScan = func(ctx context.Context, repo clients.Repo, opts ...ossf.Option) (ossf.Result, error) {
+				apiBeforeScan.Store(int32(apiCalls()))
+				return scan(ctx, repo, opts...)
+			}
+			app := newTestApp(t, map[string]string{
+				"SCORECARD_API_URLS": api.URL,
+				"GITHUB_API_URL":     github.URL,
+				"GITHUB_TOKEN":       "token",
+				"PREFER_SOURCE":      tt.preferEnv,
+			})
+
+			resp := getResponse(t, app, "/msapi/scorecard/github.com/a/b?commit="+sha(1)+tt.query)
+			var order []string
+			switch {
+			case scanned.Load() == 0:
+				order = []string{"api"}
+			case apiBeforeScan.Load() > 0:
+				order = []string{"api", "cli"}
+			case apiCalls() > 0:
+				order = []string{"cli", "api"}
+			default:
+				order = []string{"cli"}
+			}
+			if got := strings.Join(order, ","); got != tt.order {
+				t.Errorf("attempted %s, want %s", got, tt.order)
+			}
+			if resp.ResolvedRef != tt.ref {
+				t.Errorf("resolved_ref %q, want %q", resp.ResolvedRef, tt.ref)
+			}
+		})
+	}
+
+	app := newTestApp(t, nil)
+	if status, _ := get(t, app, "/msapi/scorecard/github.com/a/b?prefer=both"); status != fiber.StatusBadRequest {
+		t.Errorf("prefer=both: status %d, want 400", status)
+	}
+	if _, err := loadConfig(func(name string) string {
+		if name == "PREFER_SOURCE" {
+			return "both"
+		}
+		return ""
+	}); err == nil {
+		t.Error("PREFER_SOURCE=both was accepted")
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"go.uber.org/zap"
+)
+
+// memcachedTimeout bounds one cache read or write against memcached
+const memcachedTimeout = 2 * time.Second
+
+// memcachedMaxRelative is the longest expiry memcached takes as seconds from now; longer
+// ones must be sent as a unix time
+const memcachedMaxRelative = 30 * 24 * time.Hour
+
+// memcachedCache is the Cache kept in memcached, shared like the redis one by every
+// replica. memcached cannot list its keys, so Purge of a repo drops only its latest,
+// unpinned scorecard, Purge of everything flushes the whole memcached, and Stats has no
+// entry count or memory. Failures are logged and treated as misses.
+type memcachedCache struct {
+	cacheCounters
+	client *memcache.Client
+}
+
+func newMemcachedCache(servers []string) *memcachedCache {
+	client := memcache.New(servers...)
+	client.Timeout = memcachedTimeout
+	return &memcachedCache{client: client}
+}
+
+// memcachedKey is key under cacheKeyPrefix, hashed when memcached would not accept it as is:
+// longer than 250 bytes or with spaces or control characters
+func memcachedKey(key string) string {
+	key = cacheKeyPrefix + key
+	legal := len(key) <= 250 && !strings.ContainsFunc(key, func(r rune) bool { return r <= ' ' || r == 0x7f })
+	if legal {
+		return key
+	}
+	sum := sha256.Sum256([]byte(key))
+	return cacheKeyPrefix + hex.EncodeToString(sum[:])
+}
+
+func (c *memcachedCache) Get(key string) (CacheEntry, bool) {
+	item, err := c.client.Get(memcachedKey(key))
+	if err != nil {
+		if !errors.Is(err, memcache.ErrCacheMiss) {
+			logger.Warn("memcached cache read failed", zap.String("key", key), zap.Error(err))
+		}
+		return c.read(CacheEntry{}, false)
+	}
+
+	var entry CacheEntry
+	if err := json.Unmarshal(item.Value, &entry); err != nil || (entry.Result == nil && entry.Miss == "") {
+		logger.Warn("memcached cache entry is unreadable", zap.String("key", key), zap.Error(err))
+		return c.read(CacheEntry{}, false)
+	}
+	return c.read(entry, true)
+}
+
+func (c *memcachedCache) Set(key string, entry CacheEntry, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		logger.Warn("memcached cache entry cannot be encoded", zap.String("key", key), zap.Error(err))
+		return
+	}
+
+	expiration := int32(ttl.Seconds())
+	if ttl > memcachedMaxRelative {
+		expiration = int32(time.Now().Add(ttl).Unix())
+	}
+	if err := c.client.Set(&memcache.Item{Key: memcachedKey(key), Value: data, Expiration: max(expiration, 1)}); err != nil {
+		logger.Warn("memcached cache write failed", zap.String("key", key), zap.Error(err))
+	}
+}
+
+func (c *memcachedCache) Invalidate(key string) {
+	if err := c.client.Delete(memcachedKey(key)); err != nil && !errors.Is(err, memcache.ErrCacheMiss) {
+		logger.Warn("memcached cache invalidation failed", zap.String("key", key), zap.Error(err))
+	}
+}
+
+func (c *memcachedCache) Purge(prefix string) int {
+	if prefix == "" {
+		if err := c.client.FlushAll(); err != nil {
+			logger.Warn("memcached cache purge failed", zap.Error(err))
+		}
+		return 0
+	}
+
+	err := c.client.Delete(memcachedKey(prefix))
+	switch {
+	case err == nil:
+		return 1
+	case !errors.Is(err, memcache.ErrCacheMiss):
+		logger.Warn("memcached cache purge failed", zap.String("prefix", prefix), zap.Error(err))
+	}
+	return 0
+}
+
+func (c *memcachedCache) Stats() CacheStats {
+	return c.stats(CacheStats{Backend: cacheMemcached})
+}
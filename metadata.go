@@ -0,0 +1,103 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ortelius/scec-commons/model"
+)
+
+// checkMetadata describes how one OpenSSF check is mapped and weighted
+type checkMetadata struct {
+	Name             string  `json:"name"`
+	Field            string  `json:"field"`
+	JSONKey          string  `json:"json_key"`
+	Risk             string  `json:"risk"`
+	Weight           float64 `json:"weight"`
+	DocumentationURL string  `json:"documentation_url"`
+}
+
+// checksMetadata lists every mapped check, sorted by name, from checkFields, the bundled
+// check docs and riskWeights
+func checksMetadata() []checkMetadata {
+	scorecardType := reflect.TypeOf(model.Scorecard{})
+
+	metadata := make([]checkMetadata, 0, len(checkFields))
+	for name, field := range checkFields {
+		entry := checkMetadata{Name: name, Field: field, Risk: checkRisk(name)}
+		entry.Weight = riskWeights[entry.Risk]
+
+		if f, ok := scorecardType.FieldByName(field); ok {
+			entry.JSONKey, _, _ = strings.Cut(f.Tag.Get("json"), ",")
+		}
+		if doc, err := checkDocs.GetCheck(name); err == nil {
+			entry.DocumentationURL = doc.GetDocumentationURL("")
+		}
+		metadata = append(metadata, entry)
+	}
+
+	sort.Slice(metadata, func(i, j int) bool { return metadata[i].Name < metadata[j].Name })
+	return metadata
+}
+
+// getMetadata godoc
+// @Summary List the supported checks
+// @Description For every check mapped into the scorecard: its model field and JSON key,
+// @Description OpenSSF risk tier, the weight of that tier in the aggregate and its documentation
+// @Tags scorecard
+// @Produce json
+// @Success 200 {array} checkMetadata
+// @Router /msapi/scorecard/metadata [get]
+func getMetadata(c *fiber.Ctx) error {
+	return c.JSON(checksMetadata())
+}
+
+// checkInfo is the library's documentation for one check, for UIs explaining the checks
+type checkInfo struct {
+	Name             string   `json:"name"`
+	Short            string   `json:"short"`
+	Description      string   `json:"description"`
+	Risk             string   `json:"risk"`
+	Remediation      []string `json:"remediation"`
+	Tags             []string `json:"tags"`
+	RepoTypes        []string `json:"repo_types"`
+	DocumentationURL string   `json:"documentation_url"`
+	Field            string   `json:"field,omitempty"`
+}
+
+// checksInfo lists every check the bundled check docs describe, sorted by name. Field is the
+// model field the check is mapped to, empty for checks reported only as unmapped.
+func checksInfo() []checkInfo {
+	checks := []checkInfo{}
+	for _, doc := range checkDocs.GetChecks() {
+		checks = append(checks, checkInfo{
+			Name:             doc.GetName(),
+			Short:            doc.GetShort(),
+			Description:      doc.GetDescription(),
+			Risk:             doc.GetRisk(),
+			Remediation:      doc.GetRemediation(),
+			Tags:             doc.GetTags(),
+			RepoTypes:        doc.GetSupportedRepoTypes(),
+			DocumentationURL: doc.GetDocumentationURL(""),
+			Field:            checkFields[doc.GetName()],
+		})
+	}
+
+	sort.Slice(checks, func(i, j int) bool { return checks[i].Name < checks[j].Name })
+	return checks
+}
+
+// getChecks godoc
+// @Summary Describe the OpenSSF checks
+// @Description For every check the scorecard library documents: its short and full description, risk
+// @Description level, remediation steps, tags, supported repo types, documentation link and, when it is
+// @Description mapped into the scorecard, its model field
+// @Tags scorecard
+// @Produce json
+// @Success 200 {array} checkInfo
+// @Router /msapi/scorecard/checks [get]
+func getChecks(c *fiber.Ctx) error {
+	return c.JSON(checksInfo())
+}
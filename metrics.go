@@ -0,0 +1,69 @@
+package main
+
+import (
+	"expvar"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// scanMetrics accumulates the footprint of in-process scorecard scans for capacity planning.
+// Scans share the service process, so only their wall-clock time can be attributed to them.
+var scanMetrics = expvar.NewMap("scorecard_scan")
+
+// recordScanUsage publishes the wall-clock time of a finished scan
+func recordScanUsage(wall time.Duration) {
+	scanMetrics.Add("runs", 1)
+	scanMetrics.AddFloat("wall_seconds_total", wall.Seconds())
+	logger.Debug("scorecard scan finished", zap.Duration("wall", wall))
+}
+
+// cacheMetrics is the footprint of the cache: its entries and their approximate size in
+// bytes, each key and entry as JSON, so memory can be alerted on before it runs out. It is
+// updated every CACHE_METRICS_INTERVAL rather than on each read of /metrics, as sizing a
+// Redis or file cache walks every key.
+var (
+	cacheMetrics = expvar.NewMap("scorecard_cache")
+	cacheEntries = new(expvar.Int)
+	cacheBytes   = new(expvar.Int)
+)
+
+func init() {
+	cacheMetrics.Set("entries", cacheEntries)
+	cacheMetrics.Set("bytes", cacheBytes)
+}
+
+// startCacheMetrics publishes the cache footprint now and then every CACHE_METRICS_INTERVAL
+func startCacheMetrics(cfg *Config) {
+	recordCacheFootprint()
+	go func() {
+		for range time.Tick(cfg.CacheMetricsInterval) {
+			recordCacheFootprint()
+		}
+	}()
+}
+
+// recordCacheFootprint publishes the entry count and size the cache reports
+func recordCacheFootprint() {
+	stats := cache.Stats()
+	cacheEntries.Set(int64(stats.Entries))
+	cacheBytes.Set(stats.MemoryBytes)
+}
+
+// MetricsHandler serves every published expvar as a single JSON document
+func MetricsHandler(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+
+	first := true
+	_, _ = c.WriteString("{")
+	expvar.Do(func(kv expvar.KeyValue) {
+		if !first {
+			_, _ = c.WriteString(",")
+		}
+		first = false
+		_, _ = c.WriteString(`"` + kv.Key + `":` + kv.Value.String())
+	})
+	_, _ = c.WriteString("}")
+	return nil
+}
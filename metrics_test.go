@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ossf/scorecard/v5/clients"
+	ossf "github.com/ossf/scorecard/v5/pkg/scorecard"
+)
+
+// scanUsage is the scan runs and wall-clock seconds published so far
+func scanUsage() (int64, float64) {
+	var runs int64
+	var wall float64
+	if v, ok := scanMetrics.Get("runs").(*expvar.Int); ok {
+		runs = v.Value()
+	}
+	if v, ok := scanMetrics.Get("wall_seconds_total").(*expvar.Float); ok {
+		wall = v.Value()
+	}
+	return runs, wall
+}
+
+func TestScanRecordsItsDuration(t *testing.T) {
+	stubScan(t, sha(1), map[string]int{"License": 10}, nil)
+	scan := runScan
+	runScan = func(ctx context.Context, repo clients.Repo, opts ...ossf.Option) (ossf.Result, error) {
+		time.Sleep(20 * time.Millisecond)
+		return scan(ctx, repo, opts...)
+	}
+	app := newTestApp(t, map[string]string{"GITHUB_TOKEN": "token"})
+
+	runsBefore, wallBefore := scanUsage()
+	resp := getResponse(t, app, "/msapi/scorecard/github.com/a/b?prefer=cli&commit="+sha(1))
+	if resp.ResolvedRef != "cli" {
+		t.Fatalf("resolved_ref %q, want the scan", resp.ResolvedRef)
+	}
+
+	runs, wall := scanUsage()
+	if runs != runsBefore+1 {
+		t.Errorf("runs went from %d to %d, want one more", runsBefore, runs)
+	}
+	if elapsed := wall - wallBefore; elapsed < 0.02 {
+		t.Errorf("recorded %.3fs for a scan of at least 20ms", elapsed)
+	}
+
+	status, body := get(t, app, "/metrics")
+	if status != fiber.StatusOK {
+		t.Fatalf("metrics status %d", status)
+	}
+	var metrics map[string]json.RawMessage
+	mustJSON(t, body, &metrics)
+	var published struct {
+		Runs int64   `json:"runs"`
+		Wall float64 `json:"wall_seconds_total"`
+	}
+	mustJSON(t, string(metrics["scorecard_scan"]), &published)
+	if published.Runs != runs || published.Wall != wall {
+		t.Errorf("/metrics scorecard_scan %+v, want %d runs and %vs", published, runs, wall)
+	}
+}
+
+// cacheFootprint is the scorecard_cache entries and bytes /metrics publishes
+func cacheFootprint(t *testing.T, app *fiber.App) (int64, int64) {
+	t.Helper()
+	_, body := get(t, app, "/metrics")
+	var metrics map[string]json.RawMessage
+	mustJSON(t, body, &metrics)
+	var footprint struct {
+		Entries int64 `json:"entries"`
+		Bytes   int64 `json:"bytes"`
+	}
+	mustJSON(t, string(metrics["scorecard_cache"]), &footprint)
+	return footprint.Entries, footprint.Bytes
+}
+
+func TestCacheFootprintGrowsWithTheCache(t *testing.T) {
+	api := newFakeAPI(t)
+	for i := 1; i <= 6; i++ {
+		repo := fmt.Sprintf("github.com/o/r%d", i)
+		api.serve(repo, resultJSON(repo, sha(i), 5, map[string]int{"Code-Review": i, "License": 10}))
+	}
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL})
+	recordCacheFootprint()
+	if entries, bytes := cacheFootprint(t, app); entries != 0 || bytes != 0 {
+		t.Errorf("empty cache published as %d entries of %d bytes", entries, bytes)
+	}
+
+	for i := 1; i <= 2; i++ {
+		getResponse(t, app, fmt.Sprintf("/msapi/scorecard/github.com/o/r%d", i))
+	}
+	recordCacheFootprint()
+	entries, bytes := cacheFootprint(t, app)
+	if entries != 2 || bytes <= 0 {
+		t.Fatalf("%d entries of %d bytes, want 2 entries of some size", entries, bytes)
+	}
+
+	for i := 3; i <= 6; i++ {
+		getResponse(t, app, fmt.Sprintf("/msapi/scorecard/github.com/o/r%d", i))
+	}
+	if more, _ := cacheFootprint(t, app); more != entries {
+		t.Errorf("the gauge moved to %d before it was updated", more)
+	}
+	recordCacheFootprint()
+	more, moreBytes := cacheFootprint(t, app)
+	if more != 6 || moreBytes <= bytes {
+		t.Errorf("%d entries of %d bytes after 4 more lookups, was %d of %d", more, moreBytes, entries, bytes)
+	}
+
+	if cfg := testConfig(t, map[string]string{"CACHE_METRICS_INTERVAL": "5s"}); cfg.CacheMetricsInterval != 5*time.Second {
+		t.Errorf("CACHE_METRICS_INTERVAL gave %v", cfg.CacheMetricsInterval)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/compress"
+	"github.com/valyala/fasthttp"
+	"go.uber.org/zap"
+)
+
+var errMiddlewareUnavailable = errors.New("a middleware failed and the request was refused")
+
+// the MIDDLEWARE_FAIL_MODE settings: a middleware whose backend fails lets the request
+// through when open and refuses it when closed
+const (
+	failOpen   = "open"
+	failClosed = "closed"
+)
+
+// defaultFailModes are the middlewares and how each fails when MIDDLEWARE_FAIL_MODE does not
+// say. The admin token check guards the admin routes, so it fails closed; compression only
+// shapes the response, so it fails open and the response is sent uncompressed.
+var defaultFailModes = map[string]string{
+	"admin":    failClosed,
+	"compress": failOpen,
+}
+
+// middlewareFailed handles a failure of the backend of middleware name. Closed, it is
+// errMiddlewareUnavailable, to refuse the request with; open, it is nil, and the middleware
+// goes on as if its backend had let the request through. Either way the failure is logged.
+func middlewareFailed(name string, err error) error {
+	if config.MiddlewareFailModes[name] == failOpen {
+		logger.Warn("middleware failed open", zap.String("middleware", name), zap.Error(err))
+		return nil
+	}
+	logger.Error("middleware failed closed", zap.String("middleware", name), zap.Error(err))
+	return fmt.Errorf("%w: %s", errMiddlewareUnavailable, name)
+}
+
+// callBackend runs the backend of a middleware, with a panic reported as its error
+func callBackend(call func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return call()
+}
+
+// compressor compresses a response in place, with gzip, deflate or brotli as the client
+// accepts; setupRoutes builds it for COMPRESSION, nil when off
+var compressor fasthttp.RequestHandler
+
+// newCompressor is the compressor of a COMPRESSION level, as the fiber compress middleware builds it
+func newCompressor(level compress.Level) fasthttp.RequestHandler {
+	noop := func(*fasthttp.RequestCtx) {}
+	switch level {
+	case compress.LevelBestSpeed:
+		return fasthttp.CompressHandlerBrotliLevel(noop, fasthttp.CompressBrotliBestSpeed, fasthttp.CompressBestSpeed)
+	case compress.LevelDefault:
+		return fasthttp.CompressHandlerBrotliLevel(noop, fasthttp.CompressBrotliDefaultCompression, fasthttp.CompressDefaultCompression)
+	case compress.LevelBestCompression:
+		return fasthttp.CompressHandlerBrotliLevel(noop, fasthttp.CompressBrotliBestCompression, fasthttp.CompressBestCompression)
+	default:
+		return nil
+	}
+}
+
+// compressResponses compresses every response but the stream, which is flushed event by event
+// and would be held back. A compressor that fails is handled by the "compress" fail mode.
+func compressResponses(c *fiber.Ctx) error {
+	if compressor == nil || strings.HasPrefix(c.Path(), config.RoutePrefix+"/msapi/scorecard/stream/") {
+		return c.Next()
+	}
+	if err := c.Next(); err != nil {
+		return err
+	}
+
+	err := callBackend(func() error {
+		compressor(c.Context())
+		return nil
+	})
+	if err != nil {
+		if err := middlewareFailed("compress", err); err != nil {
+			return sendLookupError(c, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"maps"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// failAdminToken makes the backend of adminAuth fail until the test ends
+func failAdminToken(t *testing.T) {
+	previous := readAdminToken
+	readAdminToken = func() (string, error) { return "", errors.New("token backend down") }
+	t.Cleanup(func() { readAdminToken = previous })
+}
+
+func TestMiddlewareFailModes(t *testing.T) {
+	for _, tt := range []struct {
+		env        string
+		admin, zip string
+	}{
+		{"", failClosed, failOpen},
+		{"open", failOpen, failOpen},
+		{"Closed", failClosed, failClosed},
+		{"compress=closed", failClosed, failClosed},
+		{"admin=open, compress=open", failOpen, failOpen},
+	} {
+		cfg := testConfig(t, map[string]string{"MIDDLEWARE_FAIL_MODE": tt.env})
+		want := map[string]string{"admin": tt.admin, "compress": tt.zip}
+		if !maps.Equal(cfg.MiddlewareFailModes, want) {
+			t.Errorf("MIDDLEWARE_FAIL_MODE=%q gave %v, want %v", tt.env, cfg.MiddlewareFailModes, want)
+		}
+	}
+	if defaultFailModes["admin"] != failClosed {
+		t.Error("the defaults were changed by a MIDDLEWARE_FAIL_MODE")
+	}
+}
+
+func TestAdminAuthBackendFailure(t *testing.T) {
+	for _, tt := range []struct {
+		mode   string
+		status int
+	}{
+		{"", fiber.StatusServiceUnavailable},
+		{"admin=closed", fiber.StatusServiceUnavailable},
+		{"admin=open", fiber.StatusOK},
+	} {
+		t.Run(tt.mode, func(t *testing.T) {
+			app := newTestApp(t, map[string]string{"ADMIN_TOKEN": "admin", "MIDDLEWARE_FAIL_MODE": tt.mode})
+			failAdminToken(t)
+
+			status, body := get(t, app, "/admin/readonly")
+			if status != tt.status {
+				t.Fatalf("status %d, body %s, want %d", status, body, tt.status)
+			}
+			if status != fiber.StatusOK && !strings.Contains(body, "MIDDLEWARE_UNAVAILABLE") {
+				t.Errorf("body %s", body)
+			}
+		})
+	}
+
+	// a panic is a failure too
+	app := newTestApp(t, map[string]string{"ADMIN_TOKEN": "admin"})
+	previous := readAdminToken
+	readAdminToken = func() (string, error) { panic("no token") }
+	t.Cleanup(func() { readAdminToken = previous })
+	if status, _ := get(t, app, "/admin/readonly"); status != fiber.StatusServiceUnavailable {
+		t.Errorf("a panicking backend: status %d", status)
+	}
+}
+
+// getCompressed requests the metadata, large enough to be compressed, accepting gzip
+func getCompressed(t *testing.T, app *fiber.App) (int, string, string) {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodGet, "/msapi/scorecard/metadata", nil)
+	req.Header.Set(fiber.HeaderAcceptEncoding, "gzip")
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, resp.Header.Get(fiber.HeaderContentEncoding), string(body)
+}
+
+func TestCompressBackendFailure(t *testing.T) {
+	app := newTestApp(t, nil)
+	if status, encoding, _ := getCompressed(t, app); status != fiber.StatusOK || encoding != "gzip" {
+		t.Fatalf("status %d, encoding %q, want gzip", status, encoding)
+	}
+
+	for _, tt := range []struct {
+		mode   string
+		status int
+	}{
+		{"", fiber.StatusOK},
+		{"compress=open", fiber.StatusOK},
+		{"compress=closed", fiber.StatusServiceUnavailable},
+	} {
+		t.Run(tt.mode, func(t *testing.T) {
+			app := newTestApp(t, map[string]string{"MIDDLEWARE_FAIL_MODE": tt.mode})
+			compressor = func(*fasthttp.RequestCtx) { panic("compressor broken") }
+
+			status, encoding, body := getCompressed(t, app)
+			if status != tt.status {
+				t.Fatalf("status %d, body %s, want %d", status, body, tt.status)
+			}
+			if status == fiber.StatusOK && (encoding != "" || !strings.Contains(body, "Binary-Artifacts")) {
+				t.Errorf("failed open: encoding %q, body %.40s, want it sent uncompressed", encoding, body)
+			}
+			if status != fiber.StatusOK && !strings.Contains(body, "MIDDLEWARE_UNAVAILABLE") {
+				t.Errorf("body %s", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/protobuf/proto"
+)
+
+// formats maps the ?format= values to the media types the service can produce.
+// JSON is listed first so it wins when the client has no preference.
+var formats = []struct {
+	name string
+	mime string
+}{
+	{"json", fiber.MIMEApplicationJSON},
+	{"protobuf", mimeProtobuf},
+}
+
+// negotiateFormat picks the response media type. An explicit ?format= overrides the
+// Accept header; otherwise the q-values in Accept decide, falling back to JSON when
+// Accept is absent. A 406 is returned when Accept only lists unsupported types.
+func negotiateFormat(c *fiber.Ctx) (string, error) {
+	if format := c.Query("format"); format != "" {
+		for _, f := range formats {
+			if strings.EqualFold(f.name, format) {
+				return f.mime, nil
+			}
+		}
+		return "", fiber.NewError(fiber.StatusBadRequest, "unsupported format: "+format)
+	}
+
+	offers := make([]string, 0, len(formats))
+	for _, f := range formats {
+		offers = append(offers, f.mime)
+	}
+
+	mime := c.Accepts(offers...)
+	if mime == "" {
+		return "", fiber.NewError(fiber.StatusNotAcceptable, "supported types are "+strings.Join(offers, ", "))
+	}
+	return mime, nil
+}
+
+// responseFormat is how a scorecard is written: the negotiated media type and, for JSON,
+// the ?fields= projection, nil when every field is wanted
+type responseFormat struct {
+	mime   string
+	fields []string
+}
+
+// parseFormat negotiates the media type and validates ?fields= so a request that cannot be
+// answered is rejected before any lookup
+func parseFormat(c *fiber.Ctx) (responseFormat, error) {
+	mime, err := negotiateFormat(c)
+	if err != nil {
+		return responseFormat{}, err
+	}
+
+	format := responseFormat{mime: mime}
+	if fields := c.Query("fields"); fields != "" && mime != mimeProtobuf {
+		if format.fields, err = parseFields(fields); err != nil {
+			return responseFormat{}, err
+		}
+	}
+	return format, nil
+}
+
+// sendScorecard writes the scorecard in the parsed format. Protobuf carries only the
+// model.Scorecard fields; JSON honours a ?fields= projection.
+func sendScorecard(c *fiber.Ctx, format responseFormat, scorecard *scorecardResponse) error {
+	if format.mime != mimeProtobuf {
+		if format.fields != nil {
+			data, err := projectFields(scorecard, format.fields)
+			if err != nil {
+				return err
+			}
+			c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			return c.Send(data)
+		}
+		return c.JSON(scorecard)
+	}
+
+	data, err := proto.Marshal(toProto(&scorecard.Scorecard))
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, mimeProtobuf)
+	return c.Send(data)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAcceptNegotiation(t *testing.T) {
+	app := newTestApp(t, nil)
+	raw := resultJSON("github.com/a/b", sha(1), 5, nil)
+
+	for _, tt := range []struct {
+		name   string
+		query  string
+		accept string
+		status int
+		mime   string
+	}{
+		{"no Accept", "", "", fiber.StatusOK, fiber.MIMEApplicationJSON},
+		{"any type", "", "*/*", fiber.StatusOK, fiber.MIMEApplicationJSON},
+		{"protobuf", "", mimeProtobuf, fiber.StatusOK, mimeProtobuf},
+		{"json", "", fiber.MIMEApplicationJSON, fiber.StatusOK, fiber.MIMEApplicationJSON},
+		{"higher q wins", "", "application/json;q=0.5, application/x-protobuf;q=0.9", fiber.StatusOK, mimeProtobuf},
+		{"q over order", "", "application/x-protobuf;q=0.2, application/json", fiber.StatusOK, fiber.MIMEApplicationJSON},
+		{"unsupported then supported", "", "text/csv, application/json;q=0.1", fiber.StatusOK, fiber.MIMEApplicationJSON},
+		{"refused type", "", "application/json;q=0, application/x-protobuf", fiber.StatusOK, mimeProtobuf},
+		{"only unsupported", "", "text/csv, application/xml", fiber.StatusNotAcceptable, ""},
+		{"format overrides Accept", "?format=protobuf", fiber.MIMEApplicationJSON, fiber.StatusOK, mimeProtobuf},
+		{"format in any case", "?format=JSON", mimeProtobuf, fiber.StatusOK, fiber.MIMEApplicationJSON},
+		{"format overrides an unsupported Accept", "?format=json", "text/csv", fiber.StatusOK, fiber.MIMEApplicationJSON},
+		{"unknown format", "?format=xml", "", fiber.StatusBadRequest, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodPost, "/msapi/scorecard/map"+tt.query, strings.NewReader(raw))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			if tt.accept != "" {
+				req.Header.Set(fiber.HeaderAccept, tt.accept)
+			}
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status %d, want %d", resp.StatusCode, tt.status)
+			}
+			if mime := resp.Header.Get(fiber.HeaderContentType); tt.mime != "" && !strings.HasPrefix(mime, tt.mime) {
+				t.Errorf("content type %s, want %s", mime, tt.mime)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// repoTransform is one named step of repo url normalization. caseFold marks the
+// lowercasing steps that PRESERVE_CASE=true turns off.
+type repoTransform struct {
+	name     string
+	apply    func(string) string
+	caseFold bool
+}
+
+// schemePrefixes are stripped from the front of a repo url, in order
+var schemePrefixes = []string{"git+ssh://git@", "git+https://", "http://", "https://", "git://", "git:", "git+"}
+
+// repoTransforms are applied in order by normalizeRepoURL. gitlab_route drops GitLab's
+// /-/ pages (tree, blob, merge_requests, ...) that follow the project path and azure_devops
+// brings the Azure DevOps url forms to dev.azure.com/org/project/repo.
+var repoTransforms = []repoTransform{
+	{"whitespace", strings.TrimSpace, false},
+	{"fragment", func(s string) string { s, _, _ = strings.Cut(s, "#"); return s }, false},
+	{"query", func(s string) string { s, _, _ = strings.Cut(s, "?"); return s }, false},
+	{"scheme", func(s string) string {
+		for _, prefix := range schemePrefixes {
+			s = strings.TrimPrefix(s, prefix)
+		}
+		return s
+	}, false},
+	{"www", func(s string) string {
+		// before the host is lowercased, so WWW.GitHub.com is still recognised as GitHub
+		if len(s) >= 4 && strings.EqualFold(s[:4], "www.") {
+			return s[4:]
+		}
+		return s
+	}, false},
+	{"trailing_slash", func(s string) string { return strings.TrimRight(s, "/") }, false},
+	{"git_suffix", func(s string) string { return strings.TrimSuffix(s, ".git") }, false},
+	{"gitlab_route", func(s string) string {
+		if isGitLabRepo(strings.ToLower(s)) {
+			s, _, _ = strings.Cut(s, "/-/")
+		}
+		return s
+	}, false},
+	{"azure_devops", normalizeAzureDevOps, false},
+	{"lowercase_host", func(s string) string {
+		host, path, found := strings.Cut(s, "/")
+		if !found {
+			return strings.ToLower(host)
+		}
+		return strings.ToLower(host) + "/" + path
+	}, true},
+	{"lowercase_github_path", func(s string) string {
+		if isGitHubRepo(s) {
+			return strings.ToLower(s)
+		}
+		return s
+	}, true},
+}
+
+// normalizeRepoURL runs every transform and reports the names of the ones that changed the url
+func normalizeRepoURL(repoURL string) (string, []string) {
+	applied := []string{}
+	for _, t := range repoTransforms {
+		if t.caseFold && config.PreserveCase {
+			continue
+		}
+		if next := t.apply(repoURL); next != repoURL {
+			repoURL = next
+			applied = append(applied, t.name)
+		}
+	}
+	return repoURL, applied
+}
+
+// cleanRepoURL strips the scheme, fragment, www. prefix, trailing slashes and .git suffix
+// from a repo url and normalizes case. The host is always lowercased. GitHub treats
+// owner/repo as case-insensitive so those segments are lowercased too; GitLab paths are
+// case-sensitive and are left untouched. PRESERVE_CASE=true skips all case changes.
+// REPO_REWRITE_RULES are applied to the normalized url, so lookups can target a mirror.
+func cleanRepoURL(repoURL string) string {
+	normalized, _ := normalizeRepoURL(repoURL)
+	return rewriteRepoURL(normalized)
+}
+
+// normalizedURL describes what cleanRepoURL does to an input
+type normalizedURL struct {
+	Input      string   `json:"input"`
+	Normalized string   `json:"normalized"`
+	Host       string   `json:"host"`
+	Stripped   []string `json:"stripped"`
+	Rewritten  string   `json:"rewritten,omitempty"`
+}
+
+// getNormalizedURL godoc
+// @Summary Normalize a repo url
+// @Description Show how a repo url is normalized before lookup, which transformations were applied
+// @Description and, when a REPO_REWRITE_RULES rule matches, the url the lookup is rewritten to
+// @Tags scorecard
+// @Produce json
+// @Param url query string true "raw repo url"
+// @Success 200 {object} normalizedURL
+// @Failure 400
+// @Router /msapi/scorecard/normalize [get]
+func getNormalizedURL(c *fiber.Ctx) error {
+	input := c.Query("url")
+	if input == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "url is required")
+	}
+
+	normalized, applied := normalizeRepoURL(input)
+	host, _, _ := strings.Cut(normalized, "/")
+	resp := normalizedURL{Input: input, Normalized: normalized, Host: host, Stripped: applied}
+	if rewritten := rewriteRepoURL(normalized); rewritten != normalized {
+		resp.Rewritten = rewritten
+	}
+	return c.JSON(resp)
+}
+
+// isGitLabRepo reports whether a normalized repo url is on gitlab.com or a GITLAB_HOSTS host
+func isGitLabRepo(repoURL string) bool {
+	host, _, _ := strings.Cut(repoURL, "/")
+	for _, gitlab := range config.GitLabHosts {
+		if host == gitlab {
+			return true
+		}
+	}
+	return false
+}
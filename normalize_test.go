@@ -0,0 +1,132 @@
+package main
+
+import (
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCleanRepoURLFoldsGitHubCaseOnly(t *testing.T) {
+	newTestApp(t, nil)
+
+	for _, tt := range []struct {
+		input, want string
+	}{
+		{"github.com/Foo/Bar", "github.com/foo/bar"},
+		{"GitHub.com/foo/bar", "github.com/foo/bar"},
+		{"https://WWW.GitHub.com/Ortelius/SCEC-Scorecard.git", "github.com/ortelius/scec-scorecard"},
+		// GitLab paths are case-sensitive, only the host is folded
+		{"gitlab.com/Group/SubGroup/Project", "gitlab.com/Group/SubGroup/Project"},
+		{"GitLab.com/Group/Project", "gitlab.com/Group/Project"},
+	} {
+		if got := cleanRepoURL(tt.input); got != tt.want {
+			t.Errorf("cleanRepoURL(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestMixedCaseGitHubReposShareAScorecard(t *testing.T) {
+	api := newFakeAPI(t)
+	api.serve("github.com/foo/bar", resultJSON("github.com/foo/bar", sha(1), 8, nil))
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL})
+
+	for _, repo := range []string{"github.com/Foo/Bar", "github.com/foo/bar", "GITHUB.COM/FOO/BAR"} {
+		if status, body := get(t, app, "/msapi/scorecard/"+repo); status != fiber.StatusOK {
+			t.Errorf("%s: status %d, body %s", repo, status, body)
+		}
+	}
+	if n := api.called("github.com/foo/bar"); n != 1 {
+		t.Errorf("API called %d times, want the casings to share one cached scorecard", n)
+	}
+}
+
+func TestNormalizeEchoesTheInput(t *testing.T) {
+	app := newTestApp(t, nil)
+
+	status, body := get(t, app, "/msapi/scorecard/normalize?url="+url.QueryEscape("github.com/Foo/Bar"))
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d", status)
+	}
+	var got normalizedURL
+	mustJSON(t, body, &got)
+	if got.Input != "github.com/Foo/Bar" || got.Normalized != "github.com/foo/bar" {
+		t.Errorf("normalize %+v", got)
+	}
+}
+
+func TestNormalizeListsEachTransformation(t *testing.T) {
+	app := newTestApp(t, map[string]string{"REPO_REWRITE_RULES": `^github\.com/old/(.*) => github.com/new/$1`})
+
+	for _, tt := range []struct {
+		name, input, normalized, host string
+		stripped                      []string
+	}{
+		{"already clean", "github.com/a/b", "github.com/a/b", "github.com", []string{}},
+		{"whitespace", "  github.com/a/b ", "github.com/a/b", "github.com", []string{"whitespace"}},
+		{"fragment", "github.com/a/b#readme", "github.com/a/b", "github.com", []string{"fragment"}},
+		{"query", "github.com/a/b?tab=readme", "github.com/a/b", "github.com", []string{"query"}},
+		{"scheme", "git+https://github.com/a/b", "github.com/a/b", "github.com", []string{"scheme"}},
+		{"www", "www.github.com/a/b", "github.com/a/b", "github.com", []string{"www"}},
+		{"trailing slash", "github.com/a/b//", "github.com/a/b", "github.com", []string{"trailing_slash"}},
+		{"git suffix", "github.com/a/b.git", "github.com/a/b", "github.com", []string{"git_suffix"}},
+		{"gitlab route", "gitlab.com/g/sub/p/-/tree/main", "gitlab.com/g/sub/p", "gitlab.com", []string{"gitlab_route"}},
+		{"azure devops", "org@dev.azure.com/org/proj/_git/repo", "dev.azure.com/org/proj/repo", "dev.azure.com", []string{"azure_devops"}},
+		{"lowercase host", "GitLab.com/G/P", "gitlab.com/G/P", "gitlab.com", []string{"lowercase_host"}},
+		{"lowercase github path", "github.com/A/B", "github.com/a/b", "github.com", []string{"lowercase_github_path"}},
+		{"in order", "https://WWW.GitHub.com/A/B.git/#x", "github.com/a/b", "github.com",
+			[]string{"fragment", "scheme", "www", "trailing_slash", "git_suffix", "lowercase_host", "lowercase_github_path"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := get(t, app, "/msapi/scorecard/normalize?url="+url.QueryEscape(tt.input))
+			if status != fiber.StatusOK {
+				t.Fatalf("status %d, body %s", status, body)
+			}
+			var got normalizedURL
+			mustJSON(t, body, &got)
+			if got.Input != tt.input || got.Normalized != tt.normalized || got.Host != tt.host || got.Rewritten != "" {
+				t.Errorf("normalize %+v, want %s on %s", got, tt.normalized, tt.host)
+			}
+			if !slices.Equal(got.Stripped, tt.stripped) {
+				t.Errorf("stripped %q, want %q", got.Stripped, tt.stripped)
+			}
+		})
+	}
+
+	var got normalizedURL
+	_, body := get(t, app, "/msapi/scorecard/normalize?url="+url.QueryEscape("https://github.com/old/b"))
+	mustJSON(t, body, &got)
+	if got.Normalized != "github.com/old/b" || got.Rewritten != "github.com/new/b" {
+		t.Errorf("rewrite %+v", got)
+	}
+	if status, _ := get(t, app, "/msapi/scorecard/normalize"); status != fiber.StatusBadRequest {
+		t.Errorf("without url: status %d, want 400", status)
+	}
+}
+
+func TestPreserveCase(t *testing.T) {
+	const input = "https://GitHub.com/Foo/Bar.git"
+	for _, tt := range []struct {
+		preserve, want string
+	}{
+		{"", "github.com/foo/bar"},
+		{"false", "github.com/foo/bar"},
+		{"true", "GitHub.com/Foo/Bar"},
+	} {
+		newTestApp(t, map[string]string{"PRESERVE_CASE": tt.preserve})
+		if got := cleanRepoURL(input); got != tt.want {
+			t.Errorf("PRESERVE_CASE=%q: cleanRepoURL(%q) = %q, want %q", tt.preserve, input, got, tt.want)
+		}
+	}
+
+	api := newFakeAPI(t)
+	api.serve("github.com/Foo/Bar", resultJSON("github.com/Foo/Bar", sha(1), 8, nil))
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL, "PRESERVE_CASE": "true"})
+	if status, body := get(t, app, "/msapi/scorecard/github.com/Foo/Bar"); status != fiber.StatusOK {
+		t.Errorf("status %d, body %s", status, body)
+	}
+	if n := api.called("github.com/Foo/Bar"); n != 1 {
+		t.Errorf("the verbatim path was asked for %d times", n)
+	}
+}
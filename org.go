@@ -0,0 +1,243 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	ossf "github.com/ossf/scorecard/v5/pkg/scorecard"
+)
+
+var (
+	errOrgNotFound    = errors.New("the org does not exist or is not visible to the token")
+	errOrgNotListable = errors.New("only the orgs of github.com and the GH_HOST instance can be listed")
+)
+
+// orgPageSize is how many repos are asked for per page of an org listing, the GitHub maximum
+const orgPageSize = 100
+
+// scorecardPolicy is what the scorecard of a repo must meet: an aggregate of at least MinScore
+// and, for every check named in Checks, at least its score there. A check the policy names
+// that is missing or inconclusive fails it.
+type scorecardPolicy struct {
+	MinScore *float64       `json:"min_score,omitempty"`
+	Checks   map[string]int `json:"checks,omitempty"`
+}
+
+// violations lists how result falls short of the policy, empty when it meets it
+func (p scorecardPolicy) violations(result *ossf.JSONScorecardResultV2) []string {
+	var failed []string
+	if p.MinScore != nil && float64(result.AggregateScore) < *p.MinScore {
+		failed = append(failed, fmt.Sprintf("aggregate %.1f is below %.1f", result.AggregateScore, *p.MinScore))
+	}
+
+	scores := make(map[string]int, len(result.Checks))
+	for _, check := range result.Checks {
+		scores[check.Name] = check.Score
+	}
+	for name, least := range p.Checks {
+		score, ok := scores[name]
+		switch {
+		case !ok || score < 0:
+			failed = append(failed, name+" has no score")
+		case score < least:
+			failed = append(failed, fmt.Sprintf("%s %d is below %d", name, score, least))
+		}
+	}
+	sort.Strings(failed)
+	return failed
+}
+
+// orgEvaluateRequest is the org, as host/org, and the policy its repos are evaluated against.
+// Limit is how many of its repos are evaluated, BATCH_MAX_ITEMS when zero.
+type orgEvaluateRequest struct {
+	Org    string          `json:"org"`
+	Policy scorecardPolicy `json:"policy"`
+	Limit  int             `json:"limit,omitempty"`
+}
+
+// orgEvaluation is the body returned by the org evaluation endpoint, the repos in the order
+// the forge lists them
+type orgEvaluation struct {
+	Org     string           `json:"org"`
+	Summary orgSummary       `json:"summary"`
+	Repos   []repoEvaluation `json:"repos"`
+}
+
+// orgSummary counts the repos evaluated, those that meet the policy, those that do not and
+// those whose scorecard could not be looked up
+type orgSummary struct {
+	Repos  int `json:"repos"`
+	Passed int `json:"passed"`
+	Failed int `json:"failed"`
+	Errors int `json:"errors"`
+}
+
+// repoEvaluation is the outcome for one repo. Exactly one of Score and Error is set, and a repo
+// with an error does not pass.
+type repoEvaluation struct {
+	Repo       string         `json:"repo"`
+	Score      *float64       `json:"score,omitempty"`
+	Pass       bool           `json:"pass"`
+	Violations []string       `json:"violations,omitempty"`
+	Error      *errorResponse `json:"error,omitempty"`
+}
+
+// evaluateOrg godoc
+// @Summary Evaluate a policy across the repos of an org
+// @Description List the repos of a GitHub org or user, archived ones left out, and evaluate the scorecard
+// @Description of each against the policy: an aggregate of at least min_score and at least the given
+// @Description score in each named check, where a missing or inconclusive check fails. At most limit repos
+// @Description are evaluated, BATCH_MAX_ITEMS by default and at most, looked up BATCH_CONCURRENCY at a
+// @Description time. Requires ADMIN_TOKEN, sent as X-Admin-Token.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body orgEvaluateRequest true "org as host/org, e.g. github.com/ortelius, and the policy"
+// @Param X-Repo-Token header string false "forge token used instead of the configured one to list the org and scan its private repos; Authorization: Bearer also works"
+// @Success 200 {object} orgEvaluation
+// @Failure 400 {object} errorResponse "ORG_NOT_LISTABLE, an invalid org or policy or a limit past BATCH_MAX_ITEMS"
+// @Failure 404 {object} errorResponse "ORG_NOT_FOUND"
+// @Failure 502 {object} errorResponse "UPSTREAM_ERROR"
+// @Failure 503 {object} errorResponse "READ_ONLY"
+// @Router /msapi/scorecard/org/evaluate [post]
+func evaluateOrg(c *fiber.Ctx) error {
+	var body orgEvaluateRequest
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	org, _ := normalizeRepoURL(body.Org)
+	if host, owner, _ := strings.Cut(org, "/"); host == "" || owner == "" || strings.Contains(owner, "/") {
+		return fiber.NewError(fiber.StatusBadRequest, "org must be host/org, e.g. github.com/ortelius")
+	}
+	if body.Policy.MinScore == nil && len(body.Policy.Checks) == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "the policy needs min_score or checks")
+	}
+	limit := body.Limit
+	if limit == 0 {
+		limit = config.BatchMaxItems
+	}
+	if limit < 0 || limit > config.BatchMaxItems {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", config.BatchMaxItems))
+	}
+
+	token := requestToken(c)
+	repos, err := listOrgRepos(org, token, limit)
+	if err != nil {
+		return sendLookupError(c, err)
+	}
+
+	resp := orgEvaluation{Org: org, Repos: make([]repoEvaluation, len(repos))}
+	runConcurrently(len(repos), config.BatchConcurrency, func(i int) {
+		resp.Repos[i] = evaluateRepo(repos[i], token, body.Policy)
+	})
+
+	resp.Summary.Repos = len(repos)
+	for _, repo := range resp.Repos {
+		switch {
+		case repo.Error != nil:
+			resp.Summary.Errors++
+		case repo.Pass:
+			resp.Summary.Passed++
+		default:
+			resp.Summary.Failed++
+		}
+	}
+	return c.JSON(resp)
+}
+
+// evaluateRepo looks up the scorecard of a repo as a batch item is and evaluates it against policy
+func evaluateRepo(repo, token string, policy scorecardPolicy) repoEvaluation {
+	out := repoEvaluation{Repo: repo}
+	result, _, err := coalescedLookup(lookupRequest{repo: repo, prefer: config.PreferSource, token: token})
+	if err != nil {
+		_, code := lookupErrorStatus(err)
+		out.Error = &errorResponse{Code: code, Message: err.Error()}
+		return out
+	}
+
+	recordScored(repo)
+	score := float64(result.AggregateScore)
+	out.Score = &score
+	out.Violations = policy.violations(result)
+	out.Pass = len(out.Violations) == 0
+	return out
+}
+
+// listOrgRepos lists the repos of a GitHub org, or of a user when no org has the name, as
+// normalized repo urls, at most limit of them. Only the GH_HOST instance gets a token.
+func listOrgRepos(org, token string, limit int) ([]string, error) {
+	if !isGitHubRepo(org + "/repo") {
+		return nil, errOrgNotListable
+	}
+	if readOnly.Load() {
+		return nil, errReadOnly
+	}
+
+	callerToken := token != ""
+	apiURL := defaultGitHubAPIURL
+	if onConfiguredGitHub(org) {
+		apiURL = config.GitHubAPIURL
+		if token == "" {
+			token = config.GitHubToken
+		}
+	} else {
+		token, callerToken = "", false
+	}
+	host, owner, _ := strings.Cut(org, "/")
+
+	repos, err := listGitHubRepos(apiURL+"/orgs/"+url.PathEscape(owner)+"/repos", host, token, callerToken, limit)
+	if errors.Is(err, errOrgNotFound) {
+		repos, err = listGitHubRepos(apiURL+"/users/"+url.PathEscape(owner)+"/repos", host, token, callerToken, limit)
+	}
+	return repos, err
+}
+
+// listGitHubRepos reads the repos of a GitHub listing page by page until it ends or limit
+// repos are found, leaving out the archived ones
+func listGitHubRepos(listURL, host, token string, callerToken bool, limit int) ([]string, error) {
+	var repos []string
+	for page := 1; len(repos) < limit; page++ {
+		var listed []struct {
+			FullName string `json:"full_name"`
+			Archived bool   `json:"archived"`
+		}
+		req := client.R().SetResult(&listed).SetQueryParams(map[string]string{
+			"per_page": strconv.Itoa(orgPageSize),
+			"page":     strconv.Itoa(page),
+			"sort":     "full_name",
+		})
+		if token != "" {
+			req.SetAuthToken(token)
+		}
+
+		release := outbound.acquire()
+		resp, err := req.Get(listURL)
+		release()
+		if err != nil {
+			return nil, upstreamError(fmt.Errorf("listing the org: %w", err))
+		}
+		switch status := resp.StatusCode(); {
+		case status == fiber.StatusNotFound:
+			return nil, errOrgNotFound
+		case status == fiber.StatusUnauthorized && callerToken:
+			return nil, errRequestTokenInvalid
+		case status != fiber.StatusOK:
+			return nil, fmt.Errorf("%w: listing the org returned %s", errUpstream, resp.Status())
+		}
+
+		for _, repo := range listed {
+			if !repo.Archived && len(repos) < limit {
+				repos = append(repos, cleanRepoURL(host+"/"+repo.FullName))
+			}
+		}
+		if len(listed) < orgPageSize {
+			break
+		}
+	}
+	return repos, nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// postEvaluate asks app to evaluate body with the admin token
+func postEvaluate(t *testing.T, app *fiber.App, body string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodPost, "/msapi/scorecard/org/evaluate", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set("X-Admin-Token", "admin")
+	return doRequest(t, app, req)
+}
+
+func TestEvaluateOrg(t *testing.T) {
+	github := newFakeAPI(t)
+	github.serve("orgs/o/repos?page=1&per_page=100&sort=full_name", `[
+		{"full_name":"o/archived","archived":true},
+		{"full_name":"o/pass"},
+		{"full_name":"o/low"},
+		{"full_name":"o/inconclusive"},
+		{"full_name":"o/unscored"}
+	]`)
+	api := newFakeAPI(t)
+	api.serve("github.com/o/pass", resultJSON("github.com/o/pass", sha(1), 8, map[string]int{"Code-Review": 9}))
+	api.serve("github.com/o/low", resultJSON("github.com/o/low", sha(2), 4, map[string]int{"Code-Review": 9}))
+	api.serve("github.com/o/inconclusive", resultJSON("github.com/o/inconclusive", sha(3), 9, map[string]int{"Code-Review": -1}))
+	app := newTestApp(t, map[string]string{
+		"SCORECARD_API_URLS": api.URL,
+		"GITHUB_API_URL":     github.URL,
+		"ADMIN_TOKEN":        "admin",
+		"BATCH_CONCURRENCY":  "2",
+	})
+
+	policy := `"policy":{"min_score":5,"checks":{"Code-Review":5}}`
+	status, body := postEvaluate(t, app, `{"org":"https://github.com/O",`+policy+`}`)
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d, body %s", status, body)
+	}
+	var resp orgEvaluation
+	mustJSON(t, body, &resp)
+	if resp.Org != "github.com/o" || resp.Summary != (orgSummary{Repos: 4, Passed: 1, Failed: 2, Errors: 1}) {
+		t.Errorf("org %q, summary %+v", resp.Org, resp.Summary)
+	}
+	var repos, passed []string
+	for _, repo := range resp.Repos {
+		repos = append(repos, strings.TrimPrefix(repo.Repo, "github.com/o/"))
+		if repo.Pass {
+			passed = append(passed, repo.Repo)
+		}
+	}
+	if !slices.Equal(repos, []string{"pass", "low", "inconclusive", "unscored"}) || !slices.Equal(passed, []string{"github.com/o/pass"}) {
+		t.Errorf("repos %v, passed %v", repos, passed)
+	}
+	if v := resp.Repos[1].Violations; len(v) != 1 || !strings.HasPrefix(v[0], "aggregate 4.0") {
+		t.Errorf("low: violations %v", v)
+	}
+	if v := resp.Repos[2].Violations; len(v) != 1 || v[0] != "Code-Review has no score" {
+		t.Errorf("inconclusive: violations %v", v)
+	}
+	if e := resp.Repos[3].Error; e == nil || e.Code != "NO_SCORECARD" {
+		t.Errorf("unscored: error %+v", e)
+	}
+
+	_, body = postEvaluate(t, app, `{"org":"github.com/o","limit":2,`+policy+`}`)
+	resp = orgEvaluation{}
+	mustJSON(t, body, &resp)
+	if resp.Summary != (orgSummary{Repos: 2, Passed: 1, Failed: 1}) {
+		t.Errorf("limit 2: summary %+v", resp.Summary)
+	}
+}
+
+func TestEvaluateOrgListsAUser(t *testing.T) {
+	github := newFakeAPI(t)
+	github.serve("users/u/repos?page=1&per_page=100&sort=full_name", `[{"full_name":"u/r"}]`)
+	api := newFakeAPI(t)
+	api.serve("github.com/u/r", resultJSON("github.com/u/r", sha(1), 6, nil))
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL, "GITHUB_API_URL": github.URL, "ADMIN_TOKEN": "admin"})
+
+	_, body := postEvaluate(t, app, `{"org":"github.com/u","policy":{"min_score":5}}`)
+	var resp orgEvaluation
+	mustJSON(t, body, &resp)
+	if resp.Summary != (orgSummary{Repos: 1, Passed: 1}) {
+		t.Errorf("summary %+v in %s", resp.Summary, body)
+	}
+
+	status, body := postEvaluate(t, app, `{"org":"github.com/nobody","policy":{"min_score":5}}`)
+	if status != fiber.StatusNotFound || !strings.Contains(body, "ORG_NOT_FOUND") {
+		t.Errorf("an unknown org: status %d, body %s", status, body)
+	}
+}
+
+func TestEvaluateOrgRejects(t *testing.T) {
+	app := newTestApp(t, map[string]string{"GITHUB_API_URL": newFakeAPI(t).URL, "ADMIN_TOKEN": "admin", "BATCH_MAX_ITEMS": "10"})
+
+	for _, tt := range []struct {
+		body   string
+		status int
+	}{
+		{`{"org":"github.com/o"}`, fiber.StatusBadRequest},
+		{`{"org":"github.com/o/r","policy":{"min_score":5}}`, fiber.StatusBadRequest},
+		{`{"org":"github.com/o","limit":11,"policy":{"min_score":5}}`, fiber.StatusBadRequest},
+		{`{"org":"bitbucket.org/o","policy":{"min_score":5}}`, fiber.StatusBadRequest},
+	} {
+		if status, body := postEvaluate(t, app, tt.body); status != tt.status {
+			t.Errorf("%s: status %d, body %s", tt.body, status, body)
+		}
+	}
+
+	req := httptest.NewRequest(fiber.MethodPost, "/msapi/scorecard/org/evaluate", strings.NewReader(`{"org":"github.com/o","policy":{"min_score":5}}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if status, _ := doRequest(t, app, req); status != fiber.StatusUnauthorized {
+		t.Errorf("without the admin token: status %d", status)
+	}
+}
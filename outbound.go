@@ -0,0 +1,84 @@
+package main
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"golang.org/x/sync/singleflight"
+)
+
+// newClient is the HTTP client of every outbound call but the clones of a scan. An attempt
+// gets OUTBOUND_CONNECT_TIMEOUT to connect and OUTBOUND_TIMEOUT in all, and one that fails
+// to connect, is rate limited or answers with a server error is tried again OUTBOUND_RETRIES
+// times, waiting OUTBOUND_RETRY_BACKOFF and then twice as long each time, with jitter.
+// After a 429 every call to that host waits out its Retry-After, up to OUTBOUND_MAX_THROTTLE_WAIT.
+// Batches reuse connections: OUTBOUND_MAX_IDLE_CONNS_PER_HOST stay open to each upstream for
+// OUTBOUND_IDLE_CONN_TIMEOUT, over HTTP/2 unless OUTBOUND_DISABLE_HTTP2 is set. Upstream hosts
+// are resolved once per OUTBOUND_DNS_TTL, or never for those of OUTBOUND_STATIC_HOSTS.
+func newClient(cfg *Config) *resty.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := &net.Dialer{Timeout: cfg.OutboundConnectTimeout, KeepAlive: 30 * time.Second}
+	transport.DialContext = newDNSCache(cfg.OutboundDNSTTL, cfg.OutboundStaticHosts, dialer, time.Now).dialContext
+	transport.TLSHandshakeTimeout = cfg.OutboundConnectTimeout
+	transport.MaxIdleConns = 0 // bounded per host instead
+	transport.MaxIdleConnsPerHost = cfg.OutboundMaxIdlePerHost
+	transport.IdleConnTimeout = cfg.OutboundIdleTimeout
+	if cfg.OutboundDisableHTTP2 {
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	throttled := newThrottle(cfg.OutboundMaxThrottleWait, time.Now)
+	return resty.New().
+		SetTransport(transport).
+		SetTimeout(cfg.OutboundTimeout).
+		SetRetryCount(cfg.OutboundRetries).
+		SetRetryWaitTime(cfg.OutboundRetryBackoff).
+		SetRetryMaxWaitTime(cfg.OutboundRetryBackoff << cfg.OutboundRetries).
+		AddRetryCondition(func(resp *resty.Response, err error) bool {
+			// a condition replaces resty's own retry of failed connections, so it is repeated here
+			status := resp.StatusCode()
+			return err != nil || status == http.StatusTooManyRequests ||
+				(status >= http.StatusInternalServerError && status != http.StatusNotImplemented)
+		}).
+		OnBeforeRequest(throttled.beforeRequest).
+		OnAfterResponse(throttled.afterResponse)
+}
+
+// outboundLimiter caps the calls to the OpenSSF API and the GitHub API that may run at once,
+// across every endpoint. Scans are left to SCAN_CONCURRENCY. A nil slots channel means no limit.
+type outboundLimiter struct {
+	slots chan struct{}
+}
+
+func newOutboundLimiter(limit int) *outboundLimiter {
+	if limit <= 0 {
+		return &outboundLimiter{}
+	}
+	return &outboundLimiter{slots: make(chan struct{}, limit)}
+}
+
+// acquire blocks until a slot is free and returns the function that frees it
+func (l *outboundLimiter) acquire() func() {
+	if l.slots == nil {
+		return func() {}
+	}
+	l.slots <- struct{}{}
+	return func() { <-l.slots }
+}
+
+// outbound is rebuilt by setupRoutes with GLOBAL_OUTBOUND_CONCURRENCY
+var outbound = newOutboundLimiter(config.GlobalOutboundConcurrency)
+
+// resolutions shares the resolution of refs, Go module paths and packages between identical
+// calls in flight, as the coalescer does for lookups. Calls with a caller's token are not shared.
+var resolutions singleflight.Group
+
+// shared runs fn for key, or waits for the identical call in flight and returns its outcome
+func shared[T any](key string, fn func() (T, error)) (T, error) {
+	v, err, _ := resolutions.Do(key, func() (any, error) { return fn() })
+	return v.(T), err
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestGlobalOutboundConcurrencyCapsEveryEndpoint(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(30 * time.Millisecond)
+		repo := strings.TrimPrefix(r.URL.Path, "/")
+		w.Header().Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		_, _ = io.WriteString(w, resultJSON(repo, sha(1), 5, nil))
+	}))
+	t.Cleanup(api.Close)
+	app := newTestApp(t, map[string]string{
+		"SCORECARD_API_URLS":          api.URL,
+		"GLOBAL_OUTBOUND_CONCURRENCY": "2",
+		"BATCH_CONCURRENCY":           "6",
+		"SCORECARD_MAX_CONCURRENCY":   "6",
+	})
+
+	var items []batchItem
+	for i := 1; i <= 6; i++ {
+		items = append(items, batchItem{Repo: fmt.Sprintf("github.com/batch/r%d", i)})
+	}
+	batch, _ := json.Marshal(items)
+	var wg sync.WaitGroup
+	for i := 0; i <= 6; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var status int
+			if i == 0 {
+				req := httptest.NewRequest(fiber.MethodPost, "/msapi/scorecard/batch", strings.NewReader(string(batch)))
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+				status, _ = doRequest(t, app, req)
+			} else {
+				status, _ = get(t, app, fmt.Sprintf("/msapi/scorecard/github.com/single/r%d", i))
+			}
+			if status != fiber.StatusOK {
+				t.Errorf("request %d: status %d", i, status)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if n := peak.Load(); n != 2 {
+		t.Errorf("%d upstream calls at once, want the cap of 2 reached and held", n)
+	}
+
+	for i := 0; i < 2; i++ {
+		defer outbound.acquire()()
+	}
+	done := make(chan int, 1)
+	go func() {
+		status, _ := get(t, app, "/msapi/scorecard/github.com/single/r1")
+		done <- status
+	}()
+	select {
+	case status := <-done:
+		if status != fiber.StatusOK {
+			t.Errorf("cached lookup: status %d", status)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("a cached lookup waited for an outbound slot")
+	}
+}
+
+func TestScansTakeNoOutboundSlot(t *testing.T) {
+	stubScan(t, sha(1), map[string]int{"License": 10}, nil)
+	github := newFakeAPI(t)
+	app := newTestApp(t, map[string]string{
+		"SCORECARD_API_URLS":          newFakeAPI(t).URL,
+		"GITHUB_API_URL":              github.URL,
+		"GITHUB_TOKEN":                "token",
+		"GLOBAL_OUTBOUND_CONCURRENCY": "1",
+	})
+	defer outbound.acquire()()
+
+	done := make(chan int, 1)
+	go func() {
+		status, _ := get(t, app, "/msapi/scorecard/github.com/a/b?prefer=cli&commit="+sha(1))
+		done <- status
+	}()
+	select {
+	case status := <-done:
+		if status != fiber.StatusOK {
+			t.Errorf("scan: status %d", status)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("a scan waited for an outbound slot")
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var errInvalidPackage = errors.New("package must be ecosystem/name with ecosystem npm, pypi, maven or golang; maven names are group:artifact")
+
+// ecosystemTypes maps the ecosystem names accepted by the package endpoint to purl types
+var ecosystemTypes = map[string]string{
+	"npm":    "npm",
+	"pypi":   "pypi",
+	"maven":  "maven",
+	"golang": "golang",
+	"go":     "golang",
+}
+
+// ecosystemPackage is the purl an ecosystem package name stands for, e.g. maven
+// com.fasterxml.jackson.core:jackson-databind or npm @types/node
+func ecosystemPackage(ecosystem, name, version string) (packageURL, error) {
+	purlType, ok := ecosystemTypes[strings.ToLower(ecosystem)]
+	name = strings.Trim(name, "/")
+	if !ok || name == "" {
+		return packageURL{}, errInvalidPackage
+	}
+
+	p := packageURL{Type: purlType, Name: name, Version: version}
+	switch purlType {
+	case "maven":
+		var found bool
+		if p.Namespace, p.Name, found = strings.Cut(name, ":"); !found || p.Namespace == "" || p.Name == "" {
+			return packageURL{}, errInvalidPackage
+		}
+	case "npm", "golang":
+		if slash := strings.LastIndex(name, "/"); slash >= 0 {
+			p.Namespace, p.Name = name[:slash], name[slash+1:]
+		}
+	}
+	return p, nil
+}
+
+// getPackageScorecard godoc
+// @Summary Get the OSSF scorecard for an ecosystem package
+// @Description Resolve a package name to its source repo through deps.dev and return that repo's
+// @Description scorecard, e.g. npm/lodash, pypi/requests or maven/com.fasterxml.jackson.core:jackson-databind.
+// @Description Without a version the package's default version is resolved. The resolved repo is
+// @Description returned in the X-Resolved-Repo header.
+// @Tags scorecard
+// @Produce json
+// @Param ecosystem path string true "package ecosystem" Enums(npm, pypi, maven, golang)
+// @Param name path string true "package name, scope or module path included"
+// @Param version query string false "package version to resolve"
+// @Param format query string false "response format overriding the Accept header" Enums(json, protobuf)
+// @Param verbose query bool false "include per-check scores, reasons and documentation links"
+// @Param details query bool false "list the checks as verbose does, each with its details"
+// @Param aggregate_present query bool false "add aggregate_present, the equal-weighted mean of the checks that ran"
+// @Param fields query string false "comma separated fields to return, in order, in any case, e.g. score,pinned,branchprotection"
+// @Param provenance query bool false "add meta with the scored repo, commit, analysis date and scorecard version"
+// @Param include_grade query bool false "add grade, the aggregate as a letter on the GRADE_THRESHOLDS scale or N/A without an aggregate"
+// @Param risk query string false "list only the checks in these comma separated risk tiers, e.g. Critical,High"
+// @Param include_unmapped query bool false "add unmapped_checks, the scores of checks with no scorecard field; defaults to INCLUDE_UNMAPPED_CHECKS"
+// @Param prefer query string false "which source to try first; defaults to PREFER_SOURCE or api" Enums(api, cli)
+// @Param refresh query bool false "skip the cache and the lookups shared within COALESCE_WINDOW_MS and fetch the scorecard again"
+// @Success 200 {object} scorecardResponse
+// @Success 202 {object} processingResponse "scorecard still being computed"
+// @Success 304 "the scorecard If-None-Match or If-Modified-Since describes is still current"
+// @Failure 400 {object} errorResponse "INVALID_PACKAGE"
+// @Failure 404 {object} errorResponse "PACKAGE_NOT_FOUND, NO_SOURCE_REPO or any repo lookup 404"
+// @Failure 502 {object} errorResponse "UPSTREAM_ERROR"
+// @Failure 503 {object} errorResponse "READ_ONLY"
+// @Router /msapi/scorecard/package/{ecosystem}/{name} [get]
+func getPackageScorecard(c *fiber.Ctx) error {
+	prefer, err := preference(c)
+	if err != nil {
+		return err
+	}
+
+	p, err := ecosystemPackage(c.Params("ecosystem"), c.Params("*"), c.Query("version"))
+	if err != nil {
+		return sendLookupError(c, err)
+	}
+
+	githubURL, commitSha, err := resolvePackage(p, requestToken(c))
+	if err != nil {
+		return sendLookupError(c, err)
+	}
+	if err := validateRepoURL(githubURL); err != nil {
+		return sendLookupError(c, err)
+	}
+
+	c.Set("X-Resolved-Repo", githubURL)
+	return serveScorecard(c, githubURL, commitSha, prefer)
+}
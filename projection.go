@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// projectionKeys maps every field a client may name in ?fields= to its JSON key. Names are
+// matched by projectionName, so the Go field name (BranchProtection), the JSON key
+// (branch_protection) and any casing of either (branchprotection) are accepted.
+var projectionKeys = buildProjectionKeys(reflect.TypeOf(scorecardResponse{}))
+
+func buildProjectionKeys(t reflect.Type) map[string]string {
+	keys := make(map[string]string)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			for name, key := range buildProjectionKeys(field.Type) {
+				keys[name] = key
+			}
+			continue
+		}
+
+		key, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		keys[projectionName(field.Name)] = key
+		keys[projectionName(key)] = key
+	}
+	return keys
+}
+
+// projectionName folds a field name to lower case without underscores or dashes
+func projectionName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, name)
+}
+
+// parseFields resolves the comma separated ?fields= names to JSON keys, in the order given.
+// Unknown field names are rejected with a 400 and repeated fields are kept once.
+func parseFields(fields string) ([]string, error) {
+	keys := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(fields, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		key, ok := projectionKeys[projectionName(name)]
+		if !ok {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "unknown field: "+name)
+		}
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// projectFields renders only the keys from parseFields, in their order
+func projectFields(body any, keys []string) ([]byte, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	out.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+
+		quoted, _ := json.Marshal(key)
+		out.Write(quoted)
+		out.WriteByte(':')
+
+		if value, ok := values[key]; ok {
+			out.Write(value)
+		} else {
+			out.WriteString("null")
+		}
+	}
+	out.WriteByte('}')
+	return out.Bytes(), nil
+}
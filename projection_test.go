@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestFieldsProjection(t *testing.T) {
+	app := newTestApp(t, nil)
+	raw := resultJSON("github.com/a/b", sha(1), 6.5, map[string]int{"Branch-Protection": 3, "Token-Permissions": 9})
+
+	for _, tt := range []struct {
+		name   string
+		fields string
+		status int
+		body   string
+	}{
+		{"in the order asked", "Score,BranchProtection,TokenPermissions", fiber.StatusOK,
+			`{"score":6.5,"branch_protection":3,"token_permissions":9}`},
+		{"json keys and any case", "token_permissions, SCORE ,branchprotection", fiber.StatusOK,
+			`{"token_permissions":9,"score":6.5,"branch_protection":3}`},
+		{"repeated once", "score,Score,score", fiber.StatusOK, `{"score":6.5}`},
+		{"an absent extra is null", "score,grade", fiber.StatusOK, `{"score":6.5,"grade":null}`},
+		{"unknown field", "score,stars", fiber.StatusBadRequest, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := postMap(t, app, "?fields="+url.QueryEscape(tt.fields), raw)
+			if status != tt.status {
+				t.Fatalf("status %d, body %s", status, body)
+			}
+			if tt.body != "" && body != tt.body {
+				t.Errorf("body %s, want %s", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestFieldsProjectionIncludesRequestedExtras(t *testing.T) {
+	app := newTestApp(t, nil)
+	raw := resultJSON("github.com/a/b", sha(1), 9.5, nil)
+
+	_, body := postMap(t, app, "?include_grade=true&fields=grade,score", raw)
+	if body != `{"grade":"A","score":9.5}` {
+		t.Errorf("body %s", body)
+	}
+}
+
+func TestUnknownFieldIsRejectedBeforeTheLookup(t *testing.T) {
+	api := newFakeAPI(t)
+	api.serve("github.com/a/b", resultJSON("github.com/a/b", sha(1), 6.5, nil))
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL})
+
+	if status, body := get(t, app, "/msapi/scorecard/github.com/a/b?fields=score,stars"); status != fiber.StatusBadRequest {
+		t.Fatalf("status %d, body %s", status, body)
+	}
+	if n := api.called("github.com/a/b"); n != 0 {
+		t.Errorf("upstream called %d times for a bad ?fields=", n)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"github.com/ortelius/scec-commons/model"
+	"github.com/ortelius/scec-scorecard/scorecardpb"
+)
+
+const mimeProtobuf = "application/x-protobuf"
+
+// toProto copies a model.Scorecard into its protobuf mirror defined in scorecardpb/scorecard.proto
+func toProto(scorecard *model.Scorecard) *scorecardpb.Scorecard {
+	return &scorecardpb.Scorecard{
+		CommitSha:            scorecard.CommitSha,
+		Pinned:               scorecard.Pinned,
+		Score:                scorecard.Score,
+		Maintained:           scorecard.Maintained,
+		CodeReview:           scorecard.CodeReview,
+		CiiBestPractices:     scorecard.CIIBestPractices,
+		License:              scorecard.License,
+		SignedReleases:       scorecard.SignedReleases,
+		DangerousWorkflow:    scorecard.DangerousWorkflow,
+		Packaging:            scorecard.Packaging,
+		TokenPermissions:     scorecard.TokenPermissions,
+		BranchProtection:     scorecard.BranchProtection,
+		BinaryArtifacts:      scorecard.BinaryArtifacts,
+		PinnedDependencies:   scorecard.PinnedDependencies,
+		SecurityPolicy:       scorecard.SecurityPolicy,
+		Fuzzing:              scorecard.Fuzzing,
+		Sast:                 scorecard.SAST,
+		Vulnerabilities:      scorecard.Vulnerabilities,
+		CiTests:              scorecard.CITests,
+		Contributors:         scorecard.Contributors,
+		DependencyUpdateTool: scorecard.DependencyUpdateTool,
+		Sbom:                 scorecard.SBOM,
+		Webhooks:             scorecard.Webhooks,
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"io"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ortelius/scec-commons/model"
+	"github.com/ortelius/scec-scorecard/scorecardpb"
+	"google.golang.org/protobuf/proto"
+)
+
+// protoFields is the fields of a scorecardpb.Scorecard by lowercased Go name
+func protoFields(msg *scorecardpb.Scorecard) map[string]reflect.Value {
+	fields := make(map[string]reflect.Value)
+	v := reflect.ValueOf(msg).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if field := v.Type().Field(i); field.IsExported() {
+			fields[strings.ToLower(field.Name)] = v.Field(i)
+		}
+	}
+	return fields
+}
+
+// assertSameScorecard fails unless every model.Scorecard field has the same value in msg
+func assertSameScorecard(t *testing.T, want *model.Scorecard, msg *scorecardpb.Scorecard) {
+	t.Helper()
+	fields := protoFields(msg)
+	v := reflect.ValueOf(want).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		got, ok := fields[strings.ToLower(name)]
+		if !ok {
+			t.Errorf("the protobuf message has no %s", name)
+			continue
+		}
+		if !reflect.DeepEqual(got.Interface(), v.Field(i).Interface()) {
+			t.Errorf("%s: got %v, want %v", name, got.Interface(), v.Field(i).Interface())
+		}
+	}
+}
+
+func TestProtobufRoundTripsEveryField(t *testing.T) {
+	scorecard := model.Scorecard{CommitSha: sha(7), Pinned: true}
+	v := reflect.ValueOf(&scorecard).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).Kind() == reflect.Float32 {
+			v.Field(i).SetFloat(float64(i) + 0.5)
+		}
+	}
+
+	data, err := proto.Marshal(toProto(&scorecard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded scorecardpb.Scorecard
+	if err := proto.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	assertSameScorecard(t, &scorecard, &decoded)
+}
+
+func TestScorecardIsNegotiatedAsProtobuf(t *testing.T) {
+	app := newTestApp(t, nil)
+	raw := resultJSON("github.com/a/b", sha(1), 6.5, map[string]int{"Code-Review": 7, "SAST": 3, "CI-Tests": 10})
+
+	post := func(accept string) (string, string) {
+		req := httptest.NewRequest(fiber.MethodPost, "/msapi/scorecard/map?commit="+sha(1), strings.NewReader(raw))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		if accept != "" {
+			req.Header.Set(fiber.HeaderAccept, accept)
+		}
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Header.Get(fiber.HeaderContentType), string(body)
+	}
+
+	mime, body := post("")
+	if !strings.HasPrefix(mime, fiber.MIMEApplicationJSON) {
+		t.Fatalf("default content type %s, want JSON", mime)
+	}
+	var want scorecardResponse
+	mustJSON(t, body, &want)
+
+	mime, body = post(mimeProtobuf)
+	if mime != mimeProtobuf {
+		t.Fatalf("content type %s, want %s", mime, mimeProtobuf)
+	}
+	var msg scorecardpb.Scorecard
+	if err := proto.Unmarshal([]byte(body), &msg); err != nil {
+		t.Fatal(err)
+	}
+	assertSameScorecard(t, &want.Scorecard, &msg)
+	if !msg.Pinned || msg.CodeReview != 7 || msg.Sast != 3 || msg.CiTests != 10 {
+		t.Errorf("protobuf scorecard %v", &msg)
+	}
+}
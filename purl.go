@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	errInvalidPurl     = errors.New("purl must be of the form pkg:type/namespace/name@version, with type github, gitlab, bitbucket, npm, pypi, maven or golang")
+	errPackageNotFound = errors.New("deps.dev does not know this package or version")
+	errNoSourceRepo    = errors.New("deps.dev has no source repository for this package")
+)
+
+// purlForgeHosts are the purl types that name a repo directly, with the host they live on
+var purlForgeHosts = map[string]string{
+	"github":    "github.com",
+	"gitlab":    "gitlab.com",
+	"bitbucket": "bitbucket.org",
+}
+
+// purlSystems are the package purl types deps.dev resolves, with its system name for each
+var purlSystems = map[string]string{
+	"npm":    "NPM",
+	"pypi":   "PYPI",
+	"maven":  "MAVEN",
+	"golang": "GO",
+}
+
+// packageURL is the part of a purl needed to find the source repo
+type packageURL struct {
+	Type      string
+	Namespace string
+	Name      string
+	Version   string
+}
+
+// parsePurl splits pkg:type/namespace/name@version, dropping qualifiers and subpath
+func parsePurl(purl string) (packageURL, error) {
+	rest, found := strings.CutPrefix(strings.TrimSpace(purl), "pkg:")
+	if !found {
+		return packageURL{}, errInvalidPurl
+	}
+	rest, _, _ = strings.Cut(rest, "#")
+	rest, _, _ = strings.Cut(rest, "?")
+
+	var p packageURL
+	if at := strings.LastIndex(rest, "@"); at > strings.LastIndex(rest, "/") {
+		rest, p.Version = rest[:at], rest[at+1:]
+	}
+
+	purlType, path, found := strings.Cut(strings.Trim(rest, "/"), "/")
+	if !found || path == "" {
+		return packageURL{}, errInvalidPurl
+	}
+	p.Type = strings.ToLower(purlType)
+	if slash := strings.LastIndex(path, "/"); slash >= 0 {
+		p.Namespace, p.Name = path[:slash], path[slash+1:]
+	} else {
+		p.Name = path
+	}
+
+	var err error
+	for _, part := range []*string{&p.Namespace, &p.Name, &p.Version} {
+		if *part, err = url.PathUnescape(*part); err != nil {
+			return packageURL{}, errInvalidPurl
+		}
+	}
+	if p.Name == "" {
+		return packageURL{}, errInvalidPurl
+	}
+	return p, nil
+}
+
+// depsDevName is the package name deps.dev uses for a purl
+func (p packageURL) depsDevName() string {
+	switch p.Type {
+	case "maven":
+		return p.Namespace + ":" + p.Name
+	case "pypi":
+		return strings.ReplaceAll(strings.ToLower(p.Name), "_", "-")
+	}
+	if p.Namespace != "" {
+		return p.Namespace + "/" + p.Name
+	}
+	return p.Name
+}
+
+// resolvePurl finds the repo, and for forge purls the commit, a purl points at. Forge
+// purls name the repo themselves, and their version, a tag, branch or sha, is resolved to
+// a commit as ?ref= is, with token when the caller passed one. Package purls are resolved
+// through DEPS_DEV_API_URL, at the purl's version or the package's default version when it
+// has none.
+func resolvePurl(purl, token string) (string, string, error) {
+	p, err := parsePurl(purl)
+	if err != nil {
+		return "", "", err
+	}
+	return resolvePackage(p, token)
+}
+
+// resolvePackage is resolvePurl for a parsed purl
+func resolvePackage(p packageURL, token string) (string, string, error) {
+	if host, ok := purlForgeHosts[p.Type]; ok {
+		if p.Namespace == "" {
+			return "", "", errInvalidPurl
+		}
+		repo := cleanRepoURL(host + "/" + p.Namespace + "/" + p.Name)
+		commit, err := revision(repo, "", p.Version, token)
+		if err != nil {
+			return "", "", err
+		}
+		return repo, commit, nil
+	}
+
+	system, ok := purlSystems[p.Type]
+	if !ok {
+		return "", "", errInvalidPurl
+	}
+	if p.Type == "maven" && p.Namespace == "" {
+		return "", "", errInvalidPurl
+	}
+
+	packagePath := "/systems/" + system + "/packages/" + depsDevEscape(p.depsDevName())
+	version := p.Version
+	if version == "" {
+		var pkg depsDevPackage
+		if err := getDepsDev(packagePath, &pkg); err != nil {
+			return "", "", err
+		}
+		if version = pkg.defaultVersion(); version == "" {
+			return "", "", errPackageNotFound
+		}
+	}
+
+	var v depsDevVersion
+	if err := getDepsDev(packagePath+"/versions/"+depsDevEscape(version), &v); err != nil {
+		return "", "", err
+	}
+	for _, project := range v.RelatedProjects {
+		if project.RelationType == "SOURCE_REPO" {
+			return cleanRepoURL(project.ProjectKey.ID), "", nil
+		}
+	}
+	return "", "", errNoSourceRepo
+}
+
+// depsDevPackage is the part of a deps.dev package used to pick a version
+type depsDevPackage struct {
+	Versions []struct {
+		VersionKey struct {
+			Version string `json:"version"`
+		} `json:"versionKey"`
+		IsDefault bool `json:"isDefault"`
+	} `json:"versions"`
+}
+
+// defaultVersion is the version deps.dev marks as default, or else the newest listed
+func (p depsDevPackage) defaultVersion() string {
+	for _, v := range p.Versions {
+		if v.IsDefault {
+			return v.VersionKey.Version
+		}
+	}
+	if len(p.Versions) == 0 {
+		return ""
+	}
+	return p.Versions[len(p.Versions)-1].VersionKey.Version
+}
+
+// depsDevVersion is the part of a deps.dev package version that names its source repo
+type depsDevVersion struct {
+	RelatedProjects []struct {
+		ProjectKey struct {
+			ID string `json:"id"`
+		} `json:"projectKey"`
+		RelationType string `json:"relationType"`
+	} `json:"relatedProjects"`
+}
+
+// depsDevEscape percent-encodes a name or version as one path segment, including the
+// @, : and / that url.PathEscape leaves alone
+func depsDevEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// getDepsDev fetches a deps.dev API path into out
+func getDepsDev(path string, out any) error {
+	body, err := shared("deps.dev:"+path, func() ([]byte, error) { return fetchDepsDev(path) })
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("%w: decoding the deps.dev response: %v", errUpstream, err)
+	}
+	return nil
+}
+
+// fetchDepsDev fetches the body of a deps.dev API path, unless the service is read-only
+func fetchDepsDev(path string) ([]byte, error) {
+	if readOnly.Load() {
+		return nil, errReadOnly
+	}
+	release := outbound.acquire()
+	resp, err := client.R().Get(config.DepsDevAPIURL + path)
+	release()
+	if err != nil {
+		return nil, upstreamError(fmt.Errorf("deps.dev: %w", err))
+	}
+
+	switch resp.StatusCode() {
+	case fiber.StatusOK:
+		return resp.Body(), nil
+	case fiber.StatusNotFound:
+		return nil, errPackageNotFound
+	default:
+		return nil, fmt.Errorf("%w: deps.dev returned %s", errUpstream, resp.Status())
+	}
+}
+
+// getPurlScorecard godoc
+// @Summary Get the OSSF scorecard for a package url
+// @Description Resolve a purl to its source repo and return that repo's scorecard. pkg:github,
+// @Description pkg:gitlab and pkg:bitbucket purls name the repo, with the version, a tag, branch or sha,
+// @Description resolved to a commit as ?ref= is; npm, pypi, maven and golang purls are resolved through
+// @Description deps.dev. The resolved repo is returned in the X-Resolved-Repo header.
+// @Tags scorecard
+// @Produce json
+// @Param purl query string true "package url, e.g. pkg:npm/express@4.18.2 or pkg:github/ortelius/scec-scorecard@v1.0.0"
+// @Param format query string false "response format overriding the Accept header" Enums(json, protobuf)
+// @Param verbose query bool false "include per-check scores, reasons and documentation links"
+// @Param details query bool false "list the checks as verbose does, each with its details"
+// @Param aggregate_present query bool false "add aggregate_present, the equal-weighted mean of the checks that ran"
+// @Param fields query string false "comma separated fields to return, in order, in any case, e.g. score,pinned,branchprotection"
+// @Param provenance query bool false "add meta with the scored repo, commit, analysis date and scorecard version"
+// @Param include_grade query bool false "add grade, the aggregate as a letter on the GRADE_THRESHOLDS scale or N/A without an aggregate"
+// @Param risk query string false "list only the checks in these comma separated risk tiers, e.g. Critical,High"
+// @Param include_unmapped query bool false "add unmapped_checks, the scores of checks with no scorecard field; defaults to INCLUDE_UNMAPPED_CHECKS"
+// @Param prefer query string false "which source to try first; defaults to PREFER_SOURCE or api" Enums(api, cli)
+// @Param refresh query bool false "skip the cache and the lookups shared within COALESCE_WINDOW_MS and fetch the scorecard again"
+// @Param X-Repo-Token header string false "forge token used instead of the configured one to scan a private repo; Authorization: Bearer also works"
+// @Success 200 {object} scorecardResponse
+// @Success 202 {object} processingResponse "scorecard still being computed"
+// @Success 304 "the scorecard If-None-Match or If-Modified-Since describes is still current"
+// @Failure 400 {object} errorResponse "INVALID_PURL, INVALID_REPO or REF_NOT_RESOLVABLE"
+// @Failure 404 {object} errorResponse "PACKAGE_NOT_FOUND, NO_SOURCE_REPO, REF_NOT_FOUND or any repo lookup 404"
+// @Failure 502 {object} errorResponse "UPSTREAM_ERROR or TOKEN_INVALID"
+// @Failure 503 {object} errorResponse "READ_ONLY"
+// @Router /msapi/scorecard/purl [get]
+func getPurlScorecard(c *fiber.Ctx) error {
+	prefer, err := preference(c)
+	if err != nil {
+		return err
+	}
+
+	githubURL, commitSha, err := resolvePurl(c.Query("purl"), requestToken(c))
+	if err != nil {
+		return sendLookupError(c, err)
+	}
+	if err := validateRepoURL(githubURL); err != nil {
+		return sendLookupError(c, err)
+	}
+
+	c.Set("X-Resolved-Repo", githubURL)
+	return serveScorecard(c, githubURL, commitSha, prefer)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestGitHubPurlVersionResolvesToACommit(t *testing.T) {
+	api := newFakeAPI(t)
+	api.serve("github.com/a/b?commit="+sha(2), resultJSON("github.com/a/b", sha(2), 7, nil))
+	github := newFakeAPI(t)
+	github.serve("repos/a/b/commits/v1.2.0", sha(2))
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL, "GITHUB_API_URL": github.URL})
+
+	for _, version := range []string{"v1.2.0", sha(2)} {
+		resp := getResponse(t, app, "/msapi/scorecard/purl?provenance=true&purl=pkg:github/a/b@"+version)
+		if resp.Meta == nil || resp.Meta.Commit != sha(2) || resp.ResolvedRef != "commit" {
+			t.Errorf("@%s: scored %+v, resolved_ref %q, want the tagged commit", version, resp.Meta, resp.ResolvedRef)
+		}
+	}
+	if n := github.called("repos/a/b/commits/v1.2.0"); n != 1 {
+		t.Errorf("the tag was resolved %d times, want 1", n)
+	}
+	if n := api.called("github.com/a/b?commit=v1.2.0"); n != 0 {
+		t.Errorf("the tag was passed to the API as a commit %d times", n)
+	}
+
+	status, body := get(t, app, "/msapi/scorecard/purl?purl=pkg:github/a/b@v9")
+	if status != fiber.StatusNotFound || !strings.Contains(body, "REF_NOT_FOUND") {
+		t.Errorf("an unknown tag: status %d, body %s", status, body)
+	}
+}
+
+func TestReadOnlyPackageLookupsSkipDepsDev(t *testing.T) {
+	depsDev := newFakeAPI(t)
+	app := newTestApp(t, map[string]string{"READ_ONLY": "true", "DEPS_DEV_API_URL": depsDev.URL})
+
+	for _, target := range []string{"/msapi/scorecard/purl?purl=pkg:npm/express@4.18.2", "/msapi/scorecard/package/npm/express?version=4.18.2"} {
+		status, body := get(t, app, target)
+		if status != fiber.StatusServiceUnavailable || !strings.Contains(body, "READ_ONLY") {
+			t.Errorf("%s: status %d, body %s, want READ_ONLY", target, status, body)
+		}
+	}
+	if n := depsDev.called("systems/NPM/packages/express/versions/4.18.2"); n != 0 {
+		t.Errorf("deps.dev was asked %d times while read-only", n)
+	}
+}
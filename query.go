@@ -0,0 +1,239 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// default and largest page sizes of the paged endpoints, the listing and the history
+const (
+	defaultPerPage = 50
+	maxPerPage     = 500
+)
+
+// default ?below= threshold and ?worst= count of the listing summary
+const (
+	defaultSummaryBelow = 5.0
+	defaultSummaryWorst = 5
+)
+
+// scorecardPage is the body returned by the query endpoint
+type scorecardPage struct {
+	Page       int               `json:"page"`
+	PerPage    int               `json:"per_page"`
+	Total      int               `json:"total"`
+	Scorecards []storedSummary   `json:"scorecards"`
+	Summary    *scorecardSummary `json:"summary,omitempty"`
+}
+
+// scorecardSummary rolls up every repo the query matched, not only those of the page.
+// Mean and median are left out when nothing matched.
+type scorecardSummary struct {
+	Repos     int             `json:"repos"`
+	Mean      *float64        `json:"mean,omitempty"`
+	Median    *float64        `json:"median,omitempty"`
+	Threshold float64         `json:"threshold"`
+	Below     int             `json:"below"`
+	Worst     []storedSummary `json:"worst"`
+}
+
+// summarize rolls up matches, which the store lists lowest aggregate first: how many are
+// below threshold and the worst of them, at most worst
+func summarize(matches []storedSummary, threshold float64, worst int) *scorecardSummary {
+	summary := &scorecardSummary{Repos: len(matches), Threshold: threshold, Worst: matches[:min(worst, len(matches))]}
+	if len(matches) == 0 {
+		return summary
+	}
+
+	total := 0.0
+	for _, m := range matches {
+		total += m.Score
+		if m.Score < threshold {
+			summary.Below++
+		}
+	}
+	mean := total / float64(len(matches))
+	median := matches[len(matches)/2].Score
+	if len(matches)%2 == 0 {
+		median = (matches[len(matches)/2-1].Score + median) / 2
+	}
+	summary.Mean, summary.Median = &mean, &median
+	return summary
+}
+
+// pageRequest is the page a paged endpoint returns, from ?page= and ?per_page=
+type pageRequest struct {
+	page    int
+	perPage int
+}
+
+// offset is how many items come before the page
+func (p pageRequest) offset() int {
+	return (p.page - 1) * p.perPage
+}
+
+// parsePage reads ?page=, from 1, and ?per_page=, at most maxPerPage. sizeAliases are
+// older names of per_page that are still read when it is not given.
+func parsePage(c *fiber.Ctx, sizeAliases ...string) (pageRequest, error) {
+	p := pageRequest{page: 1, perPage: defaultPerPage}
+	page, err := queryInt(c, "page")
+	if err != nil {
+		return p, err
+	}
+	if page != nil {
+		if *page < 1 {
+			return p, errors.New("page must be at least 1")
+		}
+		p.page = *page
+	}
+
+	for _, name := range append([]string{"per_page"}, sizeAliases...) {
+		perPage, err := queryInt(c, name)
+		if err != nil {
+			return p, err
+		}
+		if perPage == nil {
+			continue
+		}
+		if *perPage < 1 || *perPage > maxPerPage {
+			return p, fmt.Errorf("%s must be between 1 and %d", name, maxPerPage)
+		}
+		p.perPage = *perPage
+		break
+	}
+	return p, nil
+}
+
+// pageOf is the items of page p, empty past the last page
+func pageOf[T any](items []T, p pageRequest) []T {
+	start := min(p.offset(), len(items))
+	end := min(start+p.perPage, len(items))
+	return append([]T{}, items[start:end]...)
+}
+
+// queryFloat is the optional number query parameter name
+func queryFloat(c *fiber.Ctx, name string) (*float64, error) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number, got %q", name, v)
+	}
+	return &f, nil
+}
+
+// queryInt is the optional whole number query parameter name
+func queryInt(c *fiber.Ctx, name string) (*int, error) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a whole number, got %q", name, v)
+	}
+	return &n, nil
+}
+
+// queryScorecards godoc
+// @Summary Query the stored scorecards
+// @Description List the latest stored scorecard of every repo, lowest aggregate first, so the repos
+// @Description below a threshold can be found without looking each one up. minScore and maxScore bound
+// @Description the aggregate; lt and gte bound the score of the check named by check, and a repo whose
+// @Description check is missing or inconclusive does not match them. Results come per_page at a time,
+// @Description at most 500, and total counts every match. org lists only the repos of one org, and
+// @Description summary adds the mean and median aggregate of every match, how many are below the
+// @Description below threshold and the worst of them, lowest first. Needs PERSIST_SCORECARDS.
+// @Tags scorecard
+// @Produce json
+// @Param org query string false "host and owner of the repos to list, e.g. github.com/ortelius"
+// @Param minScore query number false "lowest aggregate"
+// @Param maxScore query number false "highest aggregate"
+// @Param check query string false "check name, e.g. Branch-Protection"
+// @Param lt query int false "the check scores below this"
+// @Param gte query int false "the check scores at least this"
+// @Param page query int false "page, from 1"
+// @Param per_page query int false "page size, 50 by default and at most 500"
+// @Param limit query int false "older name of per_page"
+// @Param summary query bool false "add summary, the rollup of every match"
+// @Param below query number false "aggregate the summary counts repos below, 5 by default"
+// @Param worst query int false "how many of the lowest scoring repos the summary lists, 5 by default and at most 500"
+// @Success 200 {object} scorecardPage
+// @Failure 400 {object} errorResponse "an invalid filter or page"
+// @Failure 501 {object} errorResponse "STORE_DISABLED"
+// @Failure 503 {object} errorResponse "STORE_UNAVAILABLE"
+// @Router /msapi/scorecards [get]
+func queryScorecards(c *fiber.Ctx) error {
+	if store == nil {
+		return sendLookupError(c, errStoreDisabled)
+	}
+
+	var (
+		filter scorecardFilter
+		err    error
+	)
+	if filter.minScore, err = queryFloat(c, "minScore"); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if filter.maxScore, err = queryFloat(c, "maxScore"); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if filter.checkLT, err = queryInt(c, "lt"); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if filter.checkGTE, err = queryInt(c, "gte"); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if filter.check = c.Query("check"); filter.check == "" && (filter.checkLT != nil || filter.checkGTE != nil) {
+		return fiber.NewError(fiber.StatusBadRequest, "lt and gte need a check")
+	}
+	if org := c.Query("org"); org != "" {
+		filter.org, _ = normalizeRepoURL(org)
+	}
+	page, err := parsePage(c, "limit")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	below, err := queryFloat(c, "below")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	worst, err := queryInt(c, "worst")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if worst != nil && (*worst < 0 || *worst > maxPerPage) {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("worst must be between 0 and %d", maxPerPage))
+	}
+
+	response := scorecardPage{Page: page.page, PerPage: page.perPage}
+	response.Scorecards, response.Total, err = store.query(filter, page.offset(), page.perPage)
+	if err != nil {
+		return sendLookupError(c, err)
+	}
+
+	// the summary covers every match, so it reads them all when the page does not hold them
+	if c.QueryBool("summary") {
+		matches := response.Scorecards
+		if page.offset() > 0 || len(matches) < response.Total {
+			if matches, _, err = store.query(filter, 0, response.Total); err != nil {
+				return sendLookupError(c, err)
+			}
+		}
+		threshold, count := defaultSummaryBelow, defaultSummaryWorst
+		if below != nil {
+			threshold = *below
+		}
+		if worst != nil {
+			count = *worst
+		}
+		response.Summary = summarize(matches, threshold, count)
+	}
+	return c.JSON(response)
+}
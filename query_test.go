@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// storeScores stores a scorecard of each repo with its aggregate
+func storeScores(t *testing.T, s *memoryStore, scores map[string]float64) {
+	t.Helper()
+	for repo, score := range scores {
+		s.save(repo, sourceAPI, parseResult(t, resultJSON(repo, sha(len(repo)), score, map[string]int{"Code-Review": int(score)})))
+	}
+}
+
+func TestQueryPages(t *testing.T) {
+	app := newTestApp(t, nil)
+	s := useMemoryStore(t)
+	scores := map[string]float64{}
+	for i := 1; i <= 5; i++ {
+		scores[fmt.Sprintf("github.com/o/r%d", i)] = float64(i)
+	}
+	storeScores(t, s, scores)
+
+	for _, size := range []string{"per_page", "limit"} {
+		status, body := get(t, app, "/msapi/scorecards?page=3&"+size+"=2")
+		if status != fiber.StatusOK {
+			t.Fatalf("%s: status %d, body %s", size, status, body)
+		}
+		var page scorecardPage
+		mustJSON(t, body, &page)
+		if page.Page != 3 || page.PerPage != 2 || page.Total != 5 || len(page.Scorecards) != 1 || page.Scorecards[0].Repo != "github.com/o/r5" {
+			t.Errorf("%s: last page %+v, want the highest of 5", size, page)
+		}
+	}
+	if status, _ := get(t, app, "/msapi/scorecards?per_page=501"); status != fiber.StatusBadRequest {
+		t.Errorf("per_page past the cap: status %d", status)
+	}
+}
+
+func TestQuerySummarizesAnOrg(t *testing.T) {
+	app := newTestApp(t, nil)
+	s := useMemoryStore(t)
+	storeScores(t, s, map[string]float64{
+		"github.com/org/a":   2,
+		"github.com/org/b":   4,
+		"github.com/org/c":   6,
+		"github.com/org/d":   9,
+		"github.com/other/e": 1,
+	})
+
+	// the second page holds only org/c and org/d, the summary still covers all four
+	status, body := get(t, app, "/msapi/scorecards?org=https://github.com/Org&per_page=2&page=2&summary=true&below=5&worst=3")
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d, body %s", status, body)
+	}
+	var page scorecardPage
+	mustJSON(t, body, &page)
+	if page.Total != 4 || len(page.Scorecards) != 2 || page.Scorecards[0].Repo != "github.com/org/c" {
+		t.Errorf("page %+v, want org/c and org/d of 4", page)
+	}
+	summary := page.Summary
+	if summary == nil || summary.Repos != 4 || summary.Mean == nil || *summary.Mean != 5.25 ||
+		summary.Median == nil || *summary.Median != 5 || summary.Threshold != 5 || summary.Below != 2 {
+		t.Fatalf("summary %s", body)
+	}
+	var worst []string
+	for _, w := range summary.Worst {
+		worst = append(worst, w.Repo)
+	}
+	if fmt.Sprint(worst) != "[github.com/org/a github.com/org/b github.com/org/c]" {
+		t.Errorf("worst %v", worst)
+	}
+
+	_, body = get(t, app, "/msapi/scorecards?summary=true")
+	page = scorecardPage{}
+	mustJSON(t, body, &page)
+	if page.Summary == nil || page.Summary.Repos != 5 || *page.Summary.Median != 4 || len(page.Summary.Worst) != 5 || page.Summary.Below != 3 {
+		t.Errorf("defaults: summary %s", body)
+	}
+	_, body = get(t, app, "/msapi/scorecards?org=github.com/nobody&summary=true")
+	page = scorecardPage{}
+	mustJSON(t, body, &page)
+	if page.Summary == nil || page.Summary.Repos != 0 || page.Summary.Mean != nil || len(page.Summary.Worst) != 0 {
+		t.Errorf("no matches: summary %s", body)
+	}
+	if status, _ := get(t, app, "/msapi/scorecards?summary=true&worst=-1"); status != fiber.StatusBadRequest {
+		t.Errorf("a negative worst: status %d", status)
+	}
+}
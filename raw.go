@@ -0,0 +1,52 @@
+package main
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// getRawScorecard godoc
+// @Summary Get the OpenSSF result for a repo
+// @Description Return the JSONScorecardResultV2 document behind a scorecard, as the API or the scan
+// @Description produced it, with every check's reason and details. The key, commit, ref and latest
+// @Description are resolved as on the scorecard endpoint. The source is returned in the X-Scorecard-Source header.
+// @Description The ETag and Last-Modified change only when the repo is scored again; send them back in
+// @Description If-None-Match or If-Modified-Since to get a 304. Cache-Control is set as on the scorecard endpoint.
+// @Tags scorecard
+// @Produce json
+// @Param commit query string false "commit sha"
+// @Param ref query string false "branch or tag to score, resolved to its commit through the forge; REF_COMMIT_CONFLICT decides when it disagrees with commit"
+// @Param latest query bool false "ignore commit and ref and return the latest, unpinned result"
+// @Param prefer query string false "which source to try first; defaults to PREFER_SOURCE or api" Enums(api, cli)
+// @Param refresh query bool false "skip the cache and the lookups shared within COALESCE_WINDOW_MS and fetch the scorecard again"
+// @Param X-Repo-Token header string false "forge token used instead of the configured one to scan a private repo; Authorization: Bearer also works"
+// @Success 200 {object} object "JSONScorecardResultV2"
+// @Success 202 {object} processingResponse "scorecard still being computed"
+// @Success 304 "the result If-None-Match or If-Modified-Since describes is still current"
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 502 {object} errorResponse
+// @Router /msapi/scorecard/:key/raw [get]
+func getRawScorecard(c *fiber.Ctx) error {
+	prefer, err := preference(c)
+	if err != nil {
+		return err
+	}
+
+	githubURL, commitSha, err := lookupKey(c)
+	if err != nil {
+		return sendLookupError(c, err)
+	}
+
+	result, source, err := requestLookup(c, githubURL, commitSha, prefer)
+	if err != nil {
+		return sendLookupError(c, err)
+	}
+
+	recordScored(githubURL)
+	c.Set("X-Scorecard-Source", source)
+	setCacheControl(c, result, commitSha)
+	if notModified(c, result, fiber.MIMEApplicationJSON) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	return c.JSON(result)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// redisTimeout bounds one cache read or write against Redis
+const redisTimeout = 2 * time.Second
+
+// redisPurgeTimeout bounds a purge or a stats count, which scan every key under cacheKeyPrefix
+const redisPurgeTimeout = 30 * time.Second
+
+// redisGlob escapes the characters SCAN MATCH patterns treat as wildcards
+var redisGlob = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+// redisCache is the Cache kept in Redis, so every replica sharing the Redis answers from
+// the same results. Redis failures are logged and treated as misses.
+type redisCache struct {
+	cacheCounters
+	client *redis.Client
+}
+
+func newRedisCache(opts *redis.Options) *redisCache {
+	return &redisCache{client: redis.NewClient(opts)}
+}
+
+func (c *redisCache) Get(key string) (CacheEntry, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	data, err := c.client.Get(ctx, cacheKeyPrefix+key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			logger.Warn("redis cache read failed", zap.String("key", key), zap.Error(err))
+		}
+		return c.read(CacheEntry{}, false)
+	}
+
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || (entry.Result == nil && entry.Miss == "") {
+		logger.Warn("redis cache entry is unreadable", zap.String("key", key), zap.Error(err))
+		return c.read(CacheEntry{}, false)
+	}
+	return c.read(entry, true)
+}
+
+func (c *redisCache) Set(key string, entry CacheEntry, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		logger.Warn("redis cache entry cannot be encoded", zap.String("key", key), zap.Error(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := c.client.Set(ctx, cacheKeyPrefix+key, data, ttl).Err(); err != nil {
+		logger.Warn("redis cache write failed", zap.String("key", key), zap.Error(err))
+	}
+}
+
+func (c *redisCache) Invalidate(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := c.client.Del(ctx, cacheKeyPrefix+key).Err(); err != nil {
+		logger.Warn("redis cache invalidation failed", zap.String("key", key), zap.Error(err))
+	}
+}
+
+func (c *redisCache) Purge(prefix string) int {
+	ctx, cancel := context.WithTimeout(context.Background(), redisPurgeTimeout)
+	defer cancel()
+
+	purged := 0
+	iter := c.client.Scan(ctx, 0, redisGlob.Replace(cacheKeyPrefix+prefix)+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		n, err := c.client.Del(ctx, iter.Val()).Result()
+		if err != nil {
+			logger.Warn("redis cache purge failed", zap.String("prefix", prefix), zap.Error(err))
+			return purged
+		}
+		purged += int(n)
+	}
+	if err := iter.Err(); err != nil {
+		logger.Warn("redis cache purge failed", zap.String("prefix", prefix), zap.Error(err))
+	}
+	return purged
+}
+
+// Stats counts the entries under cacheKeyPrefix. Memory and evictions are read from INFO
+// and so are those of the whole Redis: used_memory, and the evicted_keys and expired_keys.
+func (c *redisCache) Stats() CacheStats {
+	ctx, cancel := context.WithTimeout(context.Background(), redisPurgeTimeout)
+	defer cancel()
+
+	stats := c.stats(CacheStats{Backend: cacheRedis})
+	iter := c.client.Scan(ctx, 0, redisGlob.Replace(cacheKeyPrefix)+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		stats.Entries++
+	}
+	if err := iter.Err(); err != nil {
+		logger.Warn("redis cache count failed", zap.Error(err))
+	}
+
+	info, err := c.client.Info(ctx, "memory", "stats").Result()
+	if err != nil {
+		logger.Warn("redis info failed", zap.Error(err))
+		return stats
+	}
+	stats.MemoryBytes = redisInfoInt(info, "used_memory")
+	stats.Evictions = redisInfoInt(info, "evicted_keys") + redisInfoInt(info, "expired_keys")
+	return stats
+}
+
+// redisInfoInt is the integer field name of an INFO reply, or zero when it is missing
+func redisInfoInt(info, name string) int64 {
+	for _, line := range strings.Split(info, "\n") {
+		if value, found := strings.CutPrefix(strings.TrimSpace(line), name+":"); found {
+			n, _ := strconv.ParseInt(value, 10, 64)
+			return n
+		}
+	}
+	return 0
+}
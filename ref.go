@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/gofiber/fiber/v2"
+)
+
+// refResolveTimeout bounds one ref lookup against a forge
+const refResolveTimeout = 30 * time.Second
+
+var (
+	errRefNotFound      = errors.New("the ref is not a branch, tag or commit of the repository")
+	errRefNotResolvable = errors.New("refs can only be resolved on GitHub, GitLab and the clone forges; pass ?commit= instead")
+	errRefCommitClash   = errors.New("the ref resolves to a different commit than ?commit=")
+)
+
+var fullSHAPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// requestedRevision is the commit a lookup scores: nothing with ?latest=true, else ?commit=,
+// or the commit ?ref= resolves to on the repo's forge. When both are given they must agree.
+func requestedRevision(c *fiber.Ctx, repoURL string) (string, error) {
+	if c.QueryBool("latest") {
+		return "", nil
+	}
+	return revision(repoURL, c.Query("commit"), c.Query("ref"), requestToken(c))
+}
+
+// REF_COMMIT_CONFLICT policies for a ref that resolves to a different commit than ?commit=
+const (
+	conflictError        = "error"
+	conflictPreferCommit = "prefer_commit"
+	conflictPreferRef    = "prefer_ref"
+)
+
+// revision is the commit to score for a commit, a ref or both. When both are given and
+// disagree, REF_COMMIT_CONFLICT decides: an error, or the commit or the ref wins.
+func revision(repoURL, commitSha, ref, token string) (string, error) {
+	if ref == "" {
+		return commitSha, nil
+	}
+
+	resolved, err := resolveRef(repoURL, ref, token)
+	if err != nil {
+		return "", err
+	}
+	if commitSha == "" || strings.HasPrefix(resolved, strings.ToLower(commitSha)) {
+		return resolved, nil
+	}
+
+	switch config.RefCommitConflict {
+	case conflictPreferCommit:
+		return commitSha, nil
+	case conflictPreferRef:
+		return resolved, nil
+	default:
+		return "", fmt.Errorf("%w: %s is %s, not %s", errRefCommitClash, ref, resolved, commitSha)
+	}
+}
+
+// resolveRef finds the full commit sha of a branch, tag or commit through the forge API, or
+// the advertised refs for the clone forges. token is the caller's, when one was passed.
+// Only a full sha is resolved while the service is read-only.
+func resolveRef(repoURL, ref, token string) (string, error) {
+	if fullSHAPattern.MatchString(strings.ToLower(ref)) {
+		return strings.ToLower(ref), nil
+	}
+	if readOnly.Load() {
+		return "", errReadOnly
+	}
+	if token != "" {
+		return fetchRef(repoURL, ref, token)
+	}
+	return shared("ref:"+repoURL+"@"+ref, func() (string, error) { return fetchRef(repoURL, ref, "") })
+}
+
+// fetchRef is resolveRef for a ref that is not already a full sha
+func fetchRef(repoURL, ref, token string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), refResolveTimeout)
+	defer cancel()
+
+	if forge, ok := cloneForgeFor(repoURL); ok {
+		return resolveRemoteRef(ctx, forge, repoURL, ref, token)
+	}
+	switch {
+	case isGitHubRepo(repoURL):
+		return resolveGitHubRef(ctx, repoURL, ref, token)
+	case isGitLabRepo(repoURL):
+		return resolveGitLabRef(ctx, repoURL, ref, token)
+	default:
+		return "", errRefNotResolvable
+	}
+}
+
+// resolveGitHubRef asks the commits API for the sha alone. Only the GH_HOST instance gets a token.
+func resolveGitHubRef(ctx context.Context, repoURL, ref, token string) (string, error) {
+	callerToken := token != ""
+	apiURL := defaultGitHubAPIURL
+	if onConfiguredGitHub(repoURL) {
+		apiURL = config.GitHubAPIURL
+		if token == "" {
+			token = config.GitHubToken
+		}
+	} else {
+		token, callerToken = "", false
+	}
+	_, path, _ := strings.Cut(repoURL, "/")
+
+	req := client.R().SetContext(ctx).SetHeader(fiber.HeaderAccept, "application/vnd.github.sha")
+	if token != "" {
+		req.SetAuthToken(token)
+	}
+
+	release := outbound.acquire()
+	resp, err := req.Get(apiURL + "/repos/" + path + "/commits/" + url.PathEscape(ref))
+	release()
+	if err != nil {
+		return "", upstreamError(fmt.Errorf("resolving %s: %w", ref, err))
+	}
+	return refResponseSHA(resp.StatusCode(), resp.Status(), strings.TrimSpace(resp.String()), callerToken)
+}
+
+// resolveGitLabRef asks the GitLab commits API, with the caller's token or GITLAB_AUTH_TOKEN
+func resolveGitLabRef(ctx context.Context, repoURL, ref, token string) (string, error) {
+	callerToken := token != ""
+	if token == "" {
+		token = config.GitLabToken
+	}
+	host, path, _ := strings.Cut(repoURL, "/")
+
+	req := client.R().SetContext(ctx)
+	if token != "" {
+		req.SetHeader("PRIVATE-TOKEN", token)
+	}
+
+	var commit struct {
+		ID string `json:"id"`
+	}
+	release := outbound.acquire()
+	resp, err := req.SetResult(&commit).Get("https://" + host + "/api/v4/projects/" + url.PathEscape(path) + "/repository/commits/" + url.PathEscape(ref))
+	release()
+	if err != nil {
+		return "", upstreamError(fmt.Errorf("resolving %s: %w", ref, err))
+	}
+	return refResponseSHA(resp.StatusCode(), resp.Status(), commit.ID, callerToken)
+}
+
+// refResponseSHA turns a forge commits API response into the sha or the lookup error.
+// callerToken reports whether the request was made with the caller's token.
+func refResponseSHA(status int, statusText, sha string, callerToken bool) (string, error) {
+	switch {
+	case status == fiber.StatusOK && fullSHAPattern.MatchString(sha):
+		return sha, nil
+	case status == fiber.StatusNotFound, status == fiber.StatusUnprocessableEntity:
+		return "", errRefNotFound
+	case status == fiber.StatusUnauthorized && callerToken:
+		return "", errRequestTokenInvalid
+	default:
+		return "", fmt.Errorf("%w: resolving the ref returned %s", errUpstream, statusText)
+	}
+}
+
+// resolveRemoteRef matches the ref against the refs the clone forge advertises, preferring
+// the commit an annotated tag points at over the tag object itself
+func resolveRemoteRef(ctx context.Context, forge cloneForge, repoURL, ref, token string) (string, error) {
+	remote := git.NewRemote(memory.NewStorage(), &gitconfig.RemoteConfig{Name: "origin", URLs: []string{forge.cloneURL(repoURL)}})
+
+	release := outbound.acquire()
+	refs, err := remote.ListContext(ctx, &git.ListOptions{Auth: forge.auth(token), PeelingOption: git.AppendPeeled})
+	release()
+	if err != nil {
+		if token != "" && (errors.Is(err, transport.ErrAuthenticationRequired) || errors.Is(err, transport.ErrAuthorizationFailed)) {
+			return "", errRequestTokenInvalid
+		}
+		return "", upstreamError(fmt.Errorf("listing the refs of %s: %w", repoURL, err))
+	}
+
+	advertised := make(map[string]string, len(refs))
+	for _, r := range refs {
+		advertised[r.Name().String()] = r.Hash().String()
+	}
+	for _, name := range []string{"refs/tags/" + ref + "^{}", "refs/tags/" + ref, "refs/heads/" + ref, ref} {
+		if sha, ok := advertised[name]; ok {
+			return sha, nil
+		}
+	}
+	return "", errRefNotFound
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestLatestSkipsTheCommit(t *testing.T) {
+	api := newFakeAPI(t)
+	api.serve("github.com/a/b", resultJSON("github.com/a/b", sha(3), 7, nil))
+	api.serve("github.com/a/b?commit="+sha(1), resultJSON("github.com/a/b", sha(1), 5, nil))
+	github := newFakeAPI(t)
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL, "GITHUB_API_URL": github.URL})
+
+	resp := getResponse(t, app, "/msapi/scorecard/github.com/a/b?latest=true&ref=v1&commit="+sha(1))
+	if resp.ResolvedRef != "latest" || resp.Score != 7 {
+		t.Errorf("resolved_ref %q, score %v, want the latest scorecard", resp.ResolvedRef, resp.Score)
+	}
+	if n := api.called("github.com/a/b?commit=" + sha(1)); n != 0 {
+		t.Errorf("the commit was asked for %d times", n)
+	}
+	if n := api.called("github.com/a/b"); n != 1 {
+		t.Errorf("the latest scorecard was asked for %d times, want 1", n)
+	}
+	if n := github.called("repos/a/b/commits/v1"); n != 0 {
+		t.Errorf("the ref was resolved %d times", n)
+	}
+}
+
+func TestRefCommitConflictPolicies(t *testing.T) {
+	api := newFakeAPI(t)
+	api.serve("github.com/a/b?commit="+sha(1), resultJSON("github.com/a/b", sha(1), 5, nil))
+	api.serve("github.com/a/b?commit="+sha(2), resultJSON("github.com/a/b", sha(2), 7, nil))
+	github := newFakeAPI(t)
+	github.serve("repos/a/b/commits/v1", sha(2))
+	target := "/msapi/scorecard/github.com/a/b?ref=v1&commit=" + sha(1)
+
+	for _, tt := range []struct {
+		policy string
+		status int
+		commit string // scored, empty for a conflict
+	}{
+		{"", fiber.StatusBadRequest, ""},
+		{conflictError, fiber.StatusBadRequest, ""},
+		{conflictPreferCommit, fiber.StatusOK, sha(1)},
+		{conflictPreferRef, fiber.StatusOK, sha(2)},
+	} {
+		app := newTestApp(t, map[string]string{
+			"SCORECARD_API_URLS":  api.URL,
+			"GITHUB_API_URL":      github.URL,
+			"REF_COMMIT_CONFLICT": tt.policy,
+		})
+		status, body := get(t, app, target+"&provenance=true")
+		if status != tt.status {
+			t.Errorf("REF_COMMIT_CONFLICT=%q: status %d, body %s", tt.policy, status, body)
+			continue
+		}
+		if tt.commit == "" {
+			var resp errorResponse
+			mustJSON(t, body, &resp)
+			if resp.Code != "REF_COMMIT_CONFLICT" || !strings.Contains(resp.Message, sha(2)) || !strings.Contains(resp.Message, sha(1)) {
+				t.Errorf("REF_COMMIT_CONFLICT=%q: %+v, want both commits named", tt.policy, resp)
+			}
+			continue
+		}
+		var resp scorecardResponse
+		mustJSON(t, body, &resp)
+		if resp.Meta == nil || resp.Meta.Commit != tt.commit {
+			t.Errorf("REF_COMMIT_CONFLICT=%q: scored %+v, want %s", tt.policy, resp.Meta, tt.commit)
+		}
+	}
+
+	if _, err := loadConfig(func(name string) string {
+		if name == "REF_COMMIT_CONFLICT" {
+			return "prefer_neither"
+		}
+		return ""
+	}); err == nil {
+		t.Error("an unknown REF_COMMIT_CONFLICT was accepted")
+	}
+}
+
+func TestReadOnlyResolvesNoRef(t *testing.T) {
+	api := newFakeAPI(t)
+	github := newFakeAPI(t)
+	github.serve("repos/a/b/commits/v1", sha(2))
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL, "GITHUB_API_URL": github.URL, "READ_ONLY": "true"})
+
+	status, body := get(t, app, "/msapi/scorecard/github.com/a/b?ref=v1")
+	if status != fiber.StatusServiceUnavailable || !strings.Contains(body, "READ_ONLY") {
+		t.Errorf("status %d, body %s, want READ_ONLY", status, body)
+	}
+	if n := github.called("repos/a/b/commits/v1"); n != 0 {
+		t.Errorf("the ref was resolved %d times while read-only", n)
+	}
+	if commit, err := resolveRef("github.com/a/b", strings.ToUpper(sha(2)), ""); err != nil || commit != sha(2) {
+		t.Errorf("a full sha while read-only: %q, %v", commit, err)
+	}
+	if _, err := resolveRef("github.com/a/b", "v1", "token"); !errors.Is(err, errReadOnly) {
+		t.Errorf("a ref with a caller's token while read-only: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// regressionRequest is the commit of a repo the regression endpoint checks
+type regressionRequest struct {
+	Repo   string `json:"repo"`
+	Commit string `json:"commit"`
+}
+
+// regressionResponse is the body returned by the regression endpoint. Baseline is null when
+// no earlier scorecard of the repo is stored, and the deltas are then left out. ScoreDelta
+// is also omitted when either scorecard has no aggregate.
+type regressionResponse struct {
+	Repo       string       `json:"repo"`
+	Current    diffSide     `json:"current"`
+	Baseline   *diffSide    `json:"baseline"`
+	ScoreDelta *float64     `json:"score_delta,omitempty"`
+	Checks     []checkDelta `json:"checks,omitempty"`
+	Regressed  bool         `json:"regressed"`
+}
+
+// getRegression godoc
+// @Summary Check a commit's OSSF scorecard for a regression
+// @Description Look up the scorecard of a commit and compare it with the baseline, the latest scorecard
+// @Description stored for another commit of the repo and analysed no later than this one. Every check is
+// @Description listed with its scores and delta, -1 for a score that is missing at either commit, and
+// @Description regressed is true when the aggregate or any check dropped. Without a baseline, baseline is
+// @Description null and only the current scorecard is given. Needs PERSIST_SCORECARDS.
+// @Tags scorecard
+// @Accept json
+// @Produce json
+// @Param request body regressionRequest true "repo and commit sha to check"
+// @Param prefer query string false "which source to try first; defaults to PREFER_SOURCE or api" Enums(api, cli)
+// @Param refresh query bool false "skip the cache and the lookups shared within COALESCE_WINDOW_MS and fetch the scorecard again"
+// @Param X-Repo-Token header string false "forge token used instead of the configured one to scan a private repo; Authorization: Bearer also works"
+// @Success 200 {object} regressionResponse
+// @Success 202 {object} processingResponse "a scorecard still being computed"
+// @Failure 400 {object} errorResponse "INVALID_REPO or a body without repo and commit"
+// @Failure 404 {object} errorResponse "COMMIT_NOT_SCORED or any lookup 404"
+// @Failure 501 {object} errorResponse "STORE_DISABLED"
+// @Failure 502 {object} errorResponse "UPSTREAM_ERROR"
+// @Failure 503 {object} errorResponse "STORE_UNAVAILABLE"
+// @Router /msapi/scorecard/regression [post]
+func getRegression(c *fiber.Ctx) error {
+	if store == nil {
+		return sendLookupError(c, errStoreDisabled)
+	}
+	prefer, err := preference(c)
+	if err != nil {
+		return err
+	}
+
+	var body regressionRequest
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if body.Repo == "" || body.Commit == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "both repo and commit are required")
+	}
+	githubURL := cleanRepoURL(body.Repo)
+	if err := validateRepoURL(githubURL); err != nil {
+		return sendLookupError(c, err)
+	}
+
+	req := lookupRequest{repo: githubURL, commit: body.Commit, prefer: prefer, token: requestToken(c), refresh: c.QueryBool("refresh")}
+	result, source, err := scoreCommit(req)
+	if err != nil {
+		return sendLookupError(c, err)
+	}
+	recordScored(githubURL)
+
+	resp := regressionResponse{
+		Repo:    githubURL,
+		Current: diffSide{Commit: result.Repo.Commit, Date: result.Date, Score: float64(result.AggregateScore), Source: source},
+	}
+	baseline, ok, err := store.baseline(githubURL, result.Repo.Commit, result.Date)
+	if err != nil {
+		return sendLookupError(c, err)
+	}
+	if !ok {
+		return c.JSON(resp)
+	}
+
+	from := baseline.Result
+	resp.Baseline = &diffSide{Commit: from.Repo.Commit, Date: from.Date, Score: float64(from.AggregateScore), Source: baseline.Source}
+	diff := diffScorecards(from, result)
+	resp.ScoreDelta = diff.ScoreDelta
+	resp.Checks = append(append(append(append(resp.Checks, diff.Improved...), diff.Regressed...), diff.Unchanged...), diff.Inconclusive...)
+	sort.Slice(resp.Checks, func(i, j int) bool { return resp.Checks[i].Name < resp.Checks[j].Name })
+	resp.Regressed = len(diff.Regressed) > 0 || (diff.ScoreDelta != nil && *diff.ScoreDelta < 0)
+	return c.JSON(resp)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// postRegression checks body, a {repo, commit} document, for a regression
+func postRegression(t *testing.T, app *fiber.App, body string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodPost, "/msapi/scorecard/regression", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return doRequest(t, app, req)
+}
+
+func TestRegressionAgainstTheStoredBaseline(t *testing.T) {
+	api := newFakeAPI(t)
+	api.serve("github.com/a/b?commit="+sha(2), resultJSON("github.com/a/b", sha(2), 6, map[string]int{"Code-Review": 5, "License": 10, "SAST": -1}))
+	api.serve("github.com/a/b?commit="+sha(3), resultJSON("github.com/a/b", sha(3), 7.5, map[string]int{"Code-Review": 9, "License": 10}))
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL})
+	s := useMemoryStore(t)
+
+	older := parseResult(t, resultJSON("github.com/a/b", sha(9), 9, map[string]int{"Code-Review": 10}))
+	older.Date = "2024-03-01T00:00:00Z"
+	s.save("github.com/a/b", sourceAPI, older)
+	baseline := parseResult(t, resultJSON("github.com/a/b", sha(1), 7, map[string]int{"Code-Review": 8, "License": 10, "SAST": 3}))
+	baseline.Date = "2024-04-01T00:00:00Z"
+	s.save("github.com/a/b", sourceAPI, baseline)
+
+	status, body := postRegression(t, app, `{"repo":"https://github.com/a/b","commit":"`+sha(2)+`"}`)
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d, body %s", status, body)
+	}
+	var resp regressionResponse
+	mustJSON(t, body, &resp)
+	if resp.Repo != "github.com/a/b" || resp.Current.Commit != sha(2) || resp.Current.Score != 6 {
+		t.Errorf("current %s %+v", resp.Repo, resp.Current)
+	}
+	if resp.Baseline == nil || resp.Baseline.Commit != sha(1) || resp.Baseline.Score != 7 {
+		t.Fatalf("baseline %+v, want the latest earlier scorecard", resp.Baseline)
+	}
+	if resp.ScoreDelta == nil || *resp.ScoreDelta != -1 || !resp.Regressed {
+		t.Errorf("score delta %v, regressed %v", resp.ScoreDelta, resp.Regressed)
+	}
+	want := []checkDelta{
+		{Name: "Code-Review", From: 8, To: 5, Delta: -3},
+		{Name: "License", From: 10, To: 10},
+		{Name: "SAST", From: 3, To: -1},
+	}
+	if len(resp.Checks) != len(want) {
+		t.Fatalf("checks %+v", resp.Checks)
+	}
+	for i := range want {
+		if resp.Checks[i] != want[i] {
+			t.Errorf("check %+v, want %+v", resp.Checks[i], want[i])
+		}
+	}
+
+	// the scorecard just checked is stored, and is the baseline of the next commit
+	_, body = postRegression(t, app, `{"repo":"github.com/a/b","commit":"`+sha(3)+`"}`)
+	mustJSON(t, body, &resp)
+	if resp.Baseline == nil || resp.Baseline.Commit != sha(2) || resp.Regressed || *resp.ScoreDelta != 1.5 {
+		t.Errorf("after an improvement: baseline %+v, delta %v, regressed %v", resp.Baseline, resp.ScoreDelta, resp.Regressed)
+	}
+}
+
+func TestRegressionWithoutABaseline(t *testing.T) {
+	api := newFakeAPI(t)
+	api.serve("github.com/a/b?commit="+sha(1), resultJSON("github.com/a/b", sha(1), 6, map[string]int{"License": 10}))
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL})
+
+	status, body := postRegression(t, app, `{"repo":"github.com/a/b","commit":"`+sha(1)+`"}`)
+	if status != fiber.StatusNotImplemented || !strings.Contains(body, "STORE_DISABLED") {
+		t.Errorf("without a store: status %d, body %s", status, body)
+	}
+
+	useMemoryStore(t)
+	status, body = postRegression(t, app, `{"repo":"github.com/a/b","commit":"`+sha(1)+`"}`)
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d, body %s", status, body)
+	}
+	if !strings.Contains(body, `"baseline":null`) {
+		t.Errorf("no baseline null in %s", body)
+	}
+	var resp regressionResponse
+	mustJSON(t, body, &resp)
+	if resp.Current.Score != 6 || resp.Regressed || resp.ScoreDelta != nil || resp.Checks != nil {
+		t.Errorf("without a baseline %+v", resp)
+	}
+
+	for _, bad := range []string{`{"repo":"github.com/a/b"}`, `{"commit":"` + sha(1) + `"}`, `not json`} {
+		if status, _ := postRegression(t, app, bad); status != fiber.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", bad, status)
+		}
+	}
+}
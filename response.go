@@ -0,0 +1,188 @@
+package main
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/ortelius/scec-commons/model"
+	ossf "github.com/ossf/scorecard/v5/pkg/scorecard"
+)
+
+// scorecardResponse is the body returned for a scorecard lookup. The embedded
+// model.Scorecard keeps the JSON flat so existing clients see the same fields,
+// and the optional extras are omitted unless requested.
+type scorecardResponse struct {
+	model.Scorecard
+	ResolvedRef      string         `json:"resolved_ref,omitempty"`
+	Grade            string         `json:"grade,omitempty"`
+	Checks           []checkDetail  `json:"checks,omitempty"`
+	AggregatePresent *float32       `json:"aggregate_present,omitempty"`
+	UnmappedChecks   map[string]int `json:"unmapped_checks,omitempty"`
+	Meta             *responseMeta  `json:"meta,omitempty"`
+}
+
+// responseMeta records what exactly was scored so consumers can verify provenance
+type responseMeta struct {
+	Repo                string `json:"repo,omitempty"`
+	Commit              string `json:"commit,omitempty"`
+	Date                string `json:"date,omitempty"`
+	ScorecardVersion    string `json:"scorecard_version,omitempty"`
+	ScorecardCommit     string `json:"scorecard_commit,omitempty"`
+	AggregateConsistent *bool  `json:"aggregate_consistent,omitempty"`
+}
+
+// responseOptions are the per-request switches that shape the response body
+type responseOptions struct {
+	Verbose          bool
+	Details          bool // also lists the checks, with each one's details
+	AggregatePresent bool
+	Provenance       bool
+	IncludeUnmapped  bool
+	IncludeGrade     bool
+	RiskTiers        map[string]bool // from ?risk=, nil lists no checks by tier
+}
+
+func parseResponseOptions(c *fiber.Ctx) (responseOptions, error) {
+	tiers, err := parseRiskTiers(c.Query("risk"))
+	if err != nil {
+		return responseOptions{}, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return responseOptions{
+		Verbose:          c.QueryBool("verbose"),
+		Details:          c.QueryBool("details"),
+		AggregatePresent: c.QueryBool("aggregate_present"),
+		Provenance:       c.QueryBool("provenance"),
+		IncludeUnmapped:  c.QueryBool("include_unmapped", config.IncludeUnmappedChecks),
+		IncludeGrade:     c.QueryBool("include_grade"),
+		RiskTiers:        tiers,
+	}, nil
+}
+
+// checkDetail describes a single OpenSSF check in verbose output: why it scored as it did
+// and, when details are requested, the findings behind the reason
+type checkDetail struct {
+	Name          string             `json:"name"`
+	Score         int                `json:"score"`
+	Risk          string             `json:"risk,omitempty"`
+	Reason        string             `json:"reason,omitempty"`
+	Details       []string           `json:"details,omitempty"`
+	Documentation checkDocumentation `json:"documentation"`
+}
+
+// checkDocumentation points at the OpenSSF docs for a check
+type checkDocumentation struct {
+	URL   string `json:"url"`
+	Short string `json:"short"`
+}
+
+// newResponse maps the result into the response body; a nil result yields an empty scorecard.
+// With verbose set every check is listed with its reason, documentation link and risk tier,
+// and with details also with its details; with risk tiers requested only the checks in those
+// tiers are listed. With include_grade the aggregate is also given as a letter, N/A when
+// there is no aggregate.
+func newResponse(result *ossf.JSONScorecardResultV2, commitSha, source string, opts responseOptions) *scorecardResponse {
+	if result == nil {
+		resp := &scorecardResponse{Scorecard: *model.NewScorecard()}
+		if opts.IncludeGrade {
+			resp.Grade = gradeNA
+		}
+		return resp
+	}
+
+	resp := &scorecardResponse{Scorecard: *mapChecks(result, commitSha)}
+	resp.ResolvedRef = resolvedRef(source, resp.Pinned)
+	if opts.IncludeGrade {
+		resp.Grade = letterGrade(float64(result.AggregateScore))
+	}
+	if opts.Verbose || opts.Details || opts.RiskTiers != nil {
+		for _, check := range result.Checks {
+			risk := checkRisk(check.Name)
+			if opts.RiskTiers != nil && !opts.RiskTiers[risk] {
+				continue
+			}
+			detail := checkDetail{
+				Name:   check.Name,
+				Score:  check.Score,
+				Risk:   risk,
+				Reason: check.Reason,
+				Documentation: checkDocumentation{
+					URL:   check.Doc.URL,
+					Short: check.Doc.Short,
+				},
+			}
+			if opts.Details {
+				detail.Details = check.Details
+			}
+			resp.Checks = append(resp.Checks, detail)
+		}
+	}
+
+	if opts.AggregatePresent {
+		aggregate := presentAggregate(result)
+		resp.AggregatePresent = &aggregate
+	}
+
+	// Checks without a model field are kept apart under unmapped_checks so a new check
+	// can never shadow one of the mapped fields
+	if opts.IncludeUnmapped {
+		if unmapped := unmappedChecks(result); len(unmapped) > 0 {
+			resp.UnmappedChecks = unmapped
+		}
+	}
+
+	// The V2 result carries the scored commit and analysis date; it has no commit date or tree sha
+	if opts.Provenance {
+		resp.Meta = &responseMeta{
+			Repo:             result.Repo.Name,
+			Commit:           result.Repo.Commit,
+			Date:             result.Date,
+			ScorecardVersion: result.Scorecard.Version,
+			ScorecardCommit:  result.Scorecard.Commit,
+		}
+	}
+
+	// A mismatch is logged when the result is fetched; the response only carries the flag
+	if config.AggregateCheck == "flag" {
+		consistent := aggregateConsistent(result)
+		if resp.Meta == nil {
+			resp.Meta = &responseMeta{}
+		}
+		resp.Meta.AggregateConsistent = &consistent
+	}
+	return resp
+}
+
+// resolvedRef says which result the API served: "commit" when pinned to the requested sha,
+// "latest" when it had no data for the commit (or none was asked for) and returned HEAD,
+// and "cli" when the commit was scanned in-process. Results not fetched by a lookup have none.
+func resolvedRef(source string, pinned bool) string {
+	switch source {
+	case sourceAPI, sourceAPILatest:
+		if source == sourceAPI && pinned {
+			return "commit"
+		}
+		return "latest"
+	case sourceCLI:
+		return "cli"
+	default:
+		return ""
+	}
+}
+
+// presentAggregate recomputes the aggregate over only the checks that ran, giving each
+// equal weight: sum(score) / count for every check with score >= 0. Checks the upstream
+// could not run report -1 and are left out. Returns -1 when no check ran.
+func presentAggregate(result *ossf.JSONScorecardResultV2) float32 {
+	var sum, count int
+	for _, check := range result.Checks {
+		if check.Score < 0 {
+			continue
+		}
+		sum += check.Score
+		count++
+	}
+
+	if count == 0 {
+		return -1
+	}
+	return float32(sum) / float32(count)
+}
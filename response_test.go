@@ -0,0 +1,180 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// getResponse looks target up and decodes the scorecard
+func getResponse(t *testing.T, app *fiber.App, target string) scorecardResponse {
+	t.Helper()
+	status, body := get(t, app, target)
+	if status != fiber.StatusOK {
+		t.Fatalf("%s: status %d, body %s", target, status, body)
+	}
+	var resp scorecardResponse
+	mustJSON(t, body, &resp)
+	return resp
+}
+
+// checkNamed is the check of resp named name
+func checkNamed(t *testing.T, resp scorecardResponse, name string) checkDetail {
+	t.Helper()
+	for _, check := range resp.Checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	t.Fatalf("no %s in %+v", name, resp.Checks)
+	return checkDetail{}
+}
+
+func TestVerboseChecksLinkTheirDocumentation(t *testing.T) {
+	api := newFakeAPI(t)
+	api.serve("github.com/a/b", resultJSON("github.com/a/b", sha(1), 5, map[string]int{"Branch-Protection": 2}))
+	stubScan(t, sha(2), map[string]int{"Branch-Protection": 4}, nil)
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL, "GITHUB_TOKEN": "token"})
+
+	fromAPI := checkNamed(t, getResponse(t, app, "/msapi/scorecard/github.com/a/b?verbose=true"), "Branch-Protection")
+	if fromAPI.Documentation.URL != "https://github.com/ossf/scorecard/blob/main/docs/checks.md#branch-protection" ||
+		fromAPI.Documentation.Short != "Branch-Protection short" {
+		t.Errorf("API documentation %+v", fromAPI.Documentation)
+	}
+
+	scanned := getResponse(t, app, "/msapi/scorecard/github.com/a/c?verbose=true&prefer=cli&commit="+sha(2))
+	if scanned.ResolvedRef != "cli" {
+		t.Fatalf("resolved_ref %q, want the scan", scanned.ResolvedRef)
+	}
+	fromScan := checkNamed(t, scanned, "Branch-Protection")
+	if !strings.HasSuffix(fromScan.Documentation.URL, "/docs/checks.md#branch-protection") || fromScan.Documentation.Short == "" {
+		t.Errorf("scan documentation %+v", fromScan.Documentation)
+	}
+
+	compact := getResponse(t, app, "/msapi/scorecard/github.com/a/b")
+	if compact.Checks != nil {
+		t.Errorf("checks listed without verbose: %+v", compact.Checks)
+	}
+}
+
+func TestVerboseAndCompactShareTheCachedScorecard(t *testing.T) {
+	api := newFakeAPI(t)
+	checks := map[string]int{"Code-Review": 8, "License": 10}
+	api.serve("github.com/a/b", resultJSON("github.com/a/b", sha(1), 9, checks))
+	api.serve("github.com/a/c", resultJSON("github.com/a/c", sha(2), 9, checks))
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL})
+
+	for repo, order := range map[string][]bool{
+		"github.com/a/b": {false, true, false},
+		"github.com/a/c": {true, false, true},
+	} {
+		for _, verbose := range order {
+			target := "/msapi/scorecard/" + repo
+			if verbose {
+				target += "?verbose=true"
+			}
+			resp := getResponse(t, app, target)
+			if verbose && len(resp.Checks) != len(checks) {
+				t.Errorf("%s: checks %+v, want every check listed", target, resp.Checks)
+			}
+			if !verbose && resp.Checks != nil {
+				t.Errorf("%s: checks %+v listed without verbose", target, resp.Checks)
+			}
+			if resp.CodeReview != 8 || resp.License != 10 {
+				t.Errorf("%s: code review %v, license %v", target, resp.CodeReview, resp.License)
+			}
+		}
+		if n := api.called(repo); n != 1 {
+			t.Errorf("%s fetched %d times, want the shapes to share one cached scorecard", repo, n)
+		}
+	}
+}
+
+func TestAggregatePresentLeavesOutTheChecksThatDidNotRun(t *testing.T) {
+	app := newTestApp(t, nil)
+	raw := resultJSON("github.com/a/b", sha(1), 4.2, map[string]int{
+		"Code-Review": 8,
+		"License":     10,
+		"Maintained":  3,
+		"SAST":        -1,
+		"Fuzzing":     -1,
+	})
+
+	_, body := postMap(t, app, "?aggregate_present=true", raw)
+	var resp scorecardResponse
+	mustJSON(t, body, &resp)
+	if resp.Score != 4.2 {
+		t.Errorf("upstream aggregate %v, want it unchanged", resp.Score)
+	}
+	if resp.AggregatePresent == nil || *resp.AggregatePresent != 7 {
+		t.Errorf("aggregate_present %v, want (8+10+3)/3", resp.AggregatePresent)
+	}
+
+	_, body = postMap(t, app, "", raw)
+	if strings.Contains(body, "aggregate_present") {
+		t.Errorf("aggregate_present without the option: %s", body)
+	}
+
+	_, body = postMap(t, app, "?aggregate_present=true", resultJSON("github.com/a/b", sha(1), -1, map[string]int{"SAST": -1}))
+	mustJSON(t, body, &resp)
+	if resp.AggregatePresent == nil || *resp.AggregatePresent != -1 {
+		t.Errorf("aggregate_present %v with no check run, want -1", resp.AggregatePresent)
+	}
+}
+
+func TestProvenanceIsTheSameFromTheAPIAndAScan(t *testing.T) {
+	api := newFakeAPI(t)
+	api.serve("github.com/a/b?commit="+sha(1), resultJSON("github.com/a/b", sha(1), 5, map[string]int{"License": 10}))
+	stubScan(t, sha(2), map[string]int{"License": 10}, nil)
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL, "GITHUB_TOKEN": "token"})
+
+	for _, tt := range []struct {
+		target string
+		want   responseMeta
+	}{
+		{"/msapi/scorecard/github.com/a/b?provenance=true&commit=" + sha(1),
+			responseMeta{Repo: "github.com/a/b", Commit: sha(1), Date: "2024-05-01T00:00:00Z", ScorecardVersion: "v5.0.0", ScorecardCommit: "abc123"}},
+		{"/msapi/scorecard/github.com/a/c?provenance=true&prefer=cli&commit=" + sha(2),
+			responseMeta{Repo: "github.com/a/c", Commit: sha(2), Date: "2024-05-02T00:00:00Z", ScorecardVersion: "v5.0.0", ScorecardCommit: "def456"}},
+	} {
+		resp := getResponse(t, app, tt.target)
+		if resp.Meta == nil || *resp.Meta != tt.want {
+			t.Errorf("%s: meta %+v, want %+v", tt.target, resp.Meta, tt.want)
+		}
+	}
+
+	if resp := getResponse(t, app, "/msapi/scorecard/github.com/a/b?commit="+sha(1)); resp.Meta != nil {
+		t.Errorf("meta without provenance: %+v", resp.Meta)
+	}
+}
+
+func TestResolvedRefNamesTheResultServed(t *testing.T) {
+	api := newFakeAPI(t)
+	api.serve("github.com/a/pinned?commit="+sha(1), resultJSON("github.com/a/pinned", sha(1), 5, nil))
+	api.serve("github.com/a/head", resultJSON("github.com/a/head", sha(3), 5, nil))
+	api.serve("github.com/a/moved?commit="+sha(1), resultJSON("github.com/a/moved", sha(4), 5, nil))
+	github := newFakeAPI(t)
+	github.serve("repos/a/scanned", `{"full_name":"a/scanned"}`)
+	stubScan(t, sha(1), map[string]int{"License": 10}, nil)
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL, "GITHUB_API_URL": github.URL, "GITHUB_TOKEN": "token"})
+
+	for _, tt := range []struct {
+		name, target, want string
+	}{
+		{"pinned to the commit", "github.com/a/pinned?commit=" + sha(1), "commit"},
+		{"no data for the commit", "github.com/a/head?commit=" + sha(1), "latest"},
+		{"no commit asked for", "github.com/a/head", "latest"},
+		{"another commit than asked for", "github.com/a/moved?commit=" + sha(1), "latest"},
+		{"scanned", "github.com/a/scanned?commit=" + sha(1), "cli"},
+	} {
+		if got := getResponse(t, app, "/msapi/scorecard/"+tt.target).ResolvedRef; got != tt.want {
+			t.Errorf("%s: resolved_ref %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	_, body := postMap(t, app, "", resultJSON("github.com/a/b", sha(1), 5, nil))
+	if strings.Contains(body, "resolved_ref") {
+		t.Errorf("resolved_ref on a mapped result: %s", body)
+	}
+}
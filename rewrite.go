@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// rewriteRule maps a normalized repo url onto another, e.g. a public repo onto an internal mirror
+type rewriteRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// parseRewriteRules reads one "regex => replacement" rule per line. Blank lines and lines
+// starting with # are skipped. The replacement may use $1 style references to the groups.
+func parseRewriteRules(text string) ([]rewriteRule, error) {
+	var rules []rewriteRule
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		pattern, replacement, found := strings.Cut(line, "=>")
+		if !found {
+			return nil, fmt.Errorf("rewrite rule %q is not of the form regex => replacement", line)
+		}
+
+		re, err := regexp.Compile(strings.TrimSpace(pattern))
+		if err != nil {
+			return nil, fmt.Errorf("rewrite rule %q: %w", line, err)
+		}
+		rules = append(rules, rewriteRule{pattern: re, replacement: strings.TrimSpace(replacement)})
+	}
+	return rules, nil
+}
+
+// rewriteRepoURL applies the first rule matching the normalized repo url, in configured order
+func rewriteRepoURL(repoURL string) string {
+	for _, rule := range config.RepoRewriteRules {
+		if !rule.pattern.MatchString(repoURL) {
+			continue
+		}
+
+		rewritten := rule.pattern.ReplaceAllString(repoURL, rule.replacement)
+		logger.Info("repo url rewritten", zap.String("repo", repoURL), zap.String("rewritten", rewritten), zap.String("rule", rule.pattern.String()))
+		return rewritten
+	}
+	return repoURL
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRewriteRulesTargetTheMirror(t *testing.T) {
+	logs := observeLogs(t, zapcore.InfoLevel)
+	api := newFakeAPI(t)
+	api.serve("git.internal/mirror/foo/bar", resultJSON("git.internal/mirror/foo/bar", sha(1), 6, nil))
+	app := newTestApp(t, map[string]string{
+		"SCORECARD_API_URLS": api.URL,
+		"GITLAB_HOSTS":       "git.internal",
+		"REPO_REWRITE_RULES": `^github\.com/foo/(.*) => git.internal/mirror/foo/$1
+^github\.com/(.*) => git.internal/mirror/other/$1`,
+	})
+
+	if status, body := get(t, app, "/msapi/scorecard/https://github.com/Foo/Bar.git"); status != fiber.StatusOK {
+		t.Fatalf("status %d, body %s", status, body)
+	}
+	if n := api.called("git.internal/mirror/foo/bar"); n != 1 {
+		t.Errorf("mirror asked %d times", n)
+	}
+	if n := api.called("github.com/foo/bar"); n != 0 {
+		t.Errorf("public repo asked %d times", n)
+	}
+	rewrites := logs.FilterMessage("repo url rewritten").All()
+	if len(rewrites) == 0 || rewrites[0].ContextMap()["rewritten"] != "git.internal/mirror/foo/bar" {
+		t.Errorf("rewrite logged as %+v", rewrites)
+	}
+
+	// the first matching rule wins, and a url no rule matches is left alone
+	for input, want := range map[string]string{
+		"github.com/foo/baz":   "git.internal/mirror/foo/baz",
+		"github.com/other/baz": "git.internal/mirror/other/other/baz",
+		"gitlab.com/g/p":       "gitlab.com/g/p",
+	} {
+		if got := cleanRepoURL(input); got != want {
+			t.Errorf("cleanRepoURL(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestRewriteRulesAreValidated(t *testing.T) {
+	for _, rules := range []string{"github.com/(.*)", `^github\.com/(( => x`} {
+		if _, err := parseRewriteRules(rules); err == nil {
+			t.Errorf("%q was accepted", rules)
+		}
+	}
+	rules, err := parseRewriteRules("# mirrors\n\n a => b \n")
+	if err != nil || len(rules) != 1 || rules[0].replacement != "b" {
+		t.Errorf("rules %+v, %v", rules, err)
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var errNoComponentRepo = errors.New("the component has neither a vcs reference nor a purl naming its source repo")
+
+// sbomDocument is what an SBOM is decoded as: the CycloneDX and SPDX 2.x fields used to
+// find component repos, and the fields that tell the two formats apart
+type sbomDocument struct {
+	BOMFormat   string               `json:"bomFormat"`
+	Components  []cycloneDXComponent `json:"components"`
+	SPDXVersion string               `json:"spdxVersion"`
+	Packages    []spdxPackage        `json:"packages"`
+}
+
+type cycloneDXComponent struct {
+	BOMRef             string `json:"bom-ref"`
+	Name               string `json:"name"`
+	Version            string `json:"version"`
+	Purl               string `json:"purl"`
+	ExternalReferences []struct {
+		Type string `json:"type"`
+		URL  string `json:"url"`
+	} `json:"externalReferences"`
+	Components []cycloneDXComponent `json:"components"`
+}
+
+type spdxPackage struct {
+	SPDXID           string `json:"SPDXID"`
+	Name             string `json:"name"`
+	VersionInfo      string `json:"versionInfo"`
+	DownloadLocation string `json:"downloadLocation"`
+	ExternalRefs     []struct {
+		ReferenceType    string `json:"referenceType"`
+		ReferenceLocator string `json:"referenceLocator"`
+	} `json:"externalRefs"`
+}
+
+// sbomPackage is a component of either format with the references its repo may be found by
+type sbomPackage struct {
+	entry sbomComponent
+	vcs   []string
+	purls []string
+}
+
+// sbomComponent identifies an SBOM component in the response. Error is only set for
+// components that could not be resolved to a repo.
+type sbomComponent struct {
+	BOMRef  string         `json:"bom_ref,omitempty"`
+	Name    string         `json:"name"`
+	Version string         `json:"version,omitempty"`
+	Purl    string         `json:"purl,omitempty"`
+	Error   *errorResponse `json:"error,omitempty"`
+}
+
+// sbomResult is the scorecard of one repo and the components that resolved to it
+type sbomResult struct {
+	batchResult
+	Components []sbomComponent `json:"components"`
+}
+
+// sbomResponse is the body returned for an SBOM. Truncated is set when the SBOM had more
+// than SBOM_MAX_COMPONENTS components and only the first of them were scored.
+type sbomResponse struct {
+	Scorecards      []sbomResult    `json:"scorecards"`
+	Unresolved      []sbomComponent `json:"unresolved"`
+	TotalComponents int             `json:"total_components"`
+	Truncated       bool            `json:"truncated"`
+}
+
+// packages lists the components of the document, nested CycloneDX components included
+func (doc sbomDocument) packages() ([]sbomPackage, error) {
+	switch {
+	case doc.BOMFormat == "CycloneDX":
+		return cycloneDXPackages(doc.Components), nil
+	case strings.HasPrefix(doc.SPDXVersion, "SPDX-2."):
+		return spdxPackages(doc.Packages), nil
+	default:
+		return nil, fiber.NewError(fiber.StatusBadRequest, "the body must be a CycloneDX or SPDX 2.x JSON SBOM")
+	}
+}
+
+// cycloneDXPackages lists the components with their nested components, depth first
+func cycloneDXPackages(components []cycloneDXComponent) []sbomPackage {
+	var all []sbomPackage
+	for _, component := range components {
+		pkg := sbomPackage{entry: sbomComponent{BOMRef: component.BOMRef, Name: component.Name, Version: component.Version, Purl: component.Purl}}
+		for _, ref := range component.ExternalReferences {
+			if ref.Type == "vcs" {
+				pkg.vcs = append(pkg.vcs, ref.URL)
+			}
+		}
+		if component.Purl != "" {
+			pkg.purls = append(pkg.purls, component.Purl)
+		}
+		all = append(all, pkg)
+		all = append(all, cycloneDXPackages(component.Components)...)
+	}
+	return all
+}
+
+// spdxPackages lists the SPDX packages. Only a git+ downloadLocation names a repo; plain
+// download urls usually point at a registry archive instead.
+func spdxPackages(packages []spdxPackage) []sbomPackage {
+	all := make([]sbomPackage, 0, len(packages))
+	for _, p := range packages {
+		pkg := sbomPackage{entry: sbomComponent{BOMRef: p.SPDXID, Name: p.Name, Version: p.VersionInfo}}
+		if strings.HasPrefix(p.DownloadLocation, "git+") {
+			pkg.vcs = append(pkg.vcs, p.DownloadLocation)
+		}
+		for _, ref := range p.ExternalRefs {
+			if ref.ReferenceType == "purl" {
+				pkg.purls = append(pkg.purls, ref.ReferenceLocator)
+			}
+		}
+		if len(pkg.purls) > 0 {
+			pkg.entry.Purl = pkg.purls[0]
+		}
+		all = append(all, pkg)
+	}
+	return all
+}
+
+// splitVCSRevision separates the @revision an SPDX style vcs location may end with,
+// e.g. git+https://github.com/org/repo@v1.2#path, from the repo url
+func splitVCSRevision(location string) (string, string) {
+	location, _, _ = strings.Cut(location, "#")
+	_, path, found := strings.Cut(location, "://")
+	if !found {
+		path = location
+	}
+	slash := strings.Index(path, "/")
+	if at := strings.LastIndex(path, "@"); slash >= 0 && at > slash {
+		return location[:len(location)-len(path)+at], path[at+1:]
+	}
+	return location, ""
+}
+
+// resolveComponent finds the repo and commit of a component: its vcs reference when that
+// names a repo, otherwise its purls in order, resolving forge purl versions with token
+func resolveComponent(pkg sbomPackage, token string) (string, string, error) {
+	for _, location := range pkg.vcs {
+		location, revision := splitVCSRevision(location)
+		if repo := cleanRepoURL(location); validateRepoURL(repo) == nil {
+			return repo, revision, nil
+		}
+	}
+
+	err := errNoComponentRepo
+	for _, purl := range pkg.purls {
+		var repo, commit string
+		if repo, commit, err = resolvePurl(purl, token); err != nil {
+			continue
+		}
+		if err = validateRepoURL(repo); err == nil {
+			return repo, commit, nil
+		}
+	}
+	return "", "", err
+}
+
+// getSBOMScorecards godoc
+// @Summary Get the OSSF scorecards for the components of an SBOM
+// @Description Resolve every component of a CycloneDX or SPDX 2.x JSON SBOM, nested ones included, to
+// @Description its source repo and score each distinct repo once. A component resolves through its vcs
+// @Description external reference or git+ downloadLocation, else its purl as on the purl endpoint. Components that resolve to no repo are
+// @Description listed under unresolved. Components are resolved and repos scored SBOM_CONCURRENCY at
+// @Description a time. Only the first SBOM_MAX_COMPONENTS components are scored, and truncated is set
+// @Description when there were more; total_components counts them all.
+// @Tags scorecard
+// @Accept json
+// @Produce json
+// @Param sbom body object true "CycloneDX or SPDX 2.x JSON SBOM"
+// @Param verbose query bool false "include per-check scores, reasons and documentation links"
+// @Param details query bool false "list the checks as verbose does, each with its details"
+// @Param aggregate_present query bool false "add aggregate_present, the equal-weighted mean of the checks that ran"
+// @Param provenance query bool false "add meta with the scored repo, commit, analysis date and scorecard version"
+// @Param include_grade query bool false "add grade, the aggregate as a letter on the GRADE_THRESHOLDS scale or N/A without an aggregate"
+// @Param risk query string false "list only the checks in these comma separated risk tiers, e.g. Critical,High"
+// @Param include_unmapped query bool false "add unmapped_checks, the scores of checks with no scorecard field; defaults to INCLUDE_UNMAPPED_CHECKS"
+// @Param X-Repo-Token header string false "forge token used instead of the configured one to scan a private repo; Authorization: Bearer also works"
+// @Success 200 {object} sbomResponse
+// @Failure 400
+// @Router /msapi/scorecard/sbom [post]
+func getSBOMScorecards(c *fiber.Ctx) error {
+	var doc sbomDocument
+	if err := json.Unmarshal(c.Body(), &doc); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	components, err := doc.packages()
+	if err != nil {
+		return err
+	}
+
+	opts, err := parseResponseOptions(c)
+	if err != nil {
+		return err
+	}
+
+	resp := sbomResponse{Scorecards: []sbomResult{}, Unresolved: []sbomComponent{}, TotalComponents: len(components)}
+	if len(components) > config.SBOMMaxComponents {
+		components = components[:config.SBOMMaxComponents]
+		resp.Truncated = true
+	}
+
+	type resolution struct {
+		item batchItem
+		err  error
+	}
+	token := requestToken(c)
+	resolved := make([]resolution, len(components))
+	runConcurrently(len(components), config.SBOMConcurrency, func(i int) {
+		repo, commit, err := resolveComponent(components[i], token)
+		resolved[i] = resolution{batchItem{Repo: repo, Commit: commit}, err}
+	})
+
+	index := map[batchItem]int{}
+	for i, component := range components {
+		entry := component.entry
+		if err := resolved[i].err; err != nil {
+			_, code := lookupErrorStatus(err)
+			entry.Error = &errorResponse{Code: code, Message: err.Error()}
+			resp.Unresolved = append(resp.Unresolved, entry)
+			continue
+		}
+
+		item := resolved[i].item
+		n, seen := index[item]
+		if !seen {
+			n = len(resp.Scorecards)
+			index[item] = n
+			resp.Scorecards = append(resp.Scorecards, sbomResult{batchResult: batchResult{Repo: item.Repo, Commit: item.Commit}})
+		}
+		resp.Scorecards[n].Components = append(resp.Scorecards[n].Components, entry)
+	}
+
+	runConcurrently(len(resp.Scorecards), config.SBOMConcurrency, func(i int) {
+		result := &resp.Scorecards[i]
+		result.batchResult = scoreRepo(result.Repo, result.Commit, token, opts)
+	})
+	return c.JSON(resp)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// cycloneDX is a CycloneDX SBOM of n components, each with a vcs reference to github.com/o/rN
+func cycloneDX(n int) string {
+	components := make([]map[string]any, 0, n)
+	for i := 1; i <= n; i++ {
+		components = append(components, map[string]any{
+			"bom-ref":            fmt.Sprintf("c%d", i),
+			"name":               fmt.Sprintf("r%d", i),
+			"externalReferences": []map[string]string{{"type": "vcs", "url": fmt.Sprintf("https://github.com/o/r%d", i)}},
+		})
+	}
+	body, _ := json.Marshal(map[string]any{"bomFormat": "CycloneDX", "components": components})
+	return string(body)
+}
+
+func postSBOM(t *testing.T, app *fiber.App, sbom string) sbomResponse {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodPost, "/msapi/scorecard/sbom", strings.NewReader(sbom))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	status, body := doRequest(t, app, req)
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d, body %s", status, body)
+	}
+	var resp sbomResponse
+	mustJSON(t, body, &resp)
+	return resp
+}
+
+func TestSBOMIsTruncatedAtMaxComponents(t *testing.T) {
+	api := newFakeAPI(t)
+	for i := 1; i <= 5; i++ {
+		repo := fmt.Sprintf("github.com/o/r%d", i)
+		api.serve(repo, resultJSON(repo, sha(i), float64(i), nil))
+	}
+	app := newTestApp(t, map[string]string{
+		"SCORECARD_API_URLS":  api.URL,
+		"SBOM_MAX_COMPONENTS": "3",
+		"SBOM_CONCURRENCY":    "2",
+	})
+
+	resp := postSBOM(t, app, cycloneDX(5))
+	if !resp.Truncated || resp.TotalComponents != 5 {
+		t.Fatalf("truncated %v of %d components, want true of 5", resp.Truncated, resp.TotalComponents)
+	}
+	if len(resp.Scorecards) != 3 {
+		t.Fatalf("%d scorecards, want the first 3 components'", len(resp.Scorecards))
+	}
+	for i, result := range resp.Scorecards {
+		if want := fmt.Sprintf("github.com/o/r%d", i+1); result.Repo != want || result.Scorecard == nil {
+			t.Errorf("scorecard %d is %+v, want %s", i, result.batchResult, want)
+		}
+	}
+	if n := api.called("github.com/o/r4") + api.called("github.com/o/r5"); n != 0 {
+		t.Errorf("the components past the cap were looked up %d times", n)
+	}
+
+	resp = postSBOM(t, app, cycloneDX(3))
+	if resp.Truncated || resp.TotalComponents != 3 || len(resp.Scorecards) != 3 {
+		t.Errorf("at the cap: truncated %v, %d components, %d scorecards", resp.Truncated, resp.TotalComponents, len(resp.Scorecards))
+	}
+}
+
+func TestSBOMSettings(t *testing.T) {
+	cfg := testConfig(t, nil)
+	if cfg.SBOMConcurrency != 8 || cfg.SBOMMaxComponents != 1000 {
+		t.Errorf("defaults %d and %d", cfg.SBOMConcurrency, cfg.SBOMMaxComponents)
+	}
+	for _, name := range []string{"SBOM_CONCURRENCY", "SBOM_MAX_COMPONENTS"} {
+		if _, err := loadConfig(func(n string) string {
+			if n == name {
+				return "0"
+			}
+			return ""
+		}); err == nil {
+			t.Errorf("%s=0 was accepted", name)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/ossf/scorecard/v5/clients"
+	"github.com/ossf/scorecard/v5/clients/githubrepo"
+	"github.com/ossf/scorecard/v5/clients/gitlabrepo"
+	"github.com/ossf/scorecard/v5/clients/localdir"
+	sclog "github.com/ossf/scorecard/v5/log"
+	ossf "github.com/ossf/scorecard/v5/pkg/scorecard"
+	"go.uber.org/zap"
+)
+
+// runScan is the scorecard library's scan, a variable so tests can stand in for it
+var runScan = ossf.Run
+
+// scanEligible reports whether a lookup may fall back to a scan. GitHub needs GITHUB_TOKEN
+// and GitLab works without a token; both only scan a requested commit. The clone forges
+// and GitHub Enterprise are not scored by the API at all, so their HEAD is scanned as well.
+// Only the GH_HOST instance can be scanned, the library talks to no other GitHub.
+// githubToken is the caller's token or GITHUB_TOKEN.
+func scanEligible(repoURL, commitSha, githubToken string) bool {
+	if _, ok := cloneForgeFor(repoURL); ok {
+		return true
+	}
+
+	switch {
+	case isGitHubEnterprise(repoURL):
+		return githubToken != ""
+	case commitSha == "":
+		return false
+	case isGitLabRepo(repoURL):
+		return true
+	default:
+		return onConfiguredGitHub(repoURL) && githubToken != ""
+	}
+}
+
+// scanScoreCard scores the commit in-process with the scorecard library, the fallback when
+// the API has no result. It is still the "cli" stage and source in the API so existing
+// clients keep working. GitHub repos are scanned with GITHUB_TOKEN, which the GitHub client
+// reads from the environment itself; GitLab repos with GITLAB_AUTH_TOKEN when it is set.
+// Repos on forges the library has no client for, such as Bitbucket, Azure DevOps and Gitea, are
+// cloned and scanned as a local directory, which runs only the file based checks.
+// A token passed with the request replaces the configured credentials for the forge.
+// A failed scan yields no result, except when GitHub rejected the token, which is
+// errTokenInvalid, or errRequestTokenInvalid for the caller's token, and a scan that gets
+// no turn in the scan queue, which is errScanBusy. The scan, clone included, is abandoned
+// after SCAN_TIMEOUT. Scans are bounded by SCAN_CONCURRENCY alone, they take no outbound slot
+// so a long scan never holds back the API calls of other lookups.
+func scanScoreCard(repoURL, commitSha, token string) (*ossf.JSONScorecardResultV2, error) {
+	leave, err := scans.enter()
+	if err != nil {
+		return nil, err
+	}
+	defer leave()
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.ScanTimeout)
+	defer cancel()
+
+	opts := []ossf.Option{ossf.WithLogLevel(sclog.WarnLevel)}
+
+	var repo clients.Repo
+	var clonedSha string
+	forge, cloned := cloneForgeFor(repoURL)
+	switch {
+	case cloned:
+		var dir string
+		if dir, clonedSha, err = cloneRepo(ctx, forge, repoURL, commitSha, token); err != nil {
+			if token != "" && (errors.Is(err, transport.ErrAuthenticationRequired) || errors.Is(err, transport.ErrAuthorizationFailed)) {
+				return nil, errRequestTokenInvalid
+			}
+			logger.Warn("scorecard scan failed", zap.String("repo", repoURL), zap.Error(err))
+			return nil, nil
+		}
+		defer os.RemoveAll(dir)
+
+		if repo, err = localdir.MakeLocalDirRepo(dir); err != nil {
+			return nil, nil
+		}
+	case isGitLabRepo(repoURL):
+		if repo, err = gitlabrepo.MakeGitlabRepo(repoURL); err != nil {
+			return nil, nil
+		}
+		gitlabToken := token
+		if gitlabToken == "" {
+			gitlabToken = config.GitLabToken
+		}
+		client, err := gitlabrepo.CreateGitlabClientWithToken(ctx, gitlabToken, repo.Host())
+		if err != nil {
+			logger.Warn("creating the gitlab client failed", zap.String("repo", repoURL), zap.Error(err))
+			return nil, nil
+		}
+		opts = append(opts, ossf.WithCommitSHA(commitSha), ossf.WithRepoClient(client))
+	default:
+		if repo, err = githubrepo.MakeGithubRepo(repoURL); err != nil {
+			return nil, nil
+		}
+		opts = append(opts, ossf.WithCommitSHA(commitSha))
+		if token != "" {
+			transport := &tokenTransport{token: token, base: http.DefaultTransport}
+			opts = append(opts, ossf.WithRepoClient(githubrepo.CreateGithubRepoClientWithTransport(ctx, transport)))
+		}
+	}
+
+	start := time.Now()
+	res, err := runScan(ctx, repo, opts...)
+	recordScanUsage(time.Since(start))
+	if err != nil {
+		if isAuthFailure(err.Error()) && token != "" {
+			return nil, errRequestTokenInvalid
+		}
+		if isAuthFailure(err.Error()) {
+			logger.Warn("scorecard scan was refused by GitHub, GITHUB_TOKEN is invalid or expired", zap.String("repo", repoURL))
+			return nil, errTokenInvalid
+		}
+		logger.Warn("scorecard scan failed", zap.String("repo", repoURL), zap.Error(err))
+		return nil, nil
+	}
+
+	// AsJSON2 is the same document the API serves, so both sources decode alike; the API
+	// includes the check details, so the scan does too
+	var out bytes.Buffer
+	if err := res.AsJSON2(&out, checkDocs, &ossf.AsJSON2ResultOption{LogLevel: sclog.DefaultLevel, Details: true}); err != nil {
+		return nil, err
+	}
+
+	var result ossf.JSONScorecardResultV2
+	if err := decodeResult(out.Bytes(), &result); err != nil {
+		return nil, err
+	}
+
+	// a local scan only knows the temporary directory, not the repo it came from
+	if clonedSha != "" {
+		result.Repo.Name = repoURL
+		result.Repo.Commit = clonedSha
+	}
+	return &result, nil
+}
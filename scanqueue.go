@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var errScanBusy = errors.New("too many scans are running, retry later")
+
+// scanQueue bounds the in-process scans, each of which makes many GitHub API calls, so a
+// burst of unscored repos cannot exhaust the token's rate limit. At most `running` scans
+// run at once; up to `queued` more wait for a turn, each for at most `wait`, and a scan
+// that finds the queue full or waits too long fails with errScanBusy.
+type scanQueue struct {
+	mu      sync.Mutex
+	slots   chan struct{}
+	waiting int
+	queued  int
+	wait    time.Duration
+}
+
+func newScanQueue(running, queued int, wait time.Duration) *scanQueue {
+	return &scanQueue{slots: make(chan struct{}, running), queued: queued, wait: wait}
+}
+
+// enter waits for a turn to scan and returns the function that ends it
+func (q *scanQueue) enter() (func(), error) {
+	select {
+	case q.slots <- struct{}{}:
+		return q.leave, nil
+	default:
+	}
+
+	q.mu.Lock()
+	if q.waiting >= q.queued {
+		q.mu.Unlock()
+		scanMetrics.Add("rejected", 1)
+		return nil, fmt.Errorf("%w: the scan queue is full", errScanBusy)
+	}
+	q.waiting++
+	q.mu.Unlock()
+	defer func() {
+		q.mu.Lock()
+		q.waiting--
+		q.mu.Unlock()
+	}()
+
+	scanMetrics.Add("queued", 1)
+	timer := time.NewTimer(q.wait)
+	defer timer.Stop()
+	select {
+	case q.slots <- struct{}{}:
+		return q.leave, nil
+	case <-timer.C:
+		scanMetrics.Add("rejected", 1)
+		return nil, fmt.Errorf("%w: no scan finished within SCAN_QUEUE_TIMEOUT", errScanBusy)
+	}
+}
+
+func (q *scanQueue) leave() {
+	<-q.slots
+}
+
+// scans is rebuilt by setupRoutes with SCAN_CONCURRENCY, SCAN_QUEUE_LENGTH and SCAN_QUEUE_TIMEOUT
+var scans = newScanQueue(config.ScanConcurrency, config.ScanQueueLength, config.ScanQueueTimeout)
@@ -0,0 +1,374 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: scorecard.proto
+
+// Package ortelius.scorecard mirrors the model.Scorecard struct from scec-commons
+// so consumers can request a compact binary encoding of the scorecard.
+
+package scorecardpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Scorecard is a collapsed version of the OpenSSF scorecard for a repo + commit.
+type Scorecard struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CommitSha            string  `protobuf:"bytes,1,opt,name=commit_sha,json=commitSha,proto3" json:"commit_sha,omitempty"`
+	Pinned               bool    `protobuf:"varint,2,opt,name=pinned,proto3" json:"pinned,omitempty"`
+	Score                float32 `protobuf:"fixed32,3,opt,name=score,proto3" json:"score,omitempty"`
+	Maintained           float32 `protobuf:"fixed32,4,opt,name=maintained,proto3" json:"maintained,omitempty"`
+	CodeReview           float32 `protobuf:"fixed32,5,opt,name=code_review,json=codeReview,proto3" json:"code_review,omitempty"`
+	CiiBestPractices     float32 `protobuf:"fixed32,6,opt,name=cii_best_practices,json=ciiBestPractices,proto3" json:"cii_best_practices,omitempty"`
+	License              float32 `protobuf:"fixed32,7,opt,name=license,proto3" json:"license,omitempty"`
+	SignedReleases       float32 `protobuf:"fixed32,8,opt,name=signed_releases,json=signedReleases,proto3" json:"signed_releases,omitempty"`
+	DangerousWorkflow    float32 `protobuf:"fixed32,9,opt,name=dangerous_workflow,json=dangerousWorkflow,proto3" json:"dangerous_workflow,omitempty"`
+	Packaging            float32 `protobuf:"fixed32,10,opt,name=packaging,proto3" json:"packaging,omitempty"`
+	TokenPermissions     float32 `protobuf:"fixed32,11,opt,name=token_permissions,json=tokenPermissions,proto3" json:"token_permissions,omitempty"`
+	BranchProtection     float32 `protobuf:"fixed32,12,opt,name=branch_protection,json=branchProtection,proto3" json:"branch_protection,omitempty"`
+	BinaryArtifacts      float32 `protobuf:"fixed32,13,opt,name=binary_artifacts,json=binaryArtifacts,proto3" json:"binary_artifacts,omitempty"`
+	PinnedDependencies   float32 `protobuf:"fixed32,14,opt,name=pinned_dependencies,json=pinnedDependencies,proto3" json:"pinned_dependencies,omitempty"`
+	SecurityPolicy       float32 `protobuf:"fixed32,15,opt,name=security_policy,json=securityPolicy,proto3" json:"security_policy,omitempty"`
+	Fuzzing              float32 `protobuf:"fixed32,16,opt,name=fuzzing,proto3" json:"fuzzing,omitempty"`
+	Sast                 float32 `protobuf:"fixed32,17,opt,name=sast,proto3" json:"sast,omitempty"`
+	Vulnerabilities      float32 `protobuf:"fixed32,18,opt,name=vulnerabilities,proto3" json:"vulnerabilities,omitempty"`
+	CiTests              float32 `protobuf:"fixed32,19,opt,name=ci_tests,json=ciTests,proto3" json:"ci_tests,omitempty"`
+	Contributors         float32 `protobuf:"fixed32,20,opt,name=contributors,proto3" json:"contributors,omitempty"`
+	DependencyUpdateTool float32 `protobuf:"fixed32,21,opt,name=dependency_update_tool,json=dependencyUpdateTool,proto3" json:"dependency_update_tool,omitempty"`
+	Sbom                 float32 `protobuf:"fixed32,22,opt,name=sbom,proto3" json:"sbom,omitempty"`
+	Webhooks             float32 `protobuf:"fixed32,23,opt,name=webhooks,proto3" json:"webhooks,omitempty"`
+}
+
+func (x *Scorecard) Reset() {
+	*x = Scorecard{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_scorecard_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Scorecard) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Scorecard) ProtoMessage() {}
+
+func (x *Scorecard) ProtoReflect() protoreflect.Message {
+	mi := &file_scorecard_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Scorecard.ProtoReflect.Descriptor instead.
+func (*Scorecard) Descriptor() ([]byte, []int) {
+	return file_scorecard_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Scorecard) GetCommitSha() string {
+	if x != nil {
+		return x.CommitSha
+	}
+	return ""
+}
+
+func (x *Scorecard) GetPinned() bool {
+	if x != nil {
+		return x.Pinned
+	}
+	return false
+}
+
+func (x *Scorecard) GetScore() float32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *Scorecard) GetMaintained() float32 {
+	if x != nil {
+		return x.Maintained
+	}
+	return 0
+}
+
+func (x *Scorecard) GetCodeReview() float32 {
+	if x != nil {
+		return x.CodeReview
+	}
+	return 0
+}
+
+func (x *Scorecard) GetCiiBestPractices() float32 {
+	if x != nil {
+		return x.CiiBestPractices
+	}
+	return 0
+}
+
+func (x *Scorecard) GetLicense() float32 {
+	if x != nil {
+		return x.License
+	}
+	return 0
+}
+
+func (x *Scorecard) GetSignedReleases() float32 {
+	if x != nil {
+		return x.SignedReleases
+	}
+	return 0
+}
+
+func (x *Scorecard) GetDangerousWorkflow() float32 {
+	if x != nil {
+		return x.DangerousWorkflow
+	}
+	return 0
+}
+
+func (x *Scorecard) GetPackaging() float32 {
+	if x != nil {
+		return x.Packaging
+	}
+	return 0
+}
+
+func (x *Scorecard) GetTokenPermissions() float32 {
+	if x != nil {
+		return x.TokenPermissions
+	}
+	return 0
+}
+
+func (x *Scorecard) GetBranchProtection() float32 {
+	if x != nil {
+		return x.BranchProtection
+	}
+	return 0
+}
+
+func (x *Scorecard) GetBinaryArtifacts() float32 {
+	if x != nil {
+		return x.BinaryArtifacts
+	}
+	return 0
+}
+
+func (x *Scorecard) GetPinnedDependencies() float32 {
+	if x != nil {
+		return x.PinnedDependencies
+	}
+	return 0
+}
+
+func (x *Scorecard) GetSecurityPolicy() float32 {
+	if x != nil {
+		return x.SecurityPolicy
+	}
+	return 0
+}
+
+func (x *Scorecard) GetFuzzing() float32 {
+	if x != nil {
+		return x.Fuzzing
+	}
+	return 0
+}
+
+func (x *Scorecard) GetSast() float32 {
+	if x != nil {
+		return x.Sast
+	}
+	return 0
+}
+
+func (x *Scorecard) GetVulnerabilities() float32 {
+	if x != nil {
+		return x.Vulnerabilities
+	}
+	return 0
+}
+
+func (x *Scorecard) GetCiTests() float32 {
+	if x != nil {
+		return x.CiTests
+	}
+	return 0
+}
+
+func (x *Scorecard) GetContributors() float32 {
+	if x != nil {
+		return x.Contributors
+	}
+	return 0
+}
+
+func (x *Scorecard) GetDependencyUpdateTool() float32 {
+	if x != nil {
+		return x.DependencyUpdateTool
+	}
+	return 0
+}
+
+func (x *Scorecard) GetSbom() float32 {
+	if x != nil {
+		return x.Sbom
+	}
+	return 0
+}
+
+func (x *Scorecard) GetWebhooks() float32 {
+	if x != nil {
+		return x.Webhooks
+	}
+	return 0
+}
+
+var File_scorecard_proto protoreflect.FileDescriptor
+
+var file_scorecard_proto_rawDesc = []byte{
+	0x0a, 0x0f, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x63, 0x61, 0x72, 0x64, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x12, 0x12, 0x6f, 0x72, 0x74, 0x65, 0x6c, 0x69, 0x75, 0x73, 0x2e, 0x73, 0x63, 0x6f, 0x72,
+	0x65, 0x63, 0x61, 0x72, 0x64, 0x22, 0xb3, 0x06, 0x0a, 0x09, 0x53, 0x63, 0x6f, 0x72, 0x65, 0x63,
+	0x61, 0x72, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x5f, 0x73, 0x68,
+	0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x53,
+	0x68, 0x61, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x69, 0x6e, 0x6e, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x06, 0x70, 0x69, 0x6e, 0x6e, 0x65, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x63,
+	0x6f, 0x72, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x02, 0x52, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65,
+	0x12, 0x1e, 0x0a, 0x0a, 0x6d, 0x61, 0x69, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x64, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x02, 0x52, 0x0a, 0x6d, 0x61, 0x69, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x64,
+	0x12, 0x1f, 0x0a, 0x0b, 0x63, 0x6f, 0x64, 0x65, 0x5f, 0x72, 0x65, 0x76, 0x69, 0x65, 0x77, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0a, 0x63, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x76, 0x69, 0x65,
+	0x77, 0x12, 0x2c, 0x0a, 0x12, 0x63, 0x69, 0x69, 0x5f, 0x62, 0x65, 0x73, 0x74, 0x5f, 0x70, 0x72,
+	0x61, 0x63, 0x74, 0x69, 0x63, 0x65, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x02, 0x52, 0x10, 0x63,
+	0x69, 0x69, 0x42, 0x65, 0x73, 0x74, 0x50, 0x72, 0x61, 0x63, 0x74, 0x69, 0x63, 0x65, 0x73, 0x12,
+	0x18, 0x0a, 0x07, 0x6c, 0x69, 0x63, 0x65, 0x6e, 0x73, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x02,
+	0x52, 0x07, 0x6c, 0x69, 0x63, 0x65, 0x6e, 0x73, 0x65, 0x12, 0x27, 0x0a, 0x0f, 0x73, 0x69, 0x67,
+	0x6e, 0x65, 0x64, 0x5f, 0x72, 0x65, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x73, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x02, 0x52, 0x0e, 0x73, 0x69, 0x67, 0x6e, 0x65, 0x64, 0x52, 0x65, 0x6c, 0x65, 0x61, 0x73,
+	0x65, 0x73, 0x12, 0x2d, 0x0a, 0x12, 0x64, 0x61, 0x6e, 0x67, 0x65, 0x72, 0x6f, 0x75, 0x73, 0x5f,
+	0x77, 0x6f, 0x72, 0x6b, 0x66, 0x6c, 0x6f, 0x77, 0x18, 0x09, 0x20, 0x01, 0x28, 0x02, 0x52, 0x11,
+	0x64, 0x61, 0x6e, 0x67, 0x65, 0x72, 0x6f, 0x75, 0x73, 0x57, 0x6f, 0x72, 0x6b, 0x66, 0x6c, 0x6f,
+	0x77, 0x12, 0x1c, 0x0a, 0x09, 0x70, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x69, 0x6e, 0x67, 0x18, 0x0a,
+	0x20, 0x01, 0x28, 0x02, 0x52, 0x09, 0x70, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x69, 0x6e, 0x67, 0x12,
+	0x2b, 0x0a, 0x11, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x5f, 0x70, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x02, 0x52, 0x10, 0x74, 0x6f, 0x6b, 0x65,
+	0x6e, 0x50, 0x65, 0x72, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x2b, 0x0a, 0x11,
+	0x62, 0x72, 0x61, 0x6e, 0x63, 0x68, 0x5f, 0x70, 0x72, 0x6f, 0x74, 0x65, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x02, 0x52, 0x10, 0x62, 0x72, 0x61, 0x6e, 0x63, 0x68, 0x50,
+	0x72, 0x6f, 0x74, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x29, 0x0a, 0x10, 0x62, 0x69, 0x6e,
+	0x61, 0x72, 0x79, 0x5f, 0x61, 0x72, 0x74, 0x69, 0x66, 0x61, 0x63, 0x74, 0x73, 0x18, 0x0d, 0x20,
+	0x01, 0x28, 0x02, 0x52, 0x0f, 0x62, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x41, 0x72, 0x74, 0x69, 0x66,
+	0x61, 0x63, 0x74, 0x73, 0x12, 0x2f, 0x0a, 0x13, 0x70, 0x69, 0x6e, 0x6e, 0x65, 0x64, 0x5f, 0x64,
+	0x65, 0x70, 0x65, 0x6e, 0x64, 0x65, 0x6e, 0x63, 0x69, 0x65, 0x73, 0x18, 0x0e, 0x20, 0x01, 0x28,
+	0x02, 0x52, 0x12, 0x70, 0x69, 0x6e, 0x6e, 0x65, 0x64, 0x44, 0x65, 0x70, 0x65, 0x6e, 0x64, 0x65,
+	0x6e, 0x63, 0x69, 0x65, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x73, 0x65, 0x63, 0x75, 0x72, 0x69, 0x74,
+	0x79, 0x5f, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0e,
+	0x73, 0x65, 0x63, 0x75, 0x72, 0x69, 0x74, 0x79, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x18,
+	0x0a, 0x07, 0x66, 0x75, 0x7a, 0x7a, 0x69, 0x6e, 0x67, 0x18, 0x10, 0x20, 0x01, 0x28, 0x02, 0x52,
+	0x07, 0x66, 0x75, 0x7a, 0x7a, 0x69, 0x6e, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x61, 0x73, 0x74,
+	0x18, 0x11, 0x20, 0x01, 0x28, 0x02, 0x52, 0x04, 0x73, 0x61, 0x73, 0x74, 0x12, 0x28, 0x0a, 0x0f,
+	0x76, 0x75, 0x6c, 0x6e, 0x65, 0x72, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x18,
+	0x12, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0f, 0x76, 0x75, 0x6c, 0x6e, 0x65, 0x72, 0x61, 0x62, 0x69,
+	0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x12, 0x19, 0x0a, 0x08, 0x63, 0x69, 0x5f, 0x74, 0x65, 0x73,
+	0x74, 0x73, 0x18, 0x13, 0x20, 0x01, 0x28, 0x02, 0x52, 0x07, 0x63, 0x69, 0x54, 0x65, 0x73, 0x74,
+	0x73, 0x12, 0x22, 0x0a, 0x0c, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x6f, 0x72,
+	0x73, 0x18, 0x14, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0c, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x69, 0x62,
+	0x75, 0x74, 0x6f, 0x72, 0x73, 0x12, 0x34, 0x0a, 0x16, 0x64, 0x65, 0x70, 0x65, 0x6e, 0x64, 0x65,
+	0x6e, 0x63, 0x79, 0x5f, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x5f, 0x74, 0x6f, 0x6f, 0x6c, 0x18,
+	0x15, 0x20, 0x01, 0x28, 0x02, 0x52, 0x14, 0x64, 0x65, 0x70, 0x65, 0x6e, 0x64, 0x65, 0x6e, 0x63,
+	0x79, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x54, 0x6f, 0x6f, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x73,
+	0x62, 0x6f, 0x6d, 0x18, 0x16, 0x20, 0x01, 0x28, 0x02, 0x52, 0x04, 0x73, 0x62, 0x6f, 0x6d, 0x12,
+	0x1a, 0x0a, 0x08, 0x77, 0x65, 0x62, 0x68, 0x6f, 0x6f, 0x6b, 0x73, 0x18, 0x17, 0x20, 0x01, 0x28,
+	0x02, 0x52, 0x08, 0x77, 0x65, 0x62, 0x68, 0x6f, 0x6f, 0x6b, 0x73, 0x42, 0x30, 0x5a, 0x2e, 0x67,
+	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6f, 0x72, 0x74, 0x65, 0x6c, 0x69,
+	0x75, 0x73, 0x2f, 0x73, 0x63, 0x65, 0x63, 0x2d, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x63, 0x61, 0x72,
+	0x64, 0x2f, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x63, 0x61, 0x72, 0x64, 0x70, 0x62, 0x62, 0x06, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_scorecard_proto_rawDescOnce sync.Once
+	file_scorecard_proto_rawDescData = file_scorecard_proto_rawDesc
+)
+
+func file_scorecard_proto_rawDescGZIP() []byte {
+	file_scorecard_proto_rawDescOnce.Do(func() {
+		file_scorecard_proto_rawDescData = protoimpl.X.CompressGZIP(file_scorecard_proto_rawDescData)
+	})
+	return file_scorecard_proto_rawDescData
+}
+
+var file_scorecard_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_scorecard_proto_goTypes = []any{
+	(*Scorecard)(nil), // 0: ortelius.scorecard.Scorecard
+}
+var file_scorecard_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_scorecard_proto_init() }
+func file_scorecard_proto_init() {
+	if File_scorecard_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_scorecard_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Scorecard); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_scorecard_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_scorecard_proto_goTypes,
+		DependencyIndexes: file_scorecard_proto_depIdxs,
+		MessageInfos:      file_scorecard_proto_msgTypes,
+	}.Build()
+	File_scorecard_proto = out.File
+	file_scorecard_proto_rawDesc = nil
+	file_scorecard_proto_goTypes = nil
+	file_scorecard_proto_depIdxs = nil
+}
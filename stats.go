@@ -0,0 +1,68 @@
+package main
+
+import (
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// repoSet remembers which repos have been scored. It holds at most limit repos; once
+// full, new repos are no longer counted and the count is reported as saturated.
+type repoSet struct {
+	mu        sync.Mutex
+	limit     int
+	since     time.Time
+	seen      map[string]struct{}
+	saturated bool
+}
+
+// repoStats is the body returned by the stats endpoint
+type repoStats struct {
+	DistinctRepos int       `json:"distinct_repos"`
+	Saturated     bool      `json:"saturated"`
+	Since         time.Time `json:"since"`
+}
+
+func newRepoSet(limit int) *repoSet {
+	return &repoSet{limit: limit, since: time.Now(), seen: make(map[string]struct{})}
+}
+
+func (s *repoSet) add(repo string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.seen[repo]; ok {
+		return
+	}
+	if len(s.seen) >= s.limit {
+		s.saturated = true
+		return
+	}
+	s.seen[repo] = struct{}{}
+}
+
+func (s *repoSet) stats() repoStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return repoStats{DistinctRepos: len(s.seen), Saturated: s.saturated, Since: s.since}
+}
+
+// scoredRepos is rebuilt by setupRoutes with DISTINCT_REPOS_LIMIT
+var scoredRepos = newRepoSet(config.DistinctReposLimit)
+
+func init() {
+	expvar.Publish("scorecard_repos", expvar.Func(func() any { return scoredRepos.stats() }))
+}
+
+// recordScored counts a repo that a lookup returned a scorecard for
+func recordScored(repo string) {
+	scoredRepos.add(repo)
+}
+
+// StatsHandler reports the number of distinct repos scored since startup. Saturated means
+// DISTINCT_REPOS_LIMIT was reached and later repos were not counted.
+func StatsHandler(c *fiber.Ctx) error {
+	return c.JSON(scoredRepos.stats())
+}
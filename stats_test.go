@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestStatsCountDistinctReposScored(t *testing.T) {
+	api := newFakeAPI(t)
+	for i := 1; i <= 3; i++ {
+		repo := fmt.Sprintf("github.com/o/r%d", i)
+		api.serve(repo, resultJSON(repo, sha(i), 5, nil))
+	}
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL})
+
+	var wg sync.WaitGroup
+	for _, repo := range []string{"o/r1", "o/r2", "O/R1", "o/r3", "o/r2", "o/r1", "o/missing"} {
+		wg.Add(1)
+		go func(repo string) {
+			defer wg.Done()
+			get(t, app, "/msapi/scorecard/github.com/"+repo)
+		}(repo)
+	}
+	wg.Wait()
+
+	var stats repoStats
+	_, body := get(t, app, "/stats")
+	mustJSON(t, body, &stats)
+	if stats.DistinctRepos != 3 || stats.Saturated {
+		t.Errorf("stats %+v, want 3 repos, the unscored one not counted", stats)
+	}
+
+	_, body = get(t, app, "/metrics")
+	var metrics map[string]json.RawMessage
+	mustJSON(t, body, &metrics)
+	var published repoStats
+	mustJSON(t, string(metrics["scorecard_repos"]), &published)
+	if published.DistinctRepos != 3 {
+		t.Errorf("/metrics scorecard_repos %+v", published)
+	}
+}
+
+func TestStatsSaturateAtTheLimit(t *testing.T) {
+	app := newTestApp(t, map[string]string{"DISTINCT_REPOS_LIMIT": "2"})
+	for _, repo := range []string{"a", "b", "a", "c", "d"} {
+		recordScored("github.com/o/" + repo)
+	}
+
+	status, body := get(t, app, "/stats")
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d", status)
+	}
+	var stats repoStats
+	mustJSON(t, body, &stats)
+	if stats.DistinctRepos != 2 || !stats.Saturated || stats.Since.IsZero() {
+		t.Errorf("stats %+v, want 2 repos and saturated", stats)
+	}
+}
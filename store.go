@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"expvar"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/arangodb/go-driver/v2/arangodb"
+	arangoshared "github.com/arangodb/go-driver/v2/arangodb/shared"
+	"github.com/ortelius/scec-commons/database"
+	ossf "github.com/ossf/scorecard/v5/pkg/scorecard"
+	"go.uber.org/zap"
+)
+
+const (
+	// scorecardCollection is the ArangoDB collection of the stored scorecards
+	scorecardCollection = "scorecards"
+	// snapshotCollection is the ArangoDB collection of the dated snapshots of every repo
+	snapshotCollection = "scorecard_snapshots"
+	// storeTimeout bounds a single read or write of the store
+	storeTimeout = 10 * time.Second
+)
+
+var (
+	errStoreDisabled    = errors.New("scorecards are not stored, set PERSIST_SCORECARDS to store them")
+	errStoreUnavailable = errors.New("the scorecard store failed")
+)
+
+// storeMetrics counts the scorecards saved to ArangoDB, the failed writes and reads, and
+// the lookups answered from the store
+var storeMetrics = expvar.NewMap("scorecard_store")
+
+// storedScorecard is the document of a scorecard in ArangoDB, keyed by repo and commit.
+// The aggregate and the score of every check by name are kept beside the result so they
+// can be queried.
+type storedScorecard struct {
+	Key    string                      `json:"_key"`
+	Repo   string                      `json:"repo"`
+	Commit string                      `json:"commit"`
+	Date   string                      `json:"date"`
+	Score  float64                     `json:"score"`
+	Checks map[string]int              `json:"checks"`
+	Source string                      `json:"source"`
+	Stored time.Time                   `json:"stored"`
+	Result *ossf.JSONScorecardResultV2 `json:"result"`
+}
+
+// storedSnapshot is the document of a snapshot in ArangoDB, one per repo, commit and
+// analysis date, so the history of a repo outlives the scorecard kept for each commit
+type storedSnapshot struct {
+	Key  string `json:"_key"`
+	Repo string `json:"repo"`
+	snapshot
+}
+
+// scorecardStore persists the fetched scorecards so they survive restarts and a scorecard
+// of a pinned commit is looked up once. Every scorecard is kept as a dated snapshot too,
+// for the history of its repo.
+type scorecardStore interface {
+	// save stores the scorecard of the commit it was computed for and its snapshot
+	save(repo, source string, result *ossf.JSONScorecardResultV2)
+	// load is the stored scorecard of a repo's commit, if there is one
+	load(repo, commit string) (storedScorecard, bool)
+	// history is a page of the stored snapshots of a repo, oldest first, or only the latest
+	// analysed no later than until when it is set, and how many there are in all
+	history(repo, until string, offset, limit int) ([]snapshot, int, error)
+	// query is a page of the latest stored scorecard of every repo that passes filter
+	query(filter scorecardFilter, offset, limit int) ([]storedSummary, int, error)
+	// baseline is the latest scorecard of repo stored for a commit other than commit and
+	// analysed no later than date, if there is one
+	baseline(repo, commit, date string) (storedScorecard, bool, error)
+}
+
+// store is set by main when PERSIST_SCORECARDS is set; nil, the default, stores nothing
+var store scorecardStore
+
+// arangoStore keeps the scorecards in ArangoDB, as the other scec services persist their data
+type arangoStore struct {
+	db        arangodb.Database
+	col       arangodb.Collection
+	snapshots arangodb.Collection
+}
+
+// openStore connects to ArangoDB through scec-commons, which waits for it to come up, and
+// creates the scorecards collection and its repo index when missing
+func openStore() (scorecardStore, error) {
+	db := database.InitializeDatabase().Database
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	col, err := ensureCollection(ctx, db, scorecardCollection)
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := ensureCollection(ctx, db, snapshotCollection)
+	if err != nil {
+		return nil, err
+	}
+	return &arangoStore{db: db, col: col, snapshots: snapshots}, nil
+}
+
+// ensureCollection opens the collection name, creating it when missing, with an index of
+// the analysis dates of each repo
+func ensureCollection(ctx context.Context, db arangodb.Database, name string) (arangodb.Collection, error) {
+	col, err := db.Collection(ctx, name)
+	if arangoshared.IsNotFound(err) {
+		col, err = db.CreateCollection(ctx, name, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	unique, sparse := false, false
+	options := arangodb.CreatePersistentIndexOptions{Name: name + "_repo", Unique: &unique, Sparse: &sparse}
+	if _, _, err := col.EnsurePersistentIndex(ctx, []string{"repo", "date"}, &options); err != nil {
+		return nil, err
+	}
+	return col, nil
+}
+
+// storeKey is the document key of a repo's commit, hashed as keys cannot hold slashes
+func storeKey(repo, commit string) string {
+	sum := sha256.Sum256([]byte(repoCachePrefix(repo) + commit))
+	return hex.EncodeToString(sum[:])
+}
+
+// save replaces any earlier copy of the scorecard. It runs in the background; a failure is
+// logged and counted.
+func (s *arangoStore) save(repo, source string, result *ossf.JSONScorecardResultV2) {
+	if result == nil || result.Repo.Commit == "" {
+		return
+	}
+
+	snap := newSnapshot(result)
+	doc := storedScorecard{
+		Key:    storeKey(repo, result.Repo.Commit),
+		Repo:   repo,
+		Commit: result.Repo.Commit,
+		Date:   snap.Date,
+		Score:  snap.Score,
+		Checks: snap.Checks,
+		Source: source,
+		Stored: time.Now().UTC(),
+		Result: result,
+	}
+	dated := storedSnapshot{Key: storeKey(repo, snap.Commit+"|"+snap.Date), Repo: repo, snapshot: snap}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+		defer cancel()
+		mode := arangodb.CollectionDocumentCreateOverwriteModeReplace
+		options := &arangodb.CollectionDocumentCreateOptions{OverwriteMode: &mode}
+		if _, err := s.col.CreateDocumentWithOptions(ctx, doc, options); err != nil {
+			storeMetrics.Add("write_failed", 1)
+			logger.Warn("storing a scorecard failed", zap.String("repo", repo), zap.String("commit", doc.Commit), zap.Error(err))
+			return
+		}
+		if _, err := s.snapshots.CreateDocumentWithOptions(ctx, dated, options); err != nil {
+			storeMetrics.Add("write_failed", 1)
+			logger.Warn("storing a snapshot failed", zap.String("repo", repo), zap.String("commit", doc.Commit), zap.Error(err))
+			return
+		}
+		storeMetrics.Add("saved", 1)
+	}()
+}
+
+// load counts a failed read, a missing document is not one
+func (s *arangoStore) load(repo, commit string) (storedScorecard, bool) {
+	if commit == "" {
+		return storedScorecard{}, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	var doc storedScorecard
+	if _, err := s.col.ReadDocument(ctx, storeKey(repo, commit), &doc); err != nil {
+		if !arangoshared.IsNotFound(err) {
+			storeMetrics.Add("read_failed", 1)
+			logger.Warn("reading a stored scorecard failed", zap.String("repo", repo), zap.String("commit", commit), zap.Error(err))
+		}
+		return storedScorecard{}, false
+	}
+	if doc.Result == nil || doc.Repo != repo {
+		return storedScorecard{}, false
+	}
+	storeMetrics.Add("hits", 1)
+	return doc, true
+}
+
+// history reads the snapshots of the repo's index, ordered by analysis date
+func (s *arangoStore) history(repo, until string, offset, limit int) ([]snapshot, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	query := `FOR s IN @@col FILTER s.repo == @repo SORT s.date LIMIT @offset, @limit RETURN s`
+	bindVars := map[string]any{"@col": snapshotCollection, "repo": repo, "offset": offset, "limit": limit}
+	if until != "" {
+		query = `FOR s IN @@col FILTER s.repo == @repo AND s.date <= @until SORT s.date DESC LIMIT 1 LIMIT @offset, @limit RETURN s`
+		bindVars["until"] = until
+	}
+	options := &arangodb.QueryOptions{BindVars: bindVars, Options: arangodb.QuerySubOptions{FullCount: true}}
+	cursor, err := s.db.Query(ctx, query, options)
+	if err != nil {
+		storeMetrics.Add("read_failed", 1)
+		return nil, 0, err
+	}
+	defer cursor.Close()
+
+	snapshots := []snapshot{}
+	for cursor.HasMore() {
+		var doc storedSnapshot
+		if _, err := cursor.ReadDocument(ctx, &doc); err != nil {
+			storeMetrics.Add("read_failed", 1)
+			return nil, 0, err
+		}
+		snapshots = append(snapshots, doc.snapshot)
+	}
+	return snapshots, int(cursor.Statistics().FullCountInt), nil
+}
+
+// storedSummary is a stored scorecard as the query endpoint lists it, without the OpenSSF result
+type storedSummary struct {
+	Repo   string         `json:"repo"`
+	Commit string         `json:"commit"`
+	Date   string         `json:"date"`
+	Score  float64        `json:"score"`
+	Checks map[string]int `json:"checks"`
+	Source string         `json:"source"`
+	Stored time.Time      `json:"stored"`
+}
+
+// scorecardFilter selects the stored scorecards a query lists; an empty org or a nil bound
+// is not applied.
+// The check bounds apply to the score of check, and an inconclusive check, scored -1,
+// never matches them.
+type scorecardFilter struct {
+	org      string // host/owner, lists only the repos under it
+	minScore *float64
+	maxScore *float64
+	check    string
+	checkLT  *int
+	checkGTE *int
+}
+
+// query lists the repos lowest aggregate first and also returns how many pass filter in all
+func (s *arangoStore) query(filter scorecardFilter, offset, limit int) ([]storedSummary, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	conditions := []string{}
+	bindVars := map[string]any{"@col": scorecardCollection, "offset": offset, "limit": limit}
+	if filter.org != "" {
+		conditions = append(conditions, "STARTS_WITH(latest.repo, @org)")
+		bindVars["org"] = filter.org + "/"
+	}
+	if filter.minScore != nil {
+		conditions = append(conditions, "latest.score >= @minScore")
+		bindVars["minScore"] = *filter.minScore
+	}
+	if filter.maxScore != nil {
+		conditions = append(conditions, "latest.score <= @maxScore")
+		bindVars["maxScore"] = *filter.maxScore
+	}
+	if filter.check != "" {
+		conditions = append(conditions, "HAS(latest.checks, @check) AND latest.checks[@check] >= 0")
+		bindVars["check"] = filter.check
+	}
+	if filter.checkLT != nil {
+		conditions = append(conditions, "latest.checks[@check] < @checkLT")
+		bindVars["checkLT"] = *filter.checkLT
+	}
+	if filter.checkGTE != nil {
+		conditions = append(conditions, "latest.checks[@check] >= @checkGTE")
+		bindVars["checkGTE"] = *filter.checkGTE
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "FILTER " + strings.Join(conditions, " AND ")
+	}
+
+	query := fmt.Sprintf(`FOR s IN @@col
+	COLLECT repo = s.repo INTO commits = s
+	LET latest = FIRST(FOR c IN commits SORT c.date DESC LIMIT 1 RETURN c)
+	%s
+	SORT latest.score, latest.repo
+	LIMIT @offset, @limit
+	RETURN KEEP(latest, "repo", "commit", "date", "score", "checks", "source", "stored")`, where)
+	options := &arangodb.QueryOptions{BindVars: bindVars, Options: arangodb.QuerySubOptions{FullCount: true}}
+	cursor, err := s.db.Query(ctx, query, options)
+	if err != nil {
+		storeMetrics.Add("read_failed", 1)
+		return nil, 0, fmt.Errorf("%w: %w", errStoreUnavailable, err)
+	}
+	defer cursor.Close()
+
+	summaries := []storedSummary{}
+	for cursor.HasMore() {
+		var summary storedSummary
+		if _, err := cursor.ReadDocument(ctx, &summary); err != nil {
+			storeMetrics.Add("read_failed", 1)
+			return nil, 0, fmt.Errorf("%w: %w", errStoreUnavailable, err)
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, int(cursor.Statistics().FullCountInt), nil
+}
+
+// baseline finds the previous scorecard through the repo and date index
+func (s *arangoStore) baseline(repo, commit, date string) (storedScorecard, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	query := `FOR s IN @@col FILTER s.repo == @repo AND s.date <= @date AND s.commit != @commit SORT s.date DESC LIMIT 1 RETURN s`
+	bindVars := map[string]any{"@col": scorecardCollection, "repo": repo, "commit": commit, "date": date}
+	cursor, err := s.db.Query(ctx, query, &arangodb.QueryOptions{BindVars: bindVars})
+	if err != nil {
+		storeMetrics.Add("read_failed", 1)
+		return storedScorecard{}, false, fmt.Errorf("%w: %w", errStoreUnavailable, err)
+	}
+	defer cursor.Close()
+
+	if !cursor.HasMore() {
+		return storedScorecard{}, false, nil
+	}
+	var doc storedScorecard
+	if _, err := cursor.ReadDocument(ctx, &doc); err != nil {
+		storeMetrics.Add("read_failed", 1)
+		return storedScorecard{}, false, fmt.Errorf("%w: %w", errStoreUnavailable, err)
+	}
+	return doc, doc.Result != nil, nil
+}
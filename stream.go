@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// streamEvent is the data of the started, api-miss, cli-started and done events
+type streamEvent struct {
+	Repo   string `json:"repo"`
+	Source string `json:"source,omitempty"`
+}
+
+// streamScorecard godoc
+// @Summary Stream the OSSF scorecard lookup for a repo
+// @Description Server-Sent Events reporting each lookup stage (started, api-miss, cli-started, done)
+// @Description followed by the scorecard in a result event, or an error event. A cached or stored scorecard
+// @Description is sent without asking the upstream, so only started and done come before it.
+// @Tags scorecard
+// @Produce text/event-stream
+// @Param commit query string false "commit sha"
+// @Param ref query string false "branch or tag to score, resolved to its commit through the forge; REF_COMMIT_CONFLICT decides when it disagrees with commit"
+// @Param latest query bool false "ignore commit and ref and return the latest, unpinned scorecard"
+// @Param prefer query string false "which source to try first; defaults to PREFER_SOURCE or api" Enums(api, cli)
+// @Param X-Repo-Token header string false "forge token used instead of the configured one to scan a private repo; Authorization: Bearer also works"
+// @Success 200
+// @Failure 400
+// @Router /msapi/scorecard/stream/:key [get]
+func streamScorecard(c *fiber.Ctx) error {
+	prefer, err := preference(c)
+	if err != nil {
+		return err
+	}
+
+	opts, err := parseResponseOptions(c)
+	if err != nil {
+		return err
+	}
+
+	githubURL, err := lookupRepo(c)
+	if err != nil {
+		return sendLookupError(c, err)
+	}
+
+	commitSha, err := requestedRevision(c, githubURL)
+	if err != nil {
+		return sendLookupError(c, err)
+	}
+
+	// The stream is written after the handler has returned and Fiber has released c, so the
+	// writer must not touch c. It gets copies, as c's strings point into its reused buffers.
+	req := lookupRequest{
+		repo:   strings.Clone(githubURL),
+		commit: strings.Clone(commitSha),
+		prefer: strings.Clone(prefer),
+		token:  strings.Clone(requestToken(c)),
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		send := func(event string, data any) {
+			body, _ := json.Marshal(data)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, body)
+			_ = w.Flush()
+		}
+
+		send("started", streamEvent{Repo: req.repo})
+
+		start := time.Now()
+		req.progress = func(stage string) { send(stage, streamEvent{Repo: req.repo}) }
+		result, source, err := coalescedLookup(req)
+		logSlowRequest(req.repo, source, time.Since(start))
+
+		send("done", streamEvent{Repo: req.repo, Source: source})
+
+		if err != nil {
+			_, code := lookupErrorStatus(err)
+			send("error", errorResponse{Code: code, Message: err.Error()})
+			return
+		}
+		recordScored(req.repo)
+		send("result", newResponse(result, req.commit, source, opts))
+	})
+	return nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// The stream is written after Fiber has released the request's Ctx, so the caller's token
+// must have been read before; reading it from the released Ctx crashed the service.
+func TestStreamUsesTheTokenReadBeforeTheStream(t *testing.T) {
+	api := newFakeAPI(t)
+	github := newFakeAPI(t)
+	github.serve("repos/a/b", `{"full_name":"a/b"}`)
+	app := newTestApp(t, map[string]string{
+		"SCORECARD_API_URLS": api.URL,
+		"GITHUB_API_URL":     github.URL,
+		"OUTBOUND_RETRIES":   "0",
+	})
+
+	for _, token := range []string{"first-token", "second-token"} {
+		req := httptest.NewRequest(fiber.MethodGet, "/msapi/scorecard/stream/github.com/a/b", nil)
+		req.Header.Set("X-Repo-Token", token)
+		status, body := doRequest(t, app, req)
+		if status != fiber.StatusOK {
+			t.Fatalf("status %d, body %s", status, body)
+		}
+
+		names, data := sseEvents(body)
+		if got := strings.Join(names, ","); got != "started,api-miss,done,error" {
+			t.Fatalf("events %s, body %s", got, body)
+		}
+		// the probe only tells an unscored repo from a missing one with the caller's token
+		if !strings.Contains(data["error"], "SCORECARD_NOT_COMPUTED") {
+			t.Errorf("error event %s", data["error"])
+		}
+		if got := github.header("repos/a/b").Get(fiber.HeaderAuthorization); got != "Bearer "+token {
+			t.Errorf("GitHub probe sent %q, want the token of the request", got)
+		}
+	}
+}
+
+func TestStreamEventsEndWithTheResult(t *testing.T) {
+	api := newFakeAPI(t)
+	api.serve("github.com/a/b", resultJSON("github.com/a/b", sha(1), 7.5, map[string]int{"Code-Review": 8}))
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL})
+
+	status, body := get(t, app, "/msapi/scorecard/stream/github.com/a/b")
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d", status)
+	}
+	names, data := sseEvents(body)
+	if got := strings.Join(names, ","); got != "started,done,result" {
+		t.Fatalf("events %s", got)
+	}
+	if !strings.Contains(data["done"], `"source":"api"`) {
+		t.Errorf("done event %s", data["done"])
+	}
+	var result scorecardResponse
+	mustJSON(t, data["result"], &result)
+	if result.Score != 7.5 || result.CodeReview != 8 {
+		t.Errorf("result %+v", result.Scorecard)
+	}
+}
+
+func TestStreamEventsForAScan(t *testing.T) {
+	api := newFakeAPI(t)
+	github := newFakeAPI(t)
+	github.serve("repos/a/b", `{"full_name":"a/b"}`)
+	stubScan(t, sha(1), map[string]int{"License": 10}, nil)
+	app := newTestApp(t, map[string]string{
+		"SCORECARD_API_URLS": api.URL,
+		"GITHUB_API_URL":     github.URL,
+		"GITHUB_TOKEN":       "token",
+	})
+
+	status, body := get(t, app, "/msapi/scorecard/stream/github.com/a/b?commit="+sha(1))
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d", status)
+	}
+	names, data := sseEvents(body)
+	if got := strings.Join(names, ","); got != "started,api-miss,cli-started,done,result" {
+		t.Fatalf("events %s, body %s", got, body)
+	}
+	if !strings.Contains(data["done"], `"source":"cli"`) {
+		t.Errorf("done event %s", data["done"])
+	}
+	var result scorecardResponse
+	mustJSON(t, data["result"], &result)
+	if result.License != 10 || result.ResolvedRef != "cli" {
+		t.Errorf("result %+v", result)
+	}
+}
+
+func TestStreamAnswersFromTheCache(t *testing.T) {
+	api := newFakeAPI(t)
+	api.serve("github.com/a/b", resultJSON("github.com/a/b", sha(1), 7.5, nil))
+	app := newTestApp(t, map[string]string{"SCORECARD_API_URLS": api.URL})
+
+	get(t, app, "/msapi/scorecard/github.com/a/b")
+	_, body := get(t, app, "/msapi/scorecard/stream/github.com/a/b")
+	if names, _ := sseEvents(body); strings.Join(names, ",") != "started,done,result" {
+		t.Fatalf("events %v, body %s", names, body)
+	}
+	if n := api.called("github.com/a/b"); n != 1 {
+		t.Errorf("upstream called %d times, want the stream answered from the cache", n)
+	}
+}
+
+func TestStreamAnswersFromTheStoreWhileReadOnly(t *testing.T) {
+	s := useMemoryStore(t)
+	app := newTestApp(t, map[string]string{"READ_ONLY": "true"})
+	s.save("github.com/a/b", sourceAPI, parseResult(t, resultJSON("github.com/a/b", sha(1), 6, nil)))
+
+	_, body := get(t, app, "/msapi/scorecard/stream/github.com/a/b?commit="+sha(1))
+	names, data := sseEvents(body)
+	if strings.Join(names, ",") != "started,done,result" {
+		t.Fatalf("events %v, body %s", names, body)
+	}
+	var result scorecardResponse
+	mustJSON(t, data["result"], &result)
+	if result.Score != 6 {
+		t.Errorf("result %+v", result.Scorecard)
+	}
+}
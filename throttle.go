@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"go.uber.org/zap"
+)
+
+// throttleMetrics counts the 429 answers of upstreams, the outbound calls held back because
+// of them and the seconds they were held
+var throttleMetrics = expvar.NewMap("scorecard_throttle")
+
+// throttle holds back the outbound calls to a host that answered 429 until the time its
+// Retry-After names, so the calls queued behind a rate limit go out once it lifts instead
+// of hammering the upstream. A call is held at most maxWait; one that would wait longer
+// fails at once.
+type throttle struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	maxWait time.Duration
+	until   map[string]time.Time
+}
+
+func newThrottle(maxWait time.Duration, now func() time.Time) *throttle {
+	return &throttle{now: now, maxWait: maxWait, until: make(map[string]time.Time)}
+}
+
+// wait blocks until host may be called again or ctx is done
+func (t *throttle) wait(ctx context.Context, host string) error {
+	t.mu.Lock()
+	delay := t.until[host].Sub(t.now())
+	t.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	if delay > t.maxWait {
+		return fmt.Errorf("%s is rate limiting for another %s", host, delay.Round(time.Second))
+	}
+
+	throttleMetrics.Add("delayed", 1)
+	throttleMetrics.AddFloat("delay_seconds", delay.Seconds())
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// hold records a 429 from host, held back for retryAfter when it has one
+func (t *throttle) hold(host string, retryAfter time.Duration) {
+	throttleMetrics.Add("throttled", 1)
+	logger.Warn("upstream is rate limiting", zap.String("host", host), zap.Duration("retry_after", retryAfter))
+	if retryAfter <= 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if until := t.now().Add(retryAfter); until.After(t.until[host]) {
+		t.until[host] = until
+	}
+}
+
+// beforeRequest is the resty middleware holding back calls to a throttled host
+func (t *throttle) beforeRequest(_ *resty.Client, req *resty.Request) error {
+	return t.wait(req.Context(), requestHost(req.URL))
+}
+
+// afterResponse is the resty middleware recording the 429 answers
+func (t *throttle) afterResponse(_ *resty.Client, resp *resty.Response) error {
+	if resp.StatusCode() == http.StatusTooManyRequests {
+		t.hold(requestHost(resp.Request.URL), parseRetryAfter(resp.Header().Get("Retry-After"), t.now()))
+	}
+	return nil
+}
+
+// requestHost is the host of a request url, or the url itself when it does not parse
+func requestHost(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return rawURL
+}
+
+// parseRetryAfter is the wait a Retry-After header asks for, in seconds or as an HTTP date,
+// or zero when it is missing or unreadable
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return max(date.Sub(now), 0)
+	}
+	return 0
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// selfSignedCert writes a certificate for 127.0.0.1 and its key to dir and returns the
+// paths and a pool that trusts the certificate
+func selfSignedCert(t *testing.T, dir string) (string, string, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "scec-scorecard test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return certFile, keyFile, pool
+}
+
+// serveTLS serves app on a local port the way main does with TLS configured, until the
+// test ends, and returns its address
+func serveTLS(t *testing.T, app *fiber.App, cfg *Config) string {
+	t.Helper()
+	tlsCfg, err := tlsConfig(cfg)
+	if err != nil || tlsCfg == nil {
+		t.Fatalf("tlsConfig: %v, %v", tlsCfg, err)
+	}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", tlsCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = app.Listener(ln) }()
+	t.Cleanup(func() { _ = app.Shutdown() })
+	return ln.Addr().String()
+}
+
+func TestServesTLSWithASelfSignedCertificate(t *testing.T) {
+	certFile, keyFile, pool := selfSignedCert(t, t.TempDir())
+	env := map[string]string{"TLS_CERT_FILE": certFile, "TLS_KEY_FILE": keyFile}
+	cfg := testConfig(t, env)
+	addr := serveTLS(t, newTestApp(t, env), cfg)
+
+	client := &http.Client{Timeout: 5 * time.Second, Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12, MaxVersion: tls.VersionTLS12},
+	}}
+	resp, err := client.Get("https://" + addr + "/health")
+	if err != nil {
+		t.Fatalf("TLS 1.2 request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != fiber.StatusOK {
+		t.Errorf("status %d", resp.StatusCode)
+	}
+	if resp.TLS == nil || resp.TLS.Version != tls.VersionTLS12 || !slices.Contains(defaultCipherSuites, resp.TLS.CipherSuite) {
+		t.Errorf("negotiated %+v, want TLS 1.2 with a default suite", resp.TLS)
+	}
+}
+
+func TestTLSMinVersionRefusesOlderClients(t *testing.T) {
+	certFile, keyFile, pool := selfSignedCert(t, t.TempDir())
+	env := map[string]string{"TLS_CERT_FILE": certFile, "TLS_KEY_FILE": keyFile, "TLS_MIN_VERSION": "1.3"}
+	addr := serveTLS(t, newTestApp(t, env), testConfig(t, env))
+
+	conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12, MaxVersion: tls.VersionTLS12})
+	if err == nil {
+		conn.Close()
+		t.Fatal("a TLS 1.2 handshake succeeded with TLS_MIN_VERSION=1.3")
+	}
+
+	conn, err = tls.Dial("tcp", addr, &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS13})
+	if err != nil {
+		t.Fatalf("TLS 1.3 handshake: %v", err)
+	}
+	defer conn.Close()
+	if conn.ConnectionState().Version != tls.VersionTLS13 {
+		t.Errorf("negotiated %x", conn.ConnectionState().Version)
+	}
+}
+
+func TestTLSSettings(t *testing.T) {
+	if cfg, err := tlsConfig(testConfig(t, nil)); cfg != nil || err != nil {
+		t.Errorf("without a key pair: %v, %v, want plain HTTP", cfg, err)
+	}
+	for name, value := range map[string]string{
+		"TLS_MIN_VERSION":   "1.1",
+		"TLS_CIPHER_SUITES": "TLS_RSA_WITH_RC4_128_SHA",
+	} {
+		if _, err := loadConfig(func(n string) string {
+			if n == name {
+				return value
+			}
+			return ""
+		}); err == nil {
+			t.Errorf("%s=%s was accepted", name, value)
+		}
+	}
+	if _, err := tlsConfig(testConfig(t, map[string]string{"TLS_CERT_FILE": "missing.pem", "TLS_KEY_FILE": "missing.pem"})); err == nil {
+		t.Error("a missing key pair was accepted")
+	}
+}
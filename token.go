@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var errRequestTokenInvalid = errors.New("the forge rejected the token passed with the request; it is invalid, expired or lacks access to the repository")
+
+// requestToken is the forge token a caller passed for scanning a private repo, from
+// X-Repo-Token or an Authorization header with a Bearer or token scheme. It replaces the
+// configured credentials for that one lookup and is never logged, cached or shared.
+func requestToken(c *fiber.Ctx) string {
+	if token := strings.TrimSpace(c.Get("X-Repo-Token")); token != "" {
+		return token
+	}
+
+	scheme, token, found := strings.Cut(strings.TrimSpace(c.Get(fiber.HeaderAuthorization)), " ")
+	if !found || (!strings.EqualFold(scheme, "bearer") && !strings.EqualFold(scheme, "token")) {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// tokenTransport authenticates every GitHub API call of a scan with the caller's token
+type tokenTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (t *tokenTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", "Bearer "+t.token)
+	return t.base.RoundTrip(r)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"runtime/debug"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const scorecardModule = "github.com/ossf/scorecard/v5"
+
+// versionInfo is the body returned by the version endpoint. Library is the scorecard module
+// the service was built with, which both decodes API results and runs the scans.
+type versionInfo struct {
+	Library string `json:"library"`
+}
+
+// versions is read from the build info once
+var versions = versionInfo{Library: libraryVersion()}
+
+// libraryVersion reads the scorecard module version from the build info
+func libraryVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == scorecardModule {
+			return dep.Version
+		}
+	}
+	return ""
+}
+
+// VersionHandler reports the scorecard library version in use
+func VersionHandler(c *fiber.Ctx) error {
+	return c.JSON(versions)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// requiredVersion is the version of module go.mod requires
+func requiredVersion(t *testing.T, module string) string {
+	t.Helper()
+	data, err := os.ReadFile("go.mod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(line), "require "))
+		if len(fields) >= 2 && fields[0] == module {
+			return fields[1]
+		}
+	}
+	t.Fatalf("go.mod does not require %s", module)
+	return ""
+}
+
+func TestVersionReportsTheLibraryInGoMod(t *testing.T) {
+	app := newTestApp(t, nil)
+	status, body := get(t, app, "/version")
+	if status != fiber.StatusOK {
+		t.Fatalf("status %d", status)
+	}
+	var got versionInfo
+	mustJSON(t, body, &got)
+	if want := requiredVersion(t, scorecardModule); got.Library != want {
+		t.Errorf("library %q, want %q from go.mod", got.Library, want)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// startWarming fetches the scorecards of the WARM_REPOS and WARM_REPOS_FILE repos into the
+// cache in the background, once at startup and then every WARM_INTERVAL, so the first
+// lookups of hot repos after a deploy do not wait on the upstream
+func startWarming(cfg *Config) {
+	if cfg.WarmRepos == "" && cfg.WarmReposFile == "" {
+		return
+	}
+
+	go func() {
+		warmCache(cfg)
+		if cfg.WarmInterval <= 0 {
+			return
+		}
+		for range time.Tick(cfg.WarmInterval) {
+			warmCache(cfg)
+		}
+	}()
+}
+
+// warmCache refreshes the cached scorecard of every repo to warm, BATCH_CONCURRENCY at a
+// time. Nothing is fetched in read-only mode.
+func warmCache(cfg *Config) {
+	if readOnly.Load() {
+		return
+	}
+
+	repos, err := warmRepos(cfg)
+	if err != nil {
+		logger.Warn("reading WARM_REPOS_FILE failed", zap.String("path", cfg.WarmReposFile), zap.Error(err))
+	}
+
+	start := time.Now()
+	var warmed atomic.Int64
+	runConcurrently(len(repos), cfg.BatchConcurrency, func(i int) {
+		if _, _, err := coalescedLookup(lookupRequest{repo: repos[i], prefer: cfg.PreferSource, refresh: true}); err != nil {
+			logger.Warn("warming the cache failed", zap.String("repo", repos[i]), zap.Error(err))
+			return
+		}
+		warmed.Add(1)
+	})
+	logger.Info("cache warmed", zap.Int("repos", len(repos)), zap.Int64("warmed", warmed.Load()), zap.Duration("took", time.Since(start)))
+}
+
+// warmRepos is the normalized, valid and distinct repos of WARM_REPOS and WARM_REPOS_FILE.
+// The file lists a repo a line, or several comma separated, and # starts a comment. The
+// repos of WARM_REPOS are returned even when the file cannot be read.
+func warmRepos(cfg *Config) ([]string, error) {
+	list := cfg.WarmRepos
+	var err error
+	if cfg.WarmReposFile != "" {
+		var data []byte
+		if data, err = os.ReadFile(cfg.WarmReposFile); err == nil {
+			list += "\n" + string(data)
+		}
+	}
+
+	var repos []string
+	seen := map[string]bool{}
+	for _, line := range strings.Split(list, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		for _, repo := range compareRepos(line) {
+			if validateRepoURL(repo) != nil {
+				logger.Warn("skipping an invalid repo to warm", zap.String("repo", repo))
+				continue
+			}
+			if !seen[repo] {
+				seen[repo] = true
+				repos = append(repos, repo)
+			}
+		}
+	}
+	return repos, err
+}